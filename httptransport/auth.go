@@ -0,0 +1,19 @@
+package httptransport
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+// requireCredentials rejects callers the server can't resolve Metoro credentials for. Callers either send their
+// own token in the Authorization header or rely on a token the server was configured with.
+func requireCredentials(c *gin.Context) {
+	if _, err := utils.ResolveCredentials(c.Request.Header); err != nil {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,126 @@
+package httptransport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+const (
+	ssePath              = "/sse"
+	sseMessagesPath      = "/messages"
+	sseKeepAliveInterval = 30 * time.Second
+)
+
+// sseSession is one client connected over the legacy HTTP+SSE transport. Responses to the messages it posts
+// are written to its event stream.
+type sseSession struct {
+	id           string
+	authHeader   string
+	organization string
+	ctx          context.Context
+	outgoing     chan []byte
+}
+
+func (s *sseSession) deliver(payload []byte) {
+	select {
+	case s.outgoing <- payload:
+	case <-s.ctx.Done():
+	}
+}
+
+func (t *Transport) handleSSEStream(c *gin.Context) {
+	session := &sseSession{
+		id:           uuid.NewString(),
+		authHeader:   c.GetHeader("Authorization"),
+		organization: c.GetHeader(utils.METORO_ORGANIZATION_HEADER),
+		ctx:          c.Request.Context(),
+		outgoing:     make(chan []byte),
+	}
+
+	t.mu.Lock()
+	t.sessions[session.id] = session
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.sessions, session.id)
+		t.mu.Unlock()
+	}()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	if err := writeSSEEvent(c, "endpoint", fmt.Sprintf("%s?sessionId=%s", sseMessagesPath, session.id)); err != nil {
+		t.reportError(err)
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case payload := <-session.outgoing:
+			if err := writeSSEEvent(c, "message", string(payload)); err != nil {
+				t.reportError(err)
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-session.ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *Transport) handleSSEMessage(c *gin.Context) {
+	sessionID := c.Query("sessionId")
+
+	t.mu.RLock()
+	session, ok := t.sessions[sessionID]
+	t.mu.RUnlock()
+	if !ok {
+		c.String(http.StatusNotFound, "unknown session: %s", sessionID)
+		return
+	}
+
+	// Clients are not required to repeat credentials on every post, the session keeps the ones it opened with.
+	if c.GetHeader("Authorization") == "" && session.authHeader != "" {
+		c.Request.Header.Set("Authorization", session.authHeader)
+	}
+	if c.GetHeader(utils.METORO_ORGANIZATION_HEADER) == "" && session.organization != "" {
+		c.Request.Header.Set(utils.METORO_ORGANIZATION_HEADER, session.organization)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxMessageSize))
+	if err != nil {
+		c.String(http.StatusBadRequest, "failed to read request body: %v", err)
+		return
+	}
+
+	if _, err := t.dispatch(requestContext(session.ctx, c), body, session.deliver); err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.String(http.StatusAccepted, "Accepted")
+}
+
+func writeSSEEvent(c *gin.Context, event string, data string) error {
+	if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,47 @@
+package httptransport
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const streamablePath = "/mcp"
+
+// handleStreamablePost implements the POST side of the MCP Streamable HTTP transport. Every request gets a
+// single application/json response, notifications are acknowledged with 202 Accepted.
+func (t *Transport) handleStreamablePost(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxMessageSize))
+	if err != nil {
+		c.String(http.StatusBadRequest, "failed to read request body: %v", err)
+		return
+	}
+
+	// Buffered so the server never blocks on a caller that has already gone away.
+	responses := make(chan []byte, 1)
+	isRequest, err := t.dispatch(requestContext(c.Request.Context(), c), body, func(payload []byte) {
+		responses <- payload
+	})
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if !isRequest {
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	select {
+	case payload := <-responses:
+		c.Data(http.StatusOK, "application/json", payload)
+	case <-c.Request.Context().Done():
+	}
+}
+
+// handleStreamableMethodNotAllowed rejects GET and DELETE on the streamable endpoint. The server is stateless:
+// it never opens a standalone event stream and has no sessions to terminate.
+func handleStreamableMethodNotAllowed(c *gin.Context) {
+	c.Header("Allow", http.MethodPost)
+	c.String(http.StatusMethodNotAllowed, "Only POST method is supported")
+}
@@ -0,0 +1,218 @@
+package httptransport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+const (
+	// ginContextKey is the context key utils.GetAPIRequirementsFromRequest reads the caller's request from.
+	ginContextKey = "ginContext"
+
+	maxMessageSize = 4 * 1024 * 1024
+)
+
+// Transport serves a single mcp-golang server over HTTP. It supports both the
+// Streamable HTTP transport (a single /mcp endpoint) and the legacy HTTP+SSE
+// transport (an /sse event stream plus a /messages endpoint).
+//
+// Incoming request ids are rewritten to ids unique across all callers before
+// they reach the MCP server, so concurrent clients cannot collide, and the
+// original id is restored on the response.
+type Transport struct {
+	mu             sync.RWMutex
+	messageHandler func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+	errorHandler   func(error)
+	closeHandler   func()
+
+	nextID   atomic.Int64
+	pending  map[transport.RequestId]*pendingRequest
+	sessions map[string]*sseSession
+}
+
+type pendingRequest struct {
+	originalID json.RawMessage
+	deliver    func(payload []byte)
+}
+
+type incomingMessage struct {
+	ID      json.RawMessage `json:"id"`
+	Jsonrpc string          `json:"jsonrpc"`
+	Method  *string         `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+func NewTransport() *Transport {
+	return &Transport{
+		pending:  make(map[transport.RequestId]*pendingRequest),
+		sessions: make(map[string]*sseSession),
+	}
+}
+
+// Start implements transport.Transport. Requests arrive through the gin routes so there is nothing to start.
+func (t *Transport) Start(ctx context.Context) error {
+	return nil
+}
+
+// Send implements transport.Transport. Responses are routed back to the HTTP request or SSE session they
+// belong to. Server initiated messages are dropped as there is no standalone stream to deliver them on.
+func (t *Transport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	var id transport.RequestId
+	switch message.Type {
+	case transport.BaseMessageTypeJSONRPCResponseType:
+		id = message.JsonRpcResponse.Id
+	case transport.BaseMessageTypeJSONRPCErrorType:
+		id = message.JsonRpcError.Id
+	default:
+		return nil
+	}
+
+	t.mu.Lock()
+	request, ok := t.pending[id]
+	delete(t.pending, id)
+	t.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no pending request found for id: %d", id)
+	}
+
+	payload, err := restoreMessageID(message, request.originalID)
+	if err != nil {
+		return err
+	}
+
+	request.deliver(payload)
+	return nil
+}
+
+// Close implements transport.Transport
+func (t *Transport) Close() error {
+	t.mu.RLock()
+	handler := t.closeHandler
+	t.mu.RUnlock()
+
+	if handler != nil {
+		handler()
+	}
+	return nil
+}
+
+// SetCloseHandler implements transport.Transport
+func (t *Transport) SetCloseHandler(handler func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeHandler = handler
+}
+
+// SetErrorHandler implements transport.Transport
+func (t *Transport) SetErrorHandler(handler func(error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errorHandler = handler
+}
+
+// SetMessageHandler implements transport.Transport
+func (t *Transport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messageHandler = handler
+}
+
+// Register adds the Streamable HTTP and legacy SSE routes to the given router.
+func (t *Transport) Register(router gin.IRouter) {
+	router.POST(streamablePath, requireCredentials, t.handleStreamablePost)
+	router.GET(streamablePath, handleStreamableMethodNotAllowed)
+	router.DELETE(streamablePath, handleStreamableMethodNotAllowed)
+
+	router.GET(ssePath, requireCredentials, t.handleSSEStream)
+	router.POST(sseMessagesPath, t.handleSSEMessage)
+}
+
+// dispatch hands one incoming JSON-RPC message to the MCP server. For requests, deliver is called with the
+// serialized response once the server produces it and true is returned. Notifications and client responses
+// have no reply so false is returned.
+func (t *Transport) dispatch(ctx context.Context, body []byte, deliver func(payload []byte)) (bool, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return false, fmt.Errorf("JSON-RPC batches are not supported")
+	}
+
+	var message incomingMessage
+	if err := json.Unmarshal(trimmed, &message); err != nil {
+		return false, fmt.Errorf("invalid JSON-RPC message: %v", err)
+	}
+
+	t.mu.RLock()
+	handler := t.messageHandler
+	t.mu.RUnlock()
+	if handler == nil {
+		return false, fmt.Errorf("transport is not connected to a server")
+	}
+
+	if message.Method == nil {
+		// A response to a server initiated request. The server never issues any so there is nothing to route.
+		return false, nil
+	}
+
+	if len(message.ID) == 0 || string(message.ID) == "null" {
+		handler(ctx, transport.NewBaseMessageNotification(&transport.BaseJSONRPCNotification{
+			Jsonrpc: message.Jsonrpc,
+			Method:  *message.Method,
+			Params:  message.Params,
+		}))
+		return false, nil
+	}
+
+	id := transport.RequestId(t.nextID.Add(1))
+	t.mu.Lock()
+	t.pending[id] = &pendingRequest{
+		originalID: message.ID,
+		deliver:    deliver,
+	}
+	t.mu.Unlock()
+
+	handler(ctx, transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{
+		Id:      id,
+		Jsonrpc: message.Jsonrpc,
+		Method:  *message.Method,
+		Params:  message.Params,
+	}))
+	return true, nil
+}
+
+func (t *Transport) reportError(err error) {
+	t.mu.RLock()
+	handler := t.errorHandler
+	t.mu.RUnlock()
+
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// requestContext builds the context a tool handler runs with. The gin context is copied because the handler
+// can outlive the HTTP request that started it.
+func requestContext(parent context.Context, c *gin.Context) context.Context {
+	return context.WithValue(parent, ginContextKey, c.Copy())
+}
+
+func restoreMessageID(message *transport.BaseJsonRpcMessage, originalID json.RawMessage) ([]byte, error) {
+	serialized, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(serialized, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	fields["id"] = originalID
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,191 @@
+package httptransport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+type whoAmIArgs struct{}
+
+func whoAmIHandler(ctx context.Context, _ whoAmIArgs) (*mcpgolang.ToolResponse, error) {
+	ginContext, ok := ctx.Value(ginContextKey).(*gin.Context)
+	if !ok {
+		return mcpgolang.NewToolResponse(mcpgolang.NewTextContent("no gin context")), nil
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(ginContext.GetHeader("Authorization"))), nil
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv(utils.METORO_API_URL_ENV_VAR, "http://metoro.test")
+	t.Setenv(utils.METORO_AUTH_TOKEN_ENV_VAR, "")
+	t.Setenv(utils.METORO_AUTH_TOKEN_FILE_ENV_VAR, "")
+	t.Setenv(utils.METORO_ORGANIZATIONS_FILE_ENV_VAR, "")
+
+	mcpTransport := NewTransport()
+	mcpServer := mcpgolang.NewServer(mcpTransport)
+	if err := mcpServer.RegisterTool("whoami", "Returns the caller's Authorization header", whoAmIHandler); err != nil {
+		t.Fatalf("failed to register tool: %v", err)
+	}
+	if err := mcpServer.Serve(); err != nil {
+		t.Fatalf("failed to serve: %v", err)
+	}
+
+	router := gin.New()
+	mcpTransport.Register(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func postJSON(t *testing.T, url string, authHeader string, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	return resp
+}
+
+const whoAmICall = `{"jsonrpc":"2.0","id":"call-1","method":"tools/call","params":{"name":"whoami","arguments":{}}}`
+
+func assertWhoAmIResponse(t *testing.T, payload []byte, expectedAuth string) {
+	t.Helper()
+	var response struct {
+		ID     string `json:"id"`
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(payload, &response); err != nil {
+		t.Fatalf("failed to decode response %q: %v", string(payload), err)
+	}
+	if response.ID != "call-1" {
+		t.Fatalf("expected original request id to be restored, got %q", response.ID)
+	}
+	if len(response.Result.Content) != 1 || response.Result.Content[0].Text != expectedAuth {
+		t.Fatalf("expected tool to see Authorization %q, got %s", expectedAuth, string(payload))
+	}
+}
+
+func TestStreamablePostForwardsAuthorizationAndRestoresID(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := postJSON(t, server.URL+streamablePath, "Bearer team-token", whoAmICall)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var payload json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	assertWhoAmIResponse(t, payload, "Bearer team-token")
+}
+
+func TestStreamablePostRequiresCredentials(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := postJSON(t, server.URL+streamablePath, "", whoAmICall)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestStreamablePostAcceptsNotifications(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := postJSON(t, server.URL+streamablePath, "Bearer team-token", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+}
+
+func TestSSESessionUsesCredentialsFromStream(t *testing.T) {
+	server := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+ssePath, nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer session-token")
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open sse stream: %v", err)
+	}
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", stream.StatusCode)
+	}
+
+	reader := bufio.NewReader(stream.Body)
+	event, data := readSSEEvent(t, reader)
+	if event != "endpoint" || !strings.HasPrefix(data, sseMessagesPath+"?sessionId=") {
+		t.Fatalf("unexpected endpoint event %q: %q", event, data)
+	}
+
+	resp := postJSON(t, server.URL+data, "", whoAmICall)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+
+	event, data = readSSEEvent(t, reader)
+	if event != "message" {
+		t.Fatalf("expected message event, got %q", event)
+	}
+	assertWhoAmIResponse(t, []byte(data), "Bearer session-token")
+}
+
+func TestSSEMessageRejectsUnknownSession(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := postJSON(t, server.URL+sseMessagesPath+"?sessionId=missing", "Bearer team-token", whoAmICall)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func readSSEEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read sse stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
@@ -1,17 +1,51 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	mcpgolang "github.com/metoro-io/mcp-golang"
 	"github.com/metoro-io/mcp-golang/transport/stdio"
+	"github.com/metoro-io/metoro-mcp-server/httptransport"
 	"github.com/metoro-io/metoro-mcp-server/resources"
 	"github.com/metoro-io/metoro-mcp-server/tools"
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
+const (
+	transportEnvVar   = "METORO_MCP_TRANSPORT"
+	httpAddrEnvVar    = "METORO_MCP_HTTP_ADDR"
+	stdioTransport    = "stdio"
+	httpTransport     = "http"
+	defaultHTTPAddr   = ":8081"
+	shutdownTimeout   = 10 * time.Second
+	readHeaderTimeout = 10 * time.Second
+)
+
 func main() {
+	transportMode := flag.String("transport", getEnvOrDefault(transportEnvVar, stdioTransport), "Transport to serve MCP over: stdio or http. In http mode both Streamable HTTP (/mcp) and legacy SSE (/sse) are served.")
+	httpAddr := flag.String("http-addr", getEnvOrDefault(httpAddrEnvVar, defaultHTTPAddr), "Address to listen on when the transport is http.")
+	flag.Parse()
+
+	switch *transportMode {
+	case stdioTransport:
+		runStdioServer()
+	case httpTransport:
+		runHTTPServer(*httpAddr)
+	default:
+		panic(fmt.Errorf("unknown transport %q, must be one of %s or %s", *transportMode, stdioTransport, httpTransport))
+	}
+}
+
+func runStdioServer() {
 	// Check if the appropriate environment variables are set
 	if err := checkEnvVars(); err != nil {
 		panic(err)
@@ -20,7 +54,66 @@ func main() {
 	done := make(chan struct{})
 
 	mcpServer := mcpgolang.NewServer(stdio.NewStdioServerTransport())
+	registerToolsAndResources(mcpServer)
+
+	err := mcpServer.Serve()
+	if err != nil {
+		panic(err)
+	}
 
+	<-done
+}
+
+// runHTTPServer serves MCP over the network. Each caller authenticates with their own Metoro token in the
+// Authorization header, which is forwarded to the Metoro API for every tool call they make, unless the server
+// was configured with a token of its own.
+func runHTTPServer(addr string) {
+	// Tokens may come from callers so only the organisations file, if any, can be checked up front.
+	if _, err := utils.LoadOrganizationsConfig(); err != nil {
+		panic(err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+
+	mcpTransport := httptransport.NewTransport()
+	mcpServer := mcpgolang.NewServer(mcpTransport)
+	registerToolsAndResources(mcpServer)
+
+	err := mcpServer.Serve()
+	if err != nil {
+		panic(err)
+	}
+
+	router := gin.New()
+	router.Use(gin.Logger(), gin.Recovery())
+	router.GET("/healthz", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	mcpTransport.Register(router)
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	err = httpServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		panic(err)
+	}
+}
+
+func registerToolsAndResources(mcpServer *mcpgolang.Server) {
 	// Add tools
 	for _, tool := range tools.MetoroToolsList {
 		err := mcpServer.RegisterTool(tool.Name, tool.Description, tool.WrappedHandler())
@@ -41,21 +134,16 @@ func main() {
 			panic(err)
 		}
 	}
+}
 
-	err := mcpServer.Serve()
-	if err != nil {
-		panic(err)
+func getEnvOrDefault(envVar string, defaultValue string) string {
+	if value := os.Getenv(envVar); value != "" {
+		return value
 	}
-
-	<-done
+	return defaultValue
 }
 
 func checkEnvVars() error {
-	if os.Getenv(utils.METORO_API_URL_ENV_VAR) == "" {
-		return fmt.Errorf("%s environment variable not set", utils.METORO_API_URL_ENV_VAR)
-	}
-	if os.Getenv(utils.METORO_AUTH_TOKEN_ENV_VAR) == "" {
-		return fmt.Errorf("%s environment variable not set", utils.METORO_AUTH_TOKEN_ENV_VAR)
-	}
-	return nil
+	_, err := utils.ResolveCredentials(nil)
+	return err
 }
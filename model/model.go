@@ -69,12 +69,13 @@ type GetTracesRequest struct {
 	EndTime        int64               `json:"endTime"`
 	Filters        map[string][]string `json:"filters"`
 	ExcludeFilters map[string][]string `json:"excludeFilters"`
-	PrevEndTime    *int64              `json:"prevEndTime"`
-	Regexes        []string            `json:"regexes"`
-	ExcludeRegexes []string            `json:"excludeRegexes"`
-	Ascending      bool                `json:"ascending"`
-	Environments   []string            `json:"environments"`
-	Limit          *int                `json:"limit,omitempty"` // Optional limit on the number of traces to return
+	// Previous page endTime in nanoseconds like GetLogsRequest.PrevEndTime, used to get the next page of traces
+	PrevEndTime    *int64   `json:"prevEndTime"`
+	Regexes        []string `json:"regexes"`
+	ExcludeRegexes []string `json:"excludeRegexes"`
+	Ascending      bool     `json:"ascending"`
+	Environments   []string `json:"environments"`
+	Limit          *int     `json:"limit,omitempty"` // Optional limit on the number of traces to return
 }
 
 type Aggregation string
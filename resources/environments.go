@@ -7,9 +7,8 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-func EnvironmentResourceHandler() (*mcpgolang.ResourceResponse, error) {
-	ctx := context.Background()
-	response, err := utils.MakeMetoroAPIRequest("GET", "environments", nil, utils.GetAPIRequirementsFromRequest(ctx))
+func EnvironmentResourceHandler(ctx context.Context) (*mcpgolang.ResourceResponse, error) {
+	response, err := utils.MakeMetoroAPIRequest(ctx, "GET", "environments", nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
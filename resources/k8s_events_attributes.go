@@ -7,9 +7,8 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-func K8sEventsAttributesResourceHandler() (*mcpgolang.ResourceResponse, error) {
-	ctx := context.Background()
-	resp, err := utils.MakeMetoroAPIRequest("GET", "k8s/events/summaryAttributes", nil, utils.GetAPIRequirementsFromRequest(ctx))
+func K8sEventsAttributesResourceHandler(ctx context.Context) (*mcpgolang.ResourceResponse, error) {
+	resp, err := utils.MakeMetoroAPIRequest(ctx, "GET", "k8s/events/summaryAttributes", nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -7,9 +7,8 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-func LogAttributesResourceHandler() (*mcpgolang.ResourceResponse, error) {
-	ctx := context.Background()
-	resp, err := utils.MakeMetoroAPIRequest("GET", "logsSummaryAttributes", nil, utils.GetAPIRequirementsFromRequest(ctx))
+func LogAttributesResourceHandler(ctx context.Context) (*mcpgolang.ResourceResponse, error) {
+	resp, err := utils.MakeMetoroAPIRequest(ctx, "GET", "logsSummaryAttributes", nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -11,7 +11,7 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-func MetricsResourceHandler() (*mcpgolang.ResourceResponse, error) {
+func MetricsResourceHandler(ctx context.Context) (*mcpgolang.ResourceResponse, error) {
 	now := time.Now()
 	twoHoursAgo := now.Add(-2 * time.Hour)
 	request := model.FuzzyMetricsRequest{
@@ -24,8 +24,7 @@ func MetricsResourceHandler() (*mcpgolang.ResourceResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx := context.Background()
-	resp, err := utils.MakeMetoroAPIRequest("POST", "fuzzyMetricsNames", bytes.NewBuffer(jsonData), utils.GetAPIRequirementsFromRequest(ctx))
+	resp, err := utils.MakeMetoroAPIRequest(ctx, "POST", "fuzzyMetricsNames", bytes.NewBuffer(jsonData), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -7,9 +7,8 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-func NamespacesResourceHandler() (*mcpgolang.ResourceResponse, error) {
-	ctx := context.Background()
-	response, err := utils.MakeMetoroAPIRequest("GET", "namespaces", nil, utils.GetAPIRequirementsFromRequest(ctx))
+func NamespacesResourceHandler(ctx context.Context) (*mcpgolang.ResourceResponse, error) {
+	response, err := utils.MakeMetoroAPIRequest(ctx, "GET", "namespaces", nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -11,7 +11,7 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-func NodesResourceHandler() (*mcpgolang.ResourceResponse, error) {
+func NodesResourceHandler(ctx context.Context) (*mcpgolang.ResourceResponse, error) {
 	now := time.Now()
 	tenMinsAgo := now.Add(-10 * time.Minute)
 	request := model.GetAllNodesRequest{
@@ -26,8 +26,7 @@ func NodesResourceHandler() (*mcpgolang.ResourceResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx := context.Background()
-	response, err := utils.MakeMetoroAPIRequest("POST", "infrastructure/nodes", bytes.NewBuffer(jsonRequest), utils.GetAPIRequirementsFromRequest(ctx))
+	response, err := utils.MakeMetoroAPIRequest(ctx, "POST", "infrastructure/nodes", bytes.NewBuffer(jsonRequest), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -7,9 +7,8 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-func ServicesResourceHandler() (*mcpgolang.ResourceResponse, error) {
-	ctx := context.Background()
-	response, err := utils.MakeMetoroAPIRequest("GET", "services", nil, utils.GetAPIRequirementsFromRequest(ctx))
+func ServicesResourceHandler(ctx context.Context) (*mcpgolang.ResourceResponse, error) {
+	response, err := utils.MakeMetoroAPIRequest(ctx, "GET", "services", nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -7,9 +7,8 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-func TraceAttributesResourceHandler() (*mcpgolang.ResourceResponse, error) {
-	ctx := context.Background()
-	resp, err := utils.MakeMetoroAPIRequest("GET", "tracesSummaryAttributes", nil, utils.GetAPIRequirementsFromRequest(ctx))
+func TraceAttributesResourceHandler(ctx context.Context) (*mcpgolang.ResourceResponse, error) {
+	resp, err := utils.MakeMetoroAPIRequest(ctx, "GET", "tracesSummaryAttributes", nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
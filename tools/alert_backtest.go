@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+// alertFireInterval is a simulated fire of an alert from the bucket that made it fire until the bucket that resolved
+// it. Ongoing fires were still firing at the end of the backtest.
+type alertFireInterval struct {
+	Start           string `json:"start"`
+	End             string `json:"end"`
+	DurationSeconds int64  `json:"durationSeconds"`
+	Ongoing         bool   `json:"ongoing,omitempty"`
+	// start and end are in milliseconds.
+	start int64
+	end   int64
+}
+
+// alertOperatorBreached reports whether the value breaches the threshold of the operator.
+func alertOperatorBreached(operator model.OperatorConfig, value float64) bool {
+	switch operator.Operator {
+	case model.GREATER_THAN:
+		return value > operator.Threshold
+	case model.LESS_THAN:
+		return value < operator.Threshold
+	case model.GREATER_THAN_OR_EQUAL:
+		return value >= operator.Threshold
+	case model.LESS_THAN_OR_EQUAL:
+		return value <= operator.Threshold
+	case model.EQUALS:
+		return value == operator.Threshold
+	case model.NOT_EQUALS:
+		return value != operator.Threshold
+	default:
+		return false
+	}
+}
+
+// staticConditionBreached reports whether the value breaches every operator of the condition, as model.StaticCondition
+// documents its operators as conditions that must all be met. Conditions without operators never breach.
+func staticConditionBreached(condition model.StaticCondition, value float64) bool {
+	if len(condition.Operators) == 0 {
+		return false
+	}
+	for _, operator := range condition.Operators {
+		if !alertOperatorBreached(operator, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// replayStaticCondition evaluates the condition at every bucket of the series like the alert would. The alert fires
+// once DatapointsToAlarm of the last DatapointsInEvaluationWindow buckets breach the condition and resolves when fewer
+// do. Buckets without a value don't breach. The points are in milliseconds and the bucket size in seconds.
+func replayStaticCondition(points []timeseriesSummaryPoint, condition model.StaticCondition, bucketSize int64) []alertFireInterval {
+	return replayAlertBreaches(alertBreaches(points, bucketSize, func(value float64) bool {
+		return staticConditionBreached(condition, value)
+	}), condition.PersistenceSettings)
+}
+
+// alertBuckets are the buckets between the first and the last point of a series and whether each of them breaches.
+type alertBuckets struct {
+	// start is the time of the first bucket in milliseconds.
+	start      int64
+	bucketSize int64
+	breached   []bool
+}
+
+func alertBreaches(points []timeseriesSummaryPoint, bucketSize int64, breached func(value float64) bool) alertBuckets {
+	buckets := alertBuckets{bucketSize: bucketSize * 1000}
+	if len(points) == 0 || bucketSize <= 0 {
+		return buckets
+	}
+	buckets.start = points[0].Time
+	buckets.breached = make([]bool, (points[len(points)-1].Time-buckets.start)/buckets.bucketSize+1)
+	for _, point := range points {
+		index := (point.Time - buckets.start) / buckets.bucketSize
+		buckets.breached[index] = buckets.breached[index] || breached(point.Value)
+	}
+	return buckets
+}
+
+func replayAlertBreaches(buckets alertBuckets, persistence model.PersistenceSettings) []alertFireInterval {
+	window := max(persistence.DatapointsInEvaluationWindow, 1)
+	toAlarm := max(persistence.DatapointsToAlarm, 1)
+
+	var fires []alertFireInterval
+	breaching := int64(0)
+	firing := false
+	for i, breached := range buckets.breached {
+		if breached {
+			breaching++
+		}
+		if int64(i) >= window && buckets.breached[int64(i)-window] {
+			breaching--
+		}
+		bucketTime := buckets.start + int64(i)*buckets.bucketSize
+		switch {
+		case !firing && breaching >= toAlarm:
+			firing = true
+			fires = append(fires, alertFireInterval{start: bucketTime})
+		case firing && breaching < toAlarm:
+			firing = false
+			fires[len(fires)-1].end = bucketTime
+		}
+	}
+	if firing {
+		fires[len(fires)-1].end = buckets.start + int64(len(buckets.breached))*buckets.bucketSize
+		fires[len(fires)-1].Ongoing = true
+	}
+	for i := range fires {
+		fires[i].Start = formatTimeseriesTime(fires[i].start)
+		fires[i].End = formatTimeseriesTime(fires[i].end)
+		fires[i].DurationSeconds = (fires[i].end - fires[i].start) / 1000
+	}
+	return fires
+}
+
+// alertFlapCount counts the fires that started within the window after the previous fire resolved.
+func alertFlapCount(fires []alertFireInterval, window time.Duration) int {
+	flaps := 0
+	for i := 1; i < len(fires); i++ {
+		if fires[i].start-fires[i-1].end < window.Milliseconds() {
+			flaps++
+		}
+	}
+	return flaps
+}
+
+// describeStaticCondition describes the condition e.g. > 10 for 3 of 5 datapoints.
+func describeStaticCondition(condition model.StaticCondition) string {
+	symbols := map[model.OperatorType]string{
+		model.GREATER_THAN:          ">",
+		model.LESS_THAN:             "<",
+		model.GREATER_THAN_OR_EQUAL: ">=",
+		model.LESS_THAN_OR_EQUAL:    "<=",
+		model.EQUALS:                "==",
+		model.NOT_EQUALS:            "!=",
+	}
+	description := ""
+	for i, operator := range condition.Operators {
+		if i > 0 {
+			description += " and "
+		}
+		description += fmt.Sprintf("%s %g", symbols[operator.Operator], operator.Threshold)
+	}
+	return fmt.Sprintf("%s for %d of %d datapoints", description, condition.PersistenceSettings.DatapointsToAlarm, condition.PersistenceSettings.DatapointsInEvaluationWindow)
+}
@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+// newTestAlertPoints returns a point per minute from 2026-02-19T10:00:00Z for every value that is not nil.
+func newTestAlertPoints(values ...*float64) []timeseriesSummaryPoint {
+	var points []timeseriesSummaryPoint
+	for i, value := range values {
+		if value != nil {
+			points = append(points, timeseriesSummaryPoint{Time: (anomalyTestWindowStart + int64(i)*60) * 1000, Value: *value})
+		}
+	}
+	return points
+}
+
+func testAlertValues(values ...float64) []*float64 {
+	pointers := make([]*float64, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	return pointers
+}
+
+func newTestStaticCondition(threshold float64, datapointsToAlarm int64, evaluationWindow int64) model.StaticCondition {
+	return model.StaticCondition{
+		Operators:           []model.OperatorConfig{{Operator: model.GREATER_THAN, Threshold: threshold}},
+		PersistenceSettings: model.PersistenceSettings{DatapointsToAlarm: datapointsToAlarm, DatapointsInEvaluationWindow: evaluationWindow},
+	}
+}
+
+func TestReplayStaticCondition(t *testing.T) {
+	points := newTestAlertPoints(testAlertValues(0, 20, 0, 20, 0, 0, 0, 20, 20, 20)...)
+
+	fires := replayStaticCondition(points, newTestStaticCondition(10, 2, 3), 60)
+	if len(fires) != 2 {
+		t.Fatalf("expected two fires, got %+v", fires)
+	}
+	if fires[0].Start != "2026-02-19T10:03:00Z" || fires[0].End != "2026-02-19T10:04:00Z" || fires[0].DurationSeconds != 60 || fires[0].Ongoing {
+		t.Fatalf("unexpected first fire %+v", fires[0])
+	}
+	if fires[1].Start != "2026-02-19T10:08:00Z" || fires[1].End != "2026-02-19T10:10:00Z" || !fires[1].Ongoing {
+		t.Fatalf("unexpected ongoing fire %+v", fires[1])
+	}
+	if flaps := alertFlapCount(fires, 3*time.Minute); flaps != 0 {
+		t.Fatalf("expected no flaps within 3 minutes, got %d", flaps)
+	}
+	if flaps := alertFlapCount(fires, 5*time.Minute); flaps != 1 {
+		t.Fatalf("expected a flap within 5 minutes, got %d", flaps)
+	}
+
+	// Buckets without a value don't breach.
+	twenty := 20.0
+	gaps := newTestAlertPoints(&twenty, &twenty, nil, nil, nil, &twenty)
+	fires = replayStaticCondition(gaps, newTestStaticCondition(10, 2, 2), 60)
+	if len(fires) != 1 || fires[0].Start != "2026-02-19T10:01:00Z" || fires[0].End != "2026-02-19T10:02:00Z" {
+		t.Fatalf("unexpected fires with gaps %+v", fires)
+	}
+
+	if fires := replayStaticCondition(nil, newTestStaticCondition(10, 1, 1), 60); fires != nil {
+		t.Fatalf("expected no fires without points, got %+v", fires)
+	}
+}
+
+func TestDescribeStaticCondition(t *testing.T) {
+	condition := newTestStaticCondition(10, 3, 5)
+	condition.Operators = append(condition.Operators, model.OperatorConfig{Operator: model.LESS_THAN_OR_EQUAL, Threshold: 20})
+	if description := describeStaticCondition(condition); description != "> 10 and <= 20 for 3 of 5 datapoints" {
+		t.Fatalf("unexpected description %q", description)
+	}
+	if !staticConditionBreached(condition, 15) || staticConditionBreached(condition, 5) || staticConditionBreached(condition, 25) {
+		t.Fatalf("expected the condition to breach only when every operator does")
+	}
+}
+
+func TestBacktestAlertHandler(t *testing.T) {
+	var data []map[string]any
+	for i, value := range []float64{0, 20, 20, 0, 0, 20, 20, 0} {
+		data = append(data, map[string]any{"time": (anomalyTestWindowStart + int64(i)*300) * 1000, "value": value})
+	}
+	quiet := []map[string]any{{"time": anomalyTestWindowStart * 1000, "value": 1}}
+	var response []byte
+
+	var request model.GetMultiMetricRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":["service.name"]}`))
+		case "/api/v1/metrics":
+			requestBody, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(requestBody, &request); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			_, _ = w.Write(response)
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	response, _ = json.Marshal(map[string]any{"metrics": []map[string]any{{"metric": []map[string]any{
+		{"attributes": map[string]string{"service.name": "checkout"}, "data": data},
+		{"attributes": map[string]string{"service.name": "cart"}, "data": quiet},
+	}}}})
+	threshold := 10.0
+	arguments := BacktestAlertHandlerArgs{CreateAlertHandlerArgs: CreateAlertHandlerArgs{
+		AlertName:         "Checkout errors",
+		Timeseries:        []model.MetricSpecifier{{MetricType: model.Trace, Aggregation: model.AggregationCount, Splits: []string{"service.name"}, BucketSize: 300}},
+		Condition:         "GreaterThan",
+		Threshold:         &threshold,
+		DatapointsToAlarm: 2,
+		EvaluationWindow:  3,
+	}}
+	result, err := BacktestAlertHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if request.EndTime-request.StartTime != 7*24*3600 || request.Metrics[0].Trace.BucketSize != 300 {
+		t.Fatalf("unexpected request %+v", request)
+	}
+
+	var backtest alertBacktestResponse
+	if err := json.Unmarshal([]byte(result.Content[0].TextContent.Text), &backtest); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	rule := backtest.Rules[0]
+	if backtest.SeriesEvaluated != 2 || rule.Name != defaultAlertRuleName || rule.Fires != 2 || rule.FireTimeSeconds != 1200 || rule.FireTime != "20m0s" || rule.FlapCount != 1 {
+		t.Fatalf("unexpected backtest %+v", backtest)
+	}
+	if len(rule.Series) != 1 || rule.Series[0].Attributes["service.name"] != "checkout" || rule.Series[0].Name != "timeseries 1" {
+		t.Fatalf("expected only the checkout series to fire, got %+v", rule.Series)
+	}
+
+	// With a formula the alert only evaluates the formula.
+	response, _ = json.Marshal(map[string]any{"metrics": []map[string]any{
+		{"metric": []map[string]any{{"data": quiet}}},
+		{"metric": []map[string]any{{"data": data}}},
+	}})
+	arguments.Timeseries[0].FormulaIdentifier = "a"
+	arguments.Formula = model.Formula{Formula: "a * 2"}
+	result, err = BacktestAlertHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].TextContent.Text), &backtest); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if backtest.SeriesEvaluated != 1 || backtest.Rules[0].Series[0].Name != "a * 2" {
+		t.Fatalf("expected the formula to be evaluated, got %+v", backtest)
+	}
+
+	// Every evaluation rule is replayed separately.
+	lower, upper := -1.0, 15.0
+	single := arguments.CreateAlertHandlerArgs
+	arguments.Condition, arguments.Threshold, arguments.DatapointsToAlarm, arguments.EvaluationWindow = "", nil, 0, 0
+	arguments.EvaluationRules = []AlertEvaluationRule{
+		{Name: "warning", Condition: "GreaterThan", Threshold: &upper, DatapointsToAlarm: 3, EvaluationWindow: 3},
+		{Name: "band", Condition: alertConditionOutsideRange, LowerThreshold: &lower, UpperThreshold: &upper, DatapointsToAlarm: 1, EvaluationWindow: 1},
+	}
+	result, err = BacktestAlertHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].TextContent.Text), &backtest); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(backtest.Rules) != 3 || backtest.Rules[0].Name != "warning" || backtest.Rules[0].Fires != 0 || backtest.Rules[1].Name != "band low" || backtest.Rules[1].Fires != 0 ||
+		backtest.Rules[2].Name != "band high" || backtest.Rules[2].Fires != 2 || backtest.Rules[2].Rule != "> 15 for 1 of 1 datapoints" {
+		t.Fatalf("unexpected rules %+v", backtest.Rules)
+	}
+	arguments.CreateAlertHandlerArgs = single
+
+	arguments.DatapointsToAlarm = 4
+	if _, err := BacktestAlertHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error for more datapoints to alarm than the evaluation window")
+	}
+}
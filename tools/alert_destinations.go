@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+// The kinds of destinations. model.Action documents its type as a free form string without the values it accepts, so
+// before an alert is sent resolveAlertActionTypes replaces them with the types the existing alerts use for the same
+// kind of destination.
+const (
+	alertDestinationSlack     = "slack"
+	alertDestinationPagerDuty = "pagerduty"
+	alertDestinationEmail     = "email"
+	alertDestinationWebhook   = "webhook"
+)
+
+// AlertDestination is where an alert notifies when it fires. It is converted to a model.Action of the evaluation rules.
+type AlertDestination struct {
+	Type            string   `json:"type" jsonschema:"required,enum=slack,enum=pagerduty,enum=email,enum=webhook,description=The type of the destination. Use list_alert_destinations to get the destinations the existing alerts notify."`
+	Channel         string   `json:"channel,omitempty" jsonschema:"description=Required for slack. The slack channel to notify e.g. #alerts"`
+	ServiceId       string   `json:"service_id,omitempty" jsonschema:"description=For pagerduty. The ID of the PagerDuty service to notify. Set either service_id or service_name."`
+	ServiceName     string   `json:"service_name,omitempty" jsonschema:"description=For pagerduty. The name of the PagerDuty service to notify. Set either service_id or service_name."`
+	Emails          []string `json:"emails,omitempty" jsonschema:"description=Required for email. The email addresses to notify."`
+	WebhookUuid     string   `json:"webhook_uuid,omitempty" jsonschema:"description=For webhook. The UUID of the webhook to call when the alert fires. Set either webhook_uuid or webhook_name."`
+	WebhookName     string   `json:"webhook_name,omitempty" jsonschema:"description=For webhook. The name of the webhook to call when the alert fires. Set either webhook_uuid or webhook_name."`
+	ResolvedUuid    string   `json:"resolved_webhook_uuid,omitempty" jsonschema:"description=Optional for webhook. The UUID of the webhook to call when the alert is resolved."`
+	ResolvedName    string   `json:"resolved_webhook_name,omitempty" jsonschema:"description=Optional for webhook. The name of the webhook to call when the alert is resolved."`
+	NotifyOnResolve *bool    `json:"notify_on_resolve,omitempty" jsonschema:"description=Optional for slack and email. Whether to also notify when the alert is resolved."`
+}
+
+// alertDestinationsToActions validates the required fields of every destination and converts them to actions.
+func alertDestinationsToActions(destinations []AlertDestination) ([]model.Action, error) {
+	actions := make([]model.Action, 0, len(destinations))
+	for i, destination := range destinations {
+		action, err := alertDestinationToAction(destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination %d: %v", i+1, err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+func alertDestinationToAction(destination AlertDestination) (model.Action, error) {
+	destinationType := strings.ToLower(strings.TrimSpace(destination.Type))
+	action := model.Action{Type: destinationType}
+	optional := func(value string) *string {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+
+	switch destinationType {
+	case alertDestinationSlack:
+		channel := optional(destination.Channel)
+		if channel == nil {
+			return model.Action{}, fmt.Errorf("channel is required for slack destinations")
+		}
+		action.SlackDestination = &model.ActionSlackDestination{Channel: channel, NotifyOnResolve: destination.NotifyOnResolve}
+	case alertDestinationPagerDuty:
+		serviceId, serviceName := optional(destination.ServiceId), optional(destination.ServiceName)
+		if serviceId == nil && serviceName == nil {
+			return model.Action{}, fmt.Errorf("service_id or service_name is required for pagerduty destinations")
+		}
+		action.PagerDutyDestination = &model.ActionPagerDutyDestination{ServiceId: serviceId, ServiceName: serviceName}
+	case alertDestinationEmail:
+		if len(destination.Emails) == 0 {
+			return model.Action{}, fmt.Errorf("emails are required for email destinations")
+		}
+		emails := make([]string, len(destination.Emails))
+		for i, email := range destination.Emails {
+			address, err := mail.ParseAddress(strings.TrimSpace(email))
+			if err != nil {
+				return model.Action{}, fmt.Errorf("invalid email address %q", email)
+			}
+			emails[i] = address.Address
+		}
+		action.EmailDestination = &model.ActionEmailDestination{Emails: emails, NotifyOnResolve: destination.NotifyOnResolve}
+	case alertDestinationWebhook:
+		uuid, name := optional(destination.WebhookUuid), optional(destination.WebhookName)
+		if uuid == nil && name == nil {
+			return model.Action{}, fmt.Errorf("webhook_uuid or webhook_name is required for webhook destinations")
+		}
+		action.WebhookDestination = &model.ActionWebhookDestination{
+			Uuid:         uuid,
+			Name:         name,
+			ResolvedUuid: optional(destination.ResolvedUuid),
+			ResolvedName: optional(destination.ResolvedName),
+		}
+	default:
+		return model.Action{}, fmt.Errorf("type must be %s, %s, %s or %s", alertDestinationSlack, alertDestinationPagerDuty, alertDestinationEmail, alertDestinationWebhook)
+	}
+	return action, nil
+}
+
+// alertActionKind returns the kind of destination an action notifies from the destination that is set.
+func alertActionKind(slack bool, pagerDuty bool, email bool, webhook bool) string {
+	switch {
+	case slack:
+		return alertDestinationSlack
+	case pagerDuty:
+		return alertDestinationPagerDuty
+	case email:
+		return alertDestinationEmail
+	case webhook:
+		return alertDestinationWebhook
+	}
+	return ""
+}
+
+// alertActionTypes returns the type metoro stores for every kind of destination the alerts notify.
+func alertActionTypes(alerts []searchedAlert) map[string]string {
+	types := map[string]string{}
+	for _, alert := range alerts {
+		for _, action := range alert.actions() {
+			if kind := action.kind(); kind != "" && action.Type != "" {
+				types[kind] = action.Type
+			}
+		}
+	}
+	return types
+}
+
+// resolveAlertActionTypes sets the type of every action of the alerts to the type the existing alerts use for the same
+// kind of destination. Kinds no existing alert notifies keep their lowercase type.
+func resolveAlertActionTypes(ctx context.Context, alerts ...*model.Alert) error {
+	hasActions := false
+	for _, alert := range alerts {
+		for _, rule := range alert.Timeseries.EvaluationRules {
+			hasActions = hasActions || len(rule.Actions) > 0
+		}
+	}
+	if !hasActions {
+		return nil
+	}
+
+	existing, err := searchAlerts(ctx)
+	if err != nil {
+		return err
+	}
+	types := alertActionTypes(existing)
+	for _, alert := range alerts {
+		for i := range alert.Timeseries.EvaluationRules {
+			for j := range alert.Timeseries.EvaluationRules[i].Actions {
+				action := &alert.Timeseries.EvaluationRules[i].Actions[j]
+				kind := alertActionKind(action.SlackDestination != nil, action.PagerDutyDestination != nil, action.EmailDestination != nil, action.WebhookDestination != nil)
+				if actionType, ok := types[kind]; ok {
+					action.Type = actionType
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setAlertActions makes every evaluation rule of the alert notify the destinations.
+func setAlertActions(alert *model.Alert, actions []model.Action) {
+	for i := range alert.Timeseries.EvaluationRules {
+		alert.Timeseries.EvaluationRules[i].Actions = actions
+	}
+}
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAlertDestinationsToActions(t *testing.T) {
+	notify := true
+	actions, err := alertDestinationsToActions([]AlertDestination{
+		{Type: "Slack", Channel: " #alerts ", NotifyOnResolve: &notify},
+		{Type: "pagerduty", ServiceId: "P123"},
+		{Type: "email", Emails: []string{"On Call <oncall@example.com>"}},
+		{Type: "webhook", WebhookName: "incident.io"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if actions[0].Type != alertDestinationSlack || *actions[0].SlackDestination.Channel != "#alerts" || !*actions[0].SlackDestination.NotifyOnResolve {
+		t.Fatalf("unexpected slack action %+v", actions[0])
+	}
+	if *actions[1].PagerDutyDestination.ServiceId != "P123" || actions[1].PagerDutyDestination.ServiceName != nil {
+		t.Fatalf("unexpected pagerduty action %+v", actions[1].PagerDutyDestination)
+	}
+	if actions[2].EmailDestination.Emails[0] != "oncall@example.com" {
+		t.Fatalf("unexpected email action %+v", actions[2].EmailDestination)
+	}
+	if *actions[3].WebhookDestination.Name != "incident.io" || actions[3].WebhookDestination.ResolvedName != nil {
+		t.Fatalf("unexpected webhook action %+v", actions[3].WebhookDestination)
+	}
+
+	invalid := map[string]AlertDestination{
+		"channel is required":           {Type: "slack"},
+		"service_id or service_name":    {Type: "pagerduty"},
+		"emails are required":           {Type: "email"},
+		"invalid email address":         {Type: "email", Emails: []string{"oncall"}},
+		"webhook_uuid or webhook_name":  {Type: "webhook", ResolvedName: "incident.io"},
+		"type must be slack, pagerduty": {Type: "sms"},
+	}
+	for expected, destination := range invalid {
+		_, err := alertDestinationsToActions([]AlertDestination{{Type: "slack", Channel: "#alerts"}, destination})
+		if err == nil || !strings.Contains(err.Error(), expected) || !strings.Contains(err.Error(), "destination 2") {
+			t.Fatalf("expected %q for %+v, got %v", expected, destination, err)
+		}
+	}
+}
+
+func TestListAlertDestinations(t *testing.T) {
+	var alerts []searchedAlert
+	err := json.Unmarshal([]byte(`[
+		{"metadata":{"id":"a1","name":"Errors"},"timeseries":{"evaluationRules":[
+			{"actions":[{"type":"slack","slackDestination":{"channel":"#alerts","notifyOnResolve":true}}]},
+			{"actions":[{"type":"slack","slackDestination":{"channel":"#alerts"}},{"type":"pagerDuty","pagerDutyDestination":{"serviceName":"checkout"}}]}]}},
+		{"metadata":{"id":"a2","name":"Latency"},"timeseries":{"evaluationRules":[
+			{"actions":[{"type":"slack","slackDestination":{"channel":"#alerts"}},{"type":"unknown"}]}]}}]`), &alerts)
+	if err != nil {
+		t.Fatalf("failed to decode alerts: %v", err)
+	}
+
+	destinations := listAlertDestinations(alerts)
+	if len(destinations) != 2 {
+		t.Fatalf("expected 2 destinations, got %+v", destinations)
+	}
+	if slack := destinations[0]; slack.Type != alertDestinationSlack || slack.Channel != "#alerts" || slack.Alerts != 2 || slack.NotifyOnResolve != nil {
+		t.Fatalf("unexpected slack destination %+v", slack)
+	}
+	if pagerDuty := destinations[1]; pagerDuty.Type != alertDestinationPagerDuty || pagerDuty.ServiceName != "checkout" || pagerDuty.Alerts != 1 {
+		t.Fatalf("unexpected pagerduty destination %+v", pagerDuty)
+	}
+
+	types := alertActionTypes(alerts)
+	if types[alertDestinationPagerDuty] != "pagerDuty" || types[alertDestinationSlack] != "slack" || len(types) != 2 {
+		t.Fatalf("unexpected action types %+v", types)
+	}
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+const (
+	jsonChangeAdd     = "add"
+	jsonChangeRemove  = "remove"
+	jsonChangeReplace = "replace"
+)
+
+// jsonChange is a single difference between two JSON documents. Path is a JSON pointer (RFC 6901) into the documents.
+type jsonChange struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// alertChangeResponse is returned by the tools that change alerts. In a dry run the changes are only returned, otherwise
+// the response of the Metoro API is included.
+type alertChangeResponse struct {
+	AlertId  string          `json:"alertId"`
+	DryRun   bool            `json:"dryRun"`
+	Changes  []jsonChange    `json:"changes"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// diffAlertDocuments returns the changes between the JSON of the current and proposed documents, which are usually
+// alerts. A nil document is a document that doesn't exist.
+func diffAlertDocuments(current any, proposed any) ([]jsonChange, error) {
+	currentJSON, err := toJSONDocument(current)
+	if err != nil {
+		return nil, err
+	}
+	proposedJSON, err := toJSONDocument(proposed)
+	if err != nil {
+		return nil, err
+	}
+	return diffJSON(nil, currentJSON, proposedJSON), nil
+}
+
+func toJSONDocument(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling alert: %v", err)
+	}
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, fmt.Errorf("error unmarshaling alert: %v", err)
+	}
+	return document, nil
+}
+
+// diffJSON compares objects key by key and arrays index by index and reports every other difference as a replacement
+// of the whole value.
+func diffJSON(path []string, current any, proposed any) []jsonChange {
+	switch {
+	case current == nil && proposed == nil:
+		return nil
+	case current == nil:
+		return []jsonChange{{Op: jsonChangeAdd, Path: formatJSONPointer(path), To: proposed}}
+	case proposed == nil:
+		return []jsonChange{{Op: jsonChangeRemove, Path: formatJSONPointer(path), From: current}}
+	}
+
+	currentObject, currentIsObject := current.(map[string]any)
+	proposedObject, proposedIsObject := proposed.(map[string]any)
+	if currentIsObject && proposedIsObject {
+		keys := slices.Sorted(maps.Keys(currentObject))
+		for key := range proposedObject {
+			if _, ok := currentObject[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		var changes []jsonChange
+		for _, key := range keys {
+			changes = append(changes, diffJSON(append(slices.Clone(path), key), currentObject[key], proposedObject[key])...)
+		}
+		return changes
+	}
+
+	currentArray, currentIsArray := current.([]any)
+	proposedArray, proposedIsArray := proposed.([]any)
+	if currentIsArray && proposedIsArray {
+		var changes []jsonChange
+		for i := 0; i < max(len(currentArray), len(proposedArray)); i++ {
+			var currentItem, proposedItem any
+			if i < len(currentArray) {
+				currentItem = currentArray[i]
+			}
+			if i < len(proposedArray) {
+				proposedItem = proposedArray[i]
+			}
+			changes = append(changes, diffJSON(append(slices.Clone(path), strconv.Itoa(i)), currentItem, proposedItem)...)
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(current, proposed) {
+		return nil
+	}
+	return []jsonChange{{Op: jsonChangeReplace, Path: formatJSONPointer(path), From: current, To: proposed}}
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+const (
+	defaultAlertRuleName = "Alert Condition"
+	// alertConditionOutsideRange fires when the value is below the lower or above the upper threshold. The operators
+	// of a static condition must all be met, so it is a LessThan and a GreaterThan condition of their own.
+	alertConditionOutsideRange = "OutsideRange"
+)
+
+// AlertEvaluationRule is a rule of an alert with its own condition, persistence and destinations, e.g. a warning and a
+// critical rule with different thresholds.
+type AlertEvaluationRule struct {
+	Name              string             `json:"name" jsonschema:"required,description=The name of the rule e.g. warning or critical. Names must be unique within the alert."`
+	Condition         string             `json:"condition" jsonschema:"required,enum=GreaterThan,enum=LessThan,enum=GreaterThanOrEqual,enum=LessThanOrEqual,enum=OutsideRange,description=The arithmetic comparison of the rule. OutsideRange fires when the value is below lower_threshold or above upper_threshold and is created as two rules named after the rule with a low and a high suffix which count their datapoints separately."`
+	Threshold         *float64           `json:"threshold,omitempty" jsonschema:"description=The threshold of the rule. Required unless the condition is OutsideRange."`
+	LowerThreshold    *float64           `json:"lower_threshold,omitempty" jsonschema:"description=Only for OutsideRange. The rule fires when the value is below this threshold."`
+	UpperThreshold    *float64           `json:"upper_threshold,omitempty" jsonschema:"description=Only for OutsideRange. The rule fires when the value is above this threshold."`
+	DatapointsToAlarm int64              `json:"datapoints_to_alarm" jsonschema:"required,description=The number of datapoints that need to breach the condition for the rule to fire"`
+	EvaluationWindow  int64              `json:"evaluation_window" jsonschema:"required,description=The evaluation window of the rule in number of datapoints"`
+	Destinations      []AlertDestination `json:"destinations,omitempty" jsonschema:"description=Optional destinations to notify when the rule fires e.g. slack for warning and pagerduty for critical. Rules without destinations notify the destinations of the alert."`
+}
+
+// createAlertConditions returns the evaluation rules of the create_alert arguments, which are either the single
+// condition or the evaluation rules.
+func createAlertConditions(arguments CreateAlertHandlerArgs) ([]model.Condition, error) {
+	actions, err := alertDestinationsToActions(arguments.Destinations)
+	if err != nil {
+		return nil, err
+	}
+	if len(arguments.EvaluationRules) > 0 {
+		if arguments.Condition != "" || arguments.Threshold != nil || arguments.DatapointsToAlarm != 0 || arguments.EvaluationWindow != 0 {
+			return nil, fmt.Errorf("set either condition with threshold datapoints_to_alarm and evaluation_window or evaluation_rules, not both")
+		}
+		return alertEvaluationRulesToConditions(arguments.EvaluationRules, actions)
+	}
+
+	operator, err := alertOperatorType(arguments.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if arguments.Threshold == nil {
+		return nil, fmt.Errorf("threshold is required for %s", arguments.Condition)
+	}
+	if err := validateAlertPersistence(arguments.DatapointsToAlarm, arguments.EvaluationWindow); err != nil {
+		return nil, err
+	}
+	operators := []model.OperatorConfig{{Operator: operator, Threshold: *arguments.Threshold}}
+	return []model.Condition{staticAlertCondition(defaultAlertRuleName, operators, arguments.DatapointsToAlarm, arguments.EvaluationWindow, actions)}, nil
+}
+
+// alertEvaluationRulesToConditions validates the rules and converts them to conditions. Rules without destinations
+// get the default actions.
+func alertEvaluationRulesToConditions(rules []AlertEvaluationRule, defaultActions []model.Action) ([]model.Condition, error) {
+	names := map[string]bool{}
+	conditions := make([]model.Condition, 0, len(rules))
+	for _, rule := range rules {
+		name := strings.TrimSpace(rule.Name)
+		if name == "" {
+			return nil, fmt.Errorf("every evaluation rule needs a name")
+		}
+
+		ruleConditions, err := alertRuleConditions(name, rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid evaluation rule %q: %v", name, err)
+		}
+		if err := validateAlertPersistence(rule.DatapointsToAlarm, rule.EvaluationWindow); err != nil {
+			return nil, fmt.Errorf("invalid evaluation rule %q: %v", name, err)
+		}
+		actions := defaultActions
+		if len(rule.Destinations) > 0 {
+			actions, err = alertDestinationsToActions(rule.Destinations)
+			if err != nil {
+				return nil, fmt.Errorf("invalid evaluation rule %q: %v", name, err)
+			}
+		}
+		for _, condition := range ruleConditions {
+			if names[condition.name] {
+				return nil, fmt.Errorf("evaluation rule names must be unique, %q is used more than once", condition.name)
+			}
+			names[condition.name] = true
+			conditions = append(conditions, staticAlertCondition(condition.name, condition.operators, rule.DatapointsToAlarm, rule.EvaluationWindow, actions))
+		}
+	}
+	return conditions, nil
+}
+
+type alertRuleCondition struct {
+	name      string
+	operators []model.OperatorConfig
+}
+
+// alertRuleConditions returns the conditions of the rule. OutsideRange is a condition below the lower threshold and a
+// condition above the upper threshold, named after the rule with a low and a high suffix.
+func alertRuleConditions(name string, rule AlertEvaluationRule) ([]alertRuleCondition, error) {
+	if rule.Condition == alertConditionOutsideRange {
+		if rule.LowerThreshold == nil || rule.UpperThreshold == nil {
+			return nil, fmt.Errorf("lower_threshold and upper_threshold are required for %s", alertConditionOutsideRange)
+		}
+		if *rule.LowerThreshold >= *rule.UpperThreshold {
+			return nil, fmt.Errorf("lower_threshold must be below upper_threshold")
+		}
+		if rule.Threshold != nil {
+			return nil, fmt.Errorf("threshold can't be set for %s, set lower_threshold and upper_threshold", alertConditionOutsideRange)
+		}
+		return []alertRuleCondition{
+			{name: name + " low", operators: []model.OperatorConfig{{Operator: model.LESS_THAN, Threshold: *rule.LowerThreshold}}},
+			{name: name + " high", operators: []model.OperatorConfig{{Operator: model.GREATER_THAN, Threshold: *rule.UpperThreshold}}},
+		}, nil
+	}
+
+	operator, err := alertOperatorType(rule.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Threshold == nil {
+		return nil, fmt.Errorf("threshold is required for %s", rule.Condition)
+	}
+	if rule.LowerThreshold != nil || rule.UpperThreshold != nil {
+		return nil, fmt.Errorf("lower_threshold and upper_threshold can only be set for %s", alertConditionOutsideRange)
+	}
+	return []alertRuleCondition{{name: name, operators: []model.OperatorConfig{{Operator: operator, Threshold: *rule.Threshold}}}}, nil
+}
+
+func validateAlertPersistence(datapointsToAlarm int64, evaluationWindow int64) error {
+	if datapointsToAlarm <= 0 || datapointsToAlarm > evaluationWindow {
+		return fmt.Errorf("datapoints_to_alarm must be between 1 and the evaluation window of %d datapoints", evaluationWindow)
+	}
+	return nil
+}
+
+func staticAlertCondition(name string, operators []model.OperatorConfig, datapointsToAlarm int64, evaluationWindow int64, actions []model.Action) model.Condition {
+	conditionType := model.STATIC
+	return model.Condition{
+		Name: name,
+		Type: &conditionType,
+		Static: &model.StaticCondition{
+			Operators: operators,
+			PersistenceSettings: model.PersistenceSettings{
+				DatapointsToAlarm:            datapointsToAlarm,
+				DatapointsInEvaluationWindow: evaluationWindow,
+			},
+		},
+		Actions: actions,
+	}
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+func TestCreateAlertConditions(t *testing.T) {
+	zero := 0.0
+	conditions, err := createAlertConditions(CreateAlertHandlerArgs{Condition: "GreaterThan", Threshold: &zero, DatapointsToAlarm: 1, EvaluationWindow: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(conditions) != 1 || conditions[0].Name != defaultAlertRuleName || conditions[0].Static.Operators[0].Operator != model.GREATER_THAN {
+		t.Fatalf("unexpected conditions %+v", conditions)
+	}
+
+	warning, critical, lower, upper := 80.0, 95.0, 10.0, 90.0
+	conditions, err = createAlertConditions(CreateAlertHandlerArgs{
+		Destinations: []AlertDestination{{Type: "slack", Channel: "#alerts"}},
+		EvaluationRules: []AlertEvaluationRule{
+			{Name: "warning", Condition: "GreaterThan", Threshold: &warning, DatapointsToAlarm: 3, EvaluationWindow: 5},
+			{Name: "critical", Condition: "GreaterThanOrEqual", Threshold: &critical, DatapointsToAlarm: 1, EvaluationWindow: 1, Destinations: []AlertDestination{{Type: "pagerduty", ServiceName: "checkout"}}},
+			{Name: "band", Condition: alertConditionOutsideRange, LowerThreshold: &lower, UpperThreshold: &upper, DatapointsToAlarm: 2, EvaluationWindow: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(conditions) != 4 || conditions[0].Static.PersistenceSettings.DatapointsInEvaluationWindow != 5 || conditions[1].Static.Operators[0].Threshold != 95 {
+		t.Fatalf("unexpected conditions %+v", conditions)
+	}
+	if conditions[0].Actions[0].SlackDestination == nil || conditions[1].Actions[0].PagerDutyDestination == nil || len(conditions[1].Actions) != 1 {
+		t.Fatalf("expected rules without destinations to notify the alert destinations, got %+v %+v", conditions[0].Actions, conditions[1].Actions)
+	}
+	low, high := conditions[2], conditions[3]
+	if low.Name != "band low" || len(low.Static.Operators) != 1 || low.Static.Operators[0] != (model.OperatorConfig{Operator: model.LESS_THAN, Threshold: 10}) {
+		t.Fatalf("unexpected low band condition %+v", low.Static)
+	}
+	if high.Name != "band high" || len(high.Static.Operators) != 1 || high.Static.Operators[0] != (model.OperatorConfig{Operator: model.GREATER_THAN, Threshold: 90}) || high.Static.PersistenceSettings.DatapointsToAlarm != 2 {
+		t.Fatalf("unexpected high band condition %+v", high.Static)
+	}
+
+	invalid := map[string]CreateAlertHandlerArgs{
+		"not both":                              {Condition: "GreaterThan", EvaluationRules: []AlertEvaluationRule{{Name: "warning", Condition: "GreaterThan", Threshold: &warning, DatapointsToAlarm: 1, EvaluationWindow: 1}}},
+		"datapoints_to_alarm":                   {Condition: "GreaterThan", Threshold: &zero, DatapointsToAlarm: 2, EvaluationWindow: 1},
+		"threshold is required for GreaterThan": {Condition: "GreaterThan", DatapointsToAlarm: 1, EvaluationWindow: 1},
+		"or evaluation_rules, not both":         {Threshold: &zero, EvaluationRules: []AlertEvaluationRule{{Name: "warning", Condition: "GreaterThan", Threshold: &warning, DatapointsToAlarm: 1, EvaluationWindow: 1}}},
+		"used more than once":                   {EvaluationRules: []AlertEvaluationRule{{Name: "a", Condition: "GreaterThan", Threshold: &warning, DatapointsToAlarm: 1, EvaluationWindow: 1}, {Name: "a", Condition: "LessThan", Threshold: &warning, DatapointsToAlarm: 1, EvaluationWindow: 1}}},
+		"threshold is required":                 {EvaluationRules: []AlertEvaluationRule{{Name: "a", Condition: "GreaterThan", DatapointsToAlarm: 1, EvaluationWindow: 1}}},
+		"must be below":                         {EvaluationRules: []AlertEvaluationRule{{Name: "a", Condition: alertConditionOutsideRange, LowerThreshold: &upper, UpperThreshold: &lower, DatapointsToAlarm: 1, EvaluationWindow: 1}}},
+		"can only be set":                       {EvaluationRules: []AlertEvaluationRule{{Name: "a", Condition: "LessThan", Threshold: &warning, LowerThreshold: &lower, DatapointsToAlarm: 1, EvaluationWindow: 1}}},
+		"band low":                              {EvaluationRules: []AlertEvaluationRule{{Name: "band low", Condition: "LessThan", Threshold: &lower, DatapointsToAlarm: 1, EvaluationWindow: 1}, {Name: "band", Condition: alertConditionOutsideRange, LowerThreshold: &lower, UpperThreshold: &upper, DatapointsToAlarm: 1, EvaluationWindow: 1}}},
+		"needs a name":                          {EvaluationRules: []AlertEvaluationRule{{Condition: "LessThan", Threshold: &warning, DatapointsToAlarm: 1, EvaluationWindow: 1}}},
+	}
+	for expected, arguments := range invalid {
+		if _, err := createAlertConditions(arguments); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestCreateAlertHandlerWithEvaluationRules(t *testing.T) {
+	var alert model.Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":["service.name"]}`))
+		case "/api/v1/metoroql/convert/metricSpecifierToMetoroql":
+			_, _ = w.Write([]byte(`{"queries":["count(traces)"]}`))
+		case "/api/v1/alerts/update":
+			var request model.CreateUpdateAlertRequest
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &request); err != nil {
+				t.Fatalf("failed to decode alert request: %v", err)
+			}
+			alert = request.Alert
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	warning, critical := 80.0, 95.0
+	_, err := CreateAlertHandler(context.Background(), CreateAlertHandlerArgs{
+		AlertName:  "Checkout latency",
+		Timeseries: []model.MetricSpecifier{{MetricType: model.Trace, Aggregation: model.AggregationCount}},
+		EvaluationRules: []AlertEvaluationRule{
+			{Name: "warning", Condition: "GreaterThan", Threshold: &warning, DatapointsToAlarm: 3, EvaluationWindow: 5},
+			{Name: "critical", Condition: "GreaterThan", Threshold: &critical, DatapointsToAlarm: 1, EvaluationWindow: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rules := alert.Timeseries.EvaluationRules
+	if len(rules) != 2 || rules[0].Name != "warning" || rules[1].Static.Operators[0].Threshold != 95 || alert.Timeseries.Expression.MetoroQLTimeseries.Query != "count(traces)" {
+		t.Fatalf("unexpected alert %+v", alert)
+	}
+}
@@ -0,0 +1,389 @@
+package tools
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	anomalyDetectorRobustZScore = "robust_zscore"
+	anomalyDetectorSeasonal     = "seasonal"
+	anomalyDetectorChangePoint  = "changepoint"
+
+	anomalySeverityLow    = "low"
+	anomalySeverityMedium = "medium"
+	anomalySeverityHigh   = "high"
+
+	defaultAnomalyThreshold = 3.5
+
+	// madScale makes the median absolute deviation comparable to the standard deviation of normally distributed data.
+	madScale = 0.6745
+	// Robust z-scores of series without any spread are capped at this value.
+	maxAnomalyScore = 100.0
+
+	// The penalty of every change point is changePointPenalty times the log of the number of points, and segments
+	// have at least changePointMinSegment points so single spikes are left to the other detectors.
+	changePointPenalty    = 3.0
+	changePointMinSegment = 3
+	// Values further than changePointClip times the noise from the median are clipped before segmenting.
+	changePointClip = 5.0
+
+	anomalySeasonPeriod = 24 * time.Hour
+)
+
+var anomalyDetectors = []string{anomalyDetectorRobustZScore, anomalyDetectorSeasonal, anomalyDetectorChangePoint}
+
+type anomalyInterval struct {
+	Series     string            `json:"series"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Detector   string            `json:"detector"`
+	Start      string            `json:"start"`
+	End        string            `json:"end"`
+	// Direction is up when the values were higher than expected and down when they were lower.
+	Direction string  `json:"direction"`
+	Severity  string  `json:"severity"`
+	Score     float64 `json:"score"`
+	// Value is the most anomalous value of the interval, or the mean after the change for change points.
+	Value float64 `json:"value"`
+	// Expected is the value the detector expected, or the mean the segment would have had without the change for
+	// change points.
+	Expected float64 `json:"expected"`
+
+	start int64
+}
+
+// anomalySeries holds the points of a series split into the baseline before the checked window and the checked window.
+type anomalySeries struct {
+	baseline []timeseriesSummaryPoint
+	current  []timeseriesSummaryPoint
+}
+
+func newAnomalySeries(series metricSeries, windowStart int64) anomalySeries {
+	summary := anomalySeries{}
+	for _, point := range metricSeriesPoints(series) {
+		if point.Time < windowStart*1000 {
+			summary.baseline = append(summary.baseline, point)
+		} else {
+			summary.current = append(summary.current, point)
+		}
+	}
+	return summary
+}
+
+// detectRobustZScoreAnomalies flags the points of the window whose robust z-score against the baseline, computed with
+// the median and the median absolute deviation, is above the threshold. Unlike the standard z-score it isn't skewed by
+// the outliers it is looking for.
+func detectRobustZScoreAnomalies(series anomalySeries, threshold float64) []anomalyInterval {
+	reference := series.baseline
+	if len(reference) < 2 {
+		reference = series.current
+	}
+	center, spread := robustLocationAndScale(pointValues(reference))
+	expected := make([]float64, len(series.current))
+	for i := range expected {
+		expected[i] = center
+	}
+	return anomalyIntervalsFromScores(anomalyDetectorRobustZScore, series.current, expected, func(i int) float64 {
+		return robustZScore(series.current[i].Value-center, spread)
+	}, threshold)
+}
+
+// seasonalExpectations expects every point to be the median of the points at the same time of day on the previous
+// days. It returns the expectation of every point of the series with at least one previous day, and nothing when the
+// series covers less than two days.
+func seasonalExpectations(series anomalySeries) map[int64]float64 {
+	all := append(append([]timeseriesSummaryPoint{}, series.baseline...), series.current...)
+	if len(all) == 0 || all[len(all)-1].Time-all[0].Time < 2*anomalySeasonPeriod.Milliseconds() {
+		return nil
+	}
+
+	byTime := make(map[int64]float64, len(all))
+	for _, point := range all {
+		byTime[point.Time] = point.Value
+	}
+	expectations := map[int64]float64{}
+	for _, point := range all {
+		var previous []float64
+		for day := point.Time - anomalySeasonPeriod.Milliseconds(); day >= all[0].Time; day -= anomalySeasonPeriod.Milliseconds() {
+			if value, ok := byTime[day]; ok {
+				previous = append(previous, value)
+			}
+		}
+		if len(previous) > 0 {
+			sort.Float64s(previous)
+			expectations[point.Time] = timeseriesPercentile(previous, 0.5)
+		}
+	}
+	return expectations
+}
+
+// detectSeasonalAnomalies removes the daily season from the series with seasonalExpectations and flags the points of
+// the window whose residual has a robust z-score above the threshold compared to the residuals of the baseline. This
+// finds values that are normal for the series but not for the time of day, e.g. peak time traffic in the middle of the
+// night.
+func detectSeasonalAnomalies(series anomalySeries, threshold float64) []anomalyInterval {
+	expectations := seasonalExpectations(series)
+	var baselineResiduals []float64
+	for _, point := range series.baseline {
+		if expected, ok := expectations[point.Time]; ok {
+			baselineResiduals = append(baselineResiduals, point.Value-expected)
+		}
+	}
+	if len(baselineResiduals) < 2 {
+		return nil
+	}
+	center, spread := robustLocationAndScale(baselineResiduals)
+
+	var points []timeseriesSummaryPoint
+	var expected []float64
+	for _, point := range series.current {
+		if value, ok := expectations[point.Time]; ok {
+			points = append(points, point)
+			expected = append(expected, value)
+		}
+	}
+	return anomalyIntervalsFromScores(anomalyDetectorSeasonal, points, expected, func(i int) float64 {
+		return robustZScore(points[i].Value-expected[i]-center, spread)
+	}, threshold)
+}
+
+// detectChangePoints segments the baseline and the window into stretches of constant level with PELT and reports the
+// segments that start in the window. It finds sustained shifts, such as a latency regression after a deploy, that are
+// too small for any single point to stand out. When the series covers at least two days the daily season is removed
+// first so the daily cycle isn't reported as changes.
+func detectChangePoints(series anomalySeries) []anomalyInterval {
+	all := append(append([]timeseriesSummaryPoint{}, series.baseline...), series.current...)
+	if len(series.current) == 0 || len(all) < 2*changePointMinSegment {
+		return nil
+	}
+
+	expectations := seasonalExpectations(series)
+	var points []timeseriesSummaryPoint
+	var seasonal, adjusted []float64
+	for _, point := range all {
+		expected, ok := expectations[point.Time]
+		if expectations != nil && !ok {
+			continue
+		}
+		points = append(points, point)
+		seasonal = append(seasonal, expected)
+		adjusted = append(adjusted, point.Value-expected)
+	}
+
+	// The noise is estimated from the differences of neighbouring points, which level shifts barely affect.
+	differences := make([]float64, 0, len(adjusted))
+	for i := 1; i < len(adjusted); i++ {
+		differences = append(differences, adjusted[i]-adjusted[i-1])
+	}
+	_, differenceSpread := robustLocationAndScale(differences)
+	noise := differenceSpread / math.Sqrt2
+	if noise == 0 {
+		return nil
+	}
+
+	// Single spikes are clipped for the segmentation so they don't pull the segments around them.
+	center, _ := robustLocationAndScale(adjusted)
+	clipped := make([]float64, len(adjusted))
+	for i, value := range adjusted {
+		clipped[i] = math.Max(center-changePointClip*noise, math.Min(center+changePointClip*noise, value))
+	}
+	boundaries := peltChangePoints(clipped, noise, changePointPenalty*math.Log(float64(len(clipped))), changePointMinSegment)
+	windowStart := series.current[0].Time
+	var intervals []anomalyInterval
+	for i := 1; i < len(boundaries)-1; i++ {
+		previousStart, start, end := boundaries[i-1], boundaries[i], boundaries[i+1]
+		if points[start].Time < windowStart {
+			continue
+		}
+		before := meanOf(adjusted[previousStart:start])
+		after := meanOf(adjusted[start:end])
+		score := math.Abs(after-before) / noise
+		direction := "up"
+		if after < before {
+			direction = "down"
+		}
+		intervals = append(intervals, anomalyInterval{
+			Detector:  anomalyDetectorChangePoint,
+			Start:     formatTimeseriesTime(points[start].Time),
+			End:       formatTimeseriesTime(points[end-1].Time),
+			Direction: direction,
+			Severity:  anomalySeverity(score, 1),
+			Score:     roundTimeseriesValue(math.Min(score, maxAnomalyScore)),
+			Value:     roundTimeseriesValue(meanPointValue(points[start:end])),
+			Expected:  roundTimeseriesValue(meanOf(seasonal[start:end]) + before),
+			start:     points[start].Time,
+		})
+	}
+	return intervals
+}
+
+// peltChangePoints returns the boundaries of the segments of the values that minimize the squared error of every
+// segment to its mean plus the penalty per segment, found with the Pruned Exact Linear Time algorithm. The boundaries
+// start with 0 and end with the number of values.
+func peltChangePoints(values []float64, noise float64, penalty float64, minSegment int) []int {
+	sums := make([]float64, len(values)+1)
+	squares := make([]float64, len(values)+1)
+	for i, value := range values {
+		standardized := value / noise
+		sums[i+1] = sums[i] + standardized
+		squares[i+1] = squares[i] + standardized*standardized
+	}
+	cost := func(from int, to int) float64 {
+		sum := sums[to] - sums[from]
+		return squares[to] - squares[from] - sum*sum/float64(to-from)
+	}
+
+	best := make([]float64, len(values)+1)
+	previous := make([]int, len(values)+1)
+	best[0] = -penalty
+	candidates := []int{0}
+	for end := 1; end <= len(values); end++ {
+		best[end] = math.Inf(1)
+		for _, start := range candidates {
+			if end-start < minSegment {
+				continue
+			}
+			if value := best[start] + cost(start, end) + penalty; value < best[end] {
+				best[end], previous[end] = value, start
+			}
+		}
+
+		pruned := candidates[:0]
+		for _, start := range candidates {
+			if end-start < minSegment || best[start]+cost(start, end) <= best[end] {
+				pruned = append(pruned, start)
+			}
+		}
+		candidates = pruned
+		if !math.IsInf(best[end], 1) {
+			candidates = append(candidates, end)
+		}
+	}
+
+	boundaries := []int{len(values)}
+	for end := len(values); end > 0; end = previous[end] {
+		boundaries = append(boundaries, previous[end])
+	}
+	slices.Reverse(boundaries)
+	return boundaries
+}
+
+// anomalyIntervalsFromScores merges consecutive points whose score is above the threshold into intervals.
+func anomalyIntervalsFromScores(detector string, points []timeseriesSummaryPoint, expected []float64, score func(i int) float64, threshold float64) []anomalyInterval {
+	var intervals []anomalyInterval
+	var current *anomalyInterval
+	for i, point := range points {
+		value := score(i)
+		direction := "up"
+		if value < 0 {
+			direction = "down"
+		}
+		if math.Abs(value) <= threshold {
+			current = nil
+			continue
+		}
+		if current == nil || current.Direction != direction {
+			intervals = append(intervals, anomalyInterval{
+				Detector:  detector,
+				Start:     formatTimeseriesTime(point.Time),
+				Direction: direction,
+				start:     point.Time,
+			})
+			current = &intervals[len(intervals)-1]
+		}
+		current.End = formatTimeseriesTime(point.Time)
+		if math.Abs(value) > current.Score {
+			current.Score = roundTimeseriesValue(math.Abs(value))
+			current.Value = roundTimeseriesValue(point.Value)
+			current.Expected = roundTimeseriesValue(expected[i])
+			current.Severity = anomalySeverity(current.Score, threshold)
+		}
+	}
+	return intervals
+}
+
+// anomalySeverity is high for scores at least twice the threshold and medium for scores at least 1.5 times it.
+func anomalySeverity(score float64, threshold float64) string {
+	switch {
+	case score >= 2*threshold:
+		return anomalySeverityHigh
+	case score >= 1.5*threshold:
+		return anomalySeverityMedium
+	default:
+		return anomalySeverityLow
+	}
+}
+
+func anomalySeverityRank(severity string) int {
+	switch severity {
+	case anomalySeverityHigh:
+		return 2
+	case anomalySeverityMedium:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// robustLocationAndScale returns the median and the median absolute deviation scaled to a standard deviation. When
+// more than half of the values are the same the mean absolute deviation is used instead.
+func robustLocationAndScale(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	median := timeseriesPercentile(sorted, 0.5)
+
+	deviations := make([]float64, len(sorted))
+	meanDeviation := 0.0
+	for i, value := range sorted {
+		deviations[i] = math.Abs(value - median)
+		meanDeviation += deviations[i]
+	}
+	sort.Float64s(deviations)
+	if mad := timeseriesPercentile(deviations, 0.5); mad > 0 {
+		return median, mad / madScale
+	}
+	// 1.2533 scales the mean absolute deviation of normally distributed data to its standard deviation.
+	return median, meanDeviation / float64(len(sorted)) * 1.2533
+}
+
+func robustZScore(deviation float64, spread float64) float64 {
+	if spread == 0 {
+		switch {
+		case deviation > 0:
+			return maxAnomalyScore
+		case deviation < 0:
+			return -maxAnomalyScore
+		default:
+			return 0
+		}
+	}
+	return math.Max(-maxAnomalyScore, math.Min(maxAnomalyScore, deviation/spread))
+}
+
+func pointValues(points []timeseriesSummaryPoint) []float64 {
+	values := make([]float64, len(points))
+	for i, point := range points {
+		values[i] = point.Value
+	}
+	return values
+}
+
+func meanPointValue(points []timeseriesSummaryPoint) float64 {
+	return meanOf(pointValues(points))
+}
+
+func meanOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+// anomalyTestWindowStart is 2026-02-19T10:00:00Z.
+const anomalyTestWindowStart = int64(1771495200)
+
+// newTestAnomalySeries returns hourly points whose value follows a daily cycle between 50 and 150 with a little noise.
+// The baseline covers the 7 days before the window and the window covers the given number of hours.
+func newTestAnomalySeries(hours int, value func(hour int, normal float64) float64) anomalySeries {
+	var series anomalySeries
+	for hour := -7 * 24; hour < hours; hour++ {
+		normal := 100 + 50*math.Sin(2*math.Pi*float64(hour)/24) + float64((hour+1000)*37%7) - 3
+		point := timeseriesSummaryPoint{Time: (anomalyTestWindowStart + int64(hour)*3600) * 1000, Value: value(hour, normal)}
+		if hour < 0 {
+			series.baseline = append(series.baseline, point)
+		} else {
+			series.current = append(series.current, point)
+		}
+	}
+	return series
+}
+
+func TestDetectRobustZScoreAnomalies(t *testing.T) {
+	series := newTestAnomalySeries(12, func(hour int, normal float64) float64 {
+		if hour == 5 || hour == 6 {
+			return 1000
+		}
+		return normal
+	})
+
+	intervals := detectRobustZScoreAnomalies(series, defaultAnomalyThreshold)
+	if len(intervals) != 1 {
+		t.Fatalf("expected a single interval, got %+v", intervals)
+	}
+	interval := intervals[0]
+	if interval.Start != "2026-02-19T15:00:00Z" || interval.End != "2026-02-19T16:00:00Z" || interval.Direction != "up" {
+		t.Fatalf("unexpected interval %+v", interval)
+	}
+	if interval.Severity != anomalySeverityHigh || interval.Value != 1000 || interval.Expected < 90 || interval.Expected > 110 {
+		t.Fatalf("unexpected severity or values %+v", interval)
+	}
+}
+
+func TestDetectSeasonalAnomalies(t *testing.T) {
+	// Peak time traffic at the low point of the daily cycle is within the range of the series but not normal for the
+	// time of day.
+	series := newTestAnomalySeries(24, func(hour int, normal float64) float64 {
+		if hour == 18 {
+			return 150
+		}
+		return normal
+	})
+
+	if intervals := detectRobustZScoreAnomalies(series, defaultAnomalyThreshold); len(intervals) != 0 {
+		t.Fatalf("expected the value to be normal for the series, got %+v", intervals)
+	}
+	intervals := detectSeasonalAnomalies(series, defaultAnomalyThreshold)
+	if len(intervals) != 1 || intervals[0].Start != "2026-02-20T04:00:00Z" || intervals[0].Direction != "up" {
+		t.Fatalf("unexpected intervals %+v", intervals)
+	}
+	if intervals[0].Expected < 47 || intervals[0].Expected > 53 {
+		t.Fatalf("expected the value of the previous days, got %+v", intervals[0])
+	}
+
+	short := anomalySeries{baseline: series.baseline[len(series.baseline)-24:], current: series.current}
+	if intervals := detectSeasonalAnomalies(short, defaultAnomalyThreshold); intervals != nil {
+		t.Fatalf("expected no seasonal detection with a single day, got %+v", intervals)
+	}
+}
+
+func TestDetectChangePoints(t *testing.T) {
+	var series anomalySeries
+	for i := 0; i < 100; i++ {
+		series.baseline = append(series.baseline, timeseriesSummaryPoint{Time: (anomalyTestWindowStart - int64(100-i)*60) * 1000, Value: 100 + float64(i%2*2)})
+	}
+	for i := 0; i < 30; i++ {
+		value := 100 + float64(i%2*2)
+		if i >= 10 {
+			// A regression that is well within the range of single points.
+			value += 4
+		}
+		series.current = append(series.current, timeseriesSummaryPoint{Time: (anomalyTestWindowStart + int64(i)*60) * 1000, Value: value})
+	}
+
+	intervals := detectChangePoints(series)
+	if len(intervals) != 1 {
+		t.Fatalf("expected a single change point, got %+v", intervals)
+	}
+	change := intervals[0]
+	if change.Start != "2026-02-19T10:10:00Z" || change.End != "2026-02-19T10:29:00Z" || change.Direction != "up" {
+		t.Fatalf("unexpected change %+v", change)
+	}
+	if change.Expected != 101 || change.Value != 105 || change.Severity != anomalySeverityHigh {
+		t.Fatalf("unexpected change values %+v", change)
+	}
+}
+
+func TestRobustLocationAndScale(t *testing.T) {
+	center, spread := robustLocationAndScale([]float64{1, 2, 3, 4, 1000})
+	if center != 3 || math.Abs(spread-1/madScale) > 1e-9 {
+		t.Fatalf("expected the outlier to be ignored, got %v %v", center, spread)
+	}
+	// More than half of the values are the same so the mean absolute deviation is used.
+	if center, spread := robustLocationAndScale([]float64{5, 5, 5, 5, 9}); center != 5 || spread == 0 {
+		t.Fatalf("unexpected constant scale %v %v", center, spread)
+	}
+	if robustZScore(1, 0) != maxAnomalyScore || robustZScore(0, 0) != 0 {
+		t.Fatalf("expected capped scores without spread")
+	}
+}
+
+func TestDetectAnomaliesHandler(t *testing.T) {
+	series := newTestAnomalySeries(12, func(hour int, normal float64) float64 {
+		if hour == 5 {
+			return 1000
+		}
+		return normal
+	})
+	var data []map[string]any
+	for _, point := range append(series.baseline, series.current...) {
+		data = append(data, map[string]any{"time": point.Time, "value": point.Value})
+	}
+	body, _ := json.Marshal(map[string]any{
+		"metrics": []map[string]any{
+			{"metric": []map[string]any{{"attributes": map[string]string{"service.name": "checkout"}, "data": data}}},
+			{"metric": []map[string]any{}},
+		},
+	})
+
+	var request model.GetMultiMetricRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":["service.name"]}`))
+		case "/api/v1/metrics":
+			requestBody, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(requestBody, &request); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			_, _ = w.Write(body)
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	arguments := DetectAnomaliesHandlerArgs{
+		TimeConfig: absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T22:00:00Z"),
+		Timeseries: []model.SingleTimeseriesRequest{
+			{Type: model.Trace, Aggregation: model.AggregationCount, BucketSize: 3600, FormulaIdentifier: "a", Splits: []string{"service.name"}},
+			{Type: model.Trace, Aggregation: model.AggregationCount, BucketSize: 3600, FormulaIdentifier: "b", ShouldNotReturn: true},
+		},
+		Formulas:     []model.Formula{{Formula: "a / b", Label: "Share"}},
+		Detectors:    []string{"Robust_ZScore"},
+		BaselineDays: 7,
+	}
+	response, err := DetectAnomaliesHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if request.StartTime != anomalyTestWindowStart-7*24*3600 || request.Metrics[0].Trace.StartTime != request.StartTime {
+		t.Fatalf("expected the baseline to be fetched with the window, got %+v", request)
+	}
+
+	var result detectAnomaliesResponse
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if result.SeriesChecked != 1 || result.TotalAnomalies != 1 || result.BaselineStart != "2026-02-12T10:00:00Z" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	anomaly := result.Anomalies[0]
+	if anomaly.Series != "a" || anomaly.Attributes["service.name"] != "checkout" || anomaly.Detector != anomalyDetectorRobustZScore || anomaly.Start != "2026-02-19T15:00:00Z" {
+		t.Fatalf("unexpected anomaly %+v", anomaly)
+	}
+
+	arguments.Detectors = []string{"prophet"}
+	if _, err := DetectAnomaliesHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error for unknown detector")
+	}
+	arguments.Detectors = nil
+	arguments.BaselineDays = 31
+	if _, err := DetectAnomaliesHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error for too long baseline")
+	}
+}
+
+func TestMetricsResponseSeriesNames(t *testing.T) {
+	timeseries := []model.SingleTimeseriesRequest{{FormulaIdentifier: "a", Label: "Errors"}, {FormulaIdentifier: "b", ShouldNotReturn: true}, {}}
+	formulas := []model.Formula{{Formula: "a / b"}}
+
+	names := metricsResponseSeriesNames(timeseries, formulas, 3)
+	if names[0] != "Errors" || names[1] != "timeseries 3" || names[2] != "a / b" {
+		t.Fatalf("unexpected names %v", names)
+	}
+	if names := metricsResponseSeriesNames(timeseries, formulas, 2); names[1] != "timeseries 2" {
+		t.Fatalf("expected numbered names when the response doesn't match, got %v", names)
+	}
+}
+
+func TestDetectAnomaliesHandlerShortensBaselineInProd(t *testing.T) {
+	var request model.GetMultiMetricRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":[]}`))
+		case "/api/v1/metrics":
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &request); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			_, _ = w.Write([]byte(`{"metrics":[{"metric":[]}]}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+	t.Setenv("IS_PROD", "true")
+
+	period, window := 2, utils.Hours
+	before := time.Now()
+	_, err := DetectAnomaliesHandler(context.Background(), DetectAnomaliesHandlerArgs{
+		TimeConfig:   utils.TimeConfig{Type: utils.RelativeTimeRange, TimePeriod: &period, TimeWindow: &window},
+		Timeseries:   []model.SingleTimeseriesRequest{{Type: model.Trace, Aggregation: model.AggregationCount, BucketSize: 3600}},
+		BaselineDays: 30,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	earliest := before.Add(-30 * 24 * time.Hour).Unix()
+	if request.StartTime < earliest || request.StartTime > earliest+120 || request.Metrics[0].Trace.StartTime != request.StartTime {
+		t.Fatalf("expected the baseline to start 30 days ago, got %d for %d", request.StartTime, earliest)
+	}
+}
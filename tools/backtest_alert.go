@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+const (
+	defaultAlertBacktestDays  = 7
+	maxAlertBacktestDays      = 30
+	maxAlertBacktestIntervals = 20
+)
+
+type BacktestAlertHandlerArgs struct {
+	CreateAlertHandlerArgs
+	Days int `json:"days,omitempty" jsonschema:"description=Optional number of days before now to replay the alert over. Defaults to 7 and can be at most 30."`
+}
+
+type alertBacktestResponse struct {
+	Start           string              `json:"start"`
+	End             string              `json:"end"`
+	BucketSize      int64               `json:"bucketSize"`
+	SeriesEvaluated int                 `json:"seriesEvaluated"`
+	Rules           []alertBacktestRule `json:"rules"`
+}
+
+// alertBacktestRule holds the simulated fires of an evaluation rule of the alert.
+type alertBacktestRule struct {
+	Name            string `json:"name"`
+	Rule            string `json:"rule"`
+	Fires           int    `json:"fires"`
+	FireTimeSeconds int64  `json:"fireTimeSeconds"`
+	FireTime        string `json:"fireTime"`
+	FlapCount       int    `json:"flapCount"`
+	// Series only holds the series that would have fired, the most fires first.
+	Series []alertBacktestSeries `json:"series"`
+}
+
+type alertBacktestSeries struct {
+	Name            string            `json:"name"`
+	Attributes      map[string]string `json:"attributes,omitempty"`
+	Fires           int               `json:"fires"`
+	FireTimeSeconds int64             `json:"fireTimeSeconds"`
+	FlapCount       int               `json:"flapCount"`
+	// Intervals holds the latest fires, OmittedIntervals counts the earlier ones that are left out.
+	Intervals        []alertFireInterval `json:"intervals"`
+	OmittedIntervals int                 `json:"omittedIntervals,omitempty"`
+}
+
+func BacktestAlertHandler(ctx context.Context, arguments BacktestAlertHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	if len(arguments.Timeseries) == 0 {
+		return nil, fmt.Errorf("no timeseries data provided")
+	}
+	days := arguments.Days
+	if days == 0 {
+		days = defaultAlertBacktestDays
+	}
+	if days < 0 || days > maxAlertBacktestDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", maxAlertBacktestDays)
+	}
+	evaluationRules, err := createAlertConditions(arguments.CreateAlertHandlerArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The alert evaluates every bucket so the series are fetched with the bucket size of the alert.
+	timeseries := convertMetricSpecifierToSingleTimeseries(arguments.Timeseries)
+	bucketSize := defaultAlertBucketSize
+	if timeseries[0].BucketSize > 0 {
+		bucketSize = timeseries[0].BucketSize
+	}
+	for i := range timeseries {
+		timeseries[i].BucketSize = bucketSize
+	}
+	var formulas []model.Formula
+	if arguments.Formula.Formula != "" {
+		formulas = []model.Formula{arguments.Formula}
+	}
+
+	endTime := time.Now().Unix()
+	startTime := endTime - int64(days)*int64((24*time.Hour).Seconds())
+	series, names, err := getAlertBacktestSeries(ctx, timeseries, formulas, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	response := alertBacktestResponse{
+		Start:      time.Unix(startTime, 0).UTC().Format(time.RFC3339),
+		End:        time.Unix(endTime, 0).UTC().Format(time.RFC3339),
+		BucketSize: bucketSize,
+		Rules:      []alertBacktestRule{},
+	}
+	for _, item := range series {
+		response.SeriesEvaluated += len(item)
+	}
+	for _, rule := range evaluationRules {
+		if rule.Static == nil {
+			continue
+		}
+		backtest := backtestAlertSeries(series, names, *rule.Static, bucketSize)
+		backtest.Name = rule.Name
+		response.Rules = append(response.Rules, backtest)
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling alert backtest: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
+
+// getAlertBacktestSeries returns the series the alert evaluates with their names. With a formula the alert evaluates
+// the formula, otherwise every returned timeseries.
+func getAlertBacktestSeries(ctx context.Context, timeseries []model.SingleTimeseriesRequest, formulas []model.Formula, startTime int64, endTime int64) ([][]metricSeries, []string, error) {
+	err := checkTimeseries(ctx, timeseries, startTime, endTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := getMultiMetricMetoroCall(ctx, model.GetMultiMetricRequest{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Metrics:   convertTimeseriesToAPITimeseries(timeseries, startTime, endTime),
+		Formulas:  sanitizeFormulas(formulas),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting metric: %v", err)
+	}
+	series, err := parseMetricsResponseSeries(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := metricsResponseSeriesNames(timeseries, formulas, len(series))
+	if len(formulas) > 0 && len(series) > 0 {
+		return series[len(series)-1:], names[len(names)-1:], nil
+	}
+	return series, names, nil
+}
+
+func backtestAlertSeries(series [][]metricSeries, names []string, condition model.StaticCondition, bucketSize int64) alertBacktestRule {
+	backtest := alertBacktestRule{Rule: describeStaticCondition(condition), Series: []alertBacktestSeries{}}
+	flapWindow := time.Duration(condition.PersistenceSettings.DatapointsInEvaluationWindow*bucketSize) * time.Second
+	for i, item := range series {
+		for _, single := range item {
+			fires := replayStaticCondition(metricSeriesPoints(single), condition, bucketSize)
+			if len(fires) == 0 {
+				continue
+			}
+			result := alertBacktestSeries{Name: names[i], Attributes: single.Attributes, Fires: len(fires), FlapCount: alertFlapCount(fires, flapWindow)}
+			for _, fire := range fires {
+				result.FireTimeSeconds += fire.DurationSeconds
+			}
+			result.OmittedIntervals = max(len(fires)-maxAlertBacktestIntervals, 0)
+			result.Intervals = fires[result.OmittedIntervals:]
+
+			backtest.Fires += result.Fires
+			backtest.FireTimeSeconds += result.FireTimeSeconds
+			backtest.FlapCount += result.FlapCount
+			backtest.Series = append(backtest.Series, result)
+		}
+	}
+	sort.SliceStable(backtest.Series, func(i, j int) bool {
+		return backtest.Series[i].Fires > backtest.Series[j].Fires
+	})
+	backtest.FireTime = (time.Duration(backtest.FireTimeSeconds) * time.Second).String()
+	return backtest
+}
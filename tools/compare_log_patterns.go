@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+const (
+	defaultLogPatternMinRateChange = 2.0
+	// logPatternMinChangedCount keeps patterns seen only a handful of times from being reported as changed.
+	logPatternMinChangedCount = 5
+)
+
+type CompareLogPatternsHandlerArgs struct {
+	BaselineTimeConfig   utils.TimeConfig `json:"baseline_time_config" jsonschema:"required,description=The time period to compare against e.g. the hour before a deployment. You can set a relative time period or an absolute time range with start_time and end_time"`
+	ComparisonTimeConfig utils.TimeConfig `json:"comparison_time_config" jsonschema:"required,description=The time period to check for new or changed log patterns e.g. the hour after a deployment. You can set a relative time period or an absolute time range with start_time and end_time"`
+	Filters              []model.Filter   `json:"attributeFilters" jsonschema:"description=You must use get_attribute_keys and get_attribute_values before setting this. Log attributes to restrict both windows to. Keys are ANDed together and values for a key are ORed. Example: [{key: 'service.name' values: ['/k8s/test/test']}]"`
+	ExcludeFilters       []model.Filter   `json:"attributeExcludeFilters" jsonschema:"description=You must use get_attribute_keys and get_attribute_values before setting this. Log attributes to exclude from both windows. Keys are ANDed together and values for a key are ORed."`
+	Regex                string           `json:"regex" jsonschema:"description=Regex to apply to the log search re2 format. Only logs with a message matching the regex are used"`
+	Environments         []string         `json:"environments" jsonschema:"description=The environments to get logs from. If empty logs from all environments will be used"`
+	MaxLogs              int              `json:"max_logs,omitempty" jsonschema:"description=Optional maximum number of log lines to scan in each window. Defaults to 2000 and can be at most 10000."`
+	Limit                int              `json:"limit,omitempty" jsonschema:"description=Optional number of patterns to return for each of new vanished and changed. Defaults to 20 and can be at most 100."`
+	MinRateChange        float64          `json:"min_rate_change,omitempty" jsonschema:"description=Optional factor the rate of a pattern has to go up or down by to be reported as changed. Defaults to 2 meaning at least twice or at most half as frequent."`
+	ResponseContinuationArgs
+}
+
+type logPatternDiffResponse struct {
+	Baseline   logPatternWindowSummary `json:"baseline"`
+	Comparison logPatternWindowSummary `json:"comparison"`
+	// New patterns only appear in the comparison window, most frequent first.
+	New []logPatternDiffEntry `json:"new"`
+	// Vanished patterns only appear in the baseline window, most frequent first.
+	Vanished []logPatternDiffEntry `json:"vanished"`
+	// Changed patterns appear in both windows with a rate change of at least min_rate_change, largest change first.
+	Changed []logPatternDiffEntry `json:"changed"`
+}
+
+type logPatternWindowSummary struct {
+	LogsScanned int  `json:"logsScanned"`
+	Complete    bool `json:"complete"`
+	// ScannedMinutes is the part of the window the scanned logs cover, which is less than the window when
+	// max_logs was reached. Rates are calculated over it.
+	ScannedMinutes float64 `json:"scannedMinutes"`
+}
+
+type logPatternDiffEntry struct {
+	Template                string            `json:"template"`
+	BaselineCount           int               `json:"baselineCount"`
+	ComparisonCount         int               `json:"comparisonCount"`
+	BaselineRatePerMinute   float64           `json:"baselineRatePerMinute"`
+	ComparisonRatePerMinute float64           `json:"comparisonRatePerMinute"`
+	RateChange              float64           `json:"rateChange,omitempty"`
+	FirstSeen               string            `json:"firstSeen"`
+	LastSeen                string            `json:"lastSeen"`
+	Severities              map[string]int    `json:"severities,omitempty"`
+	Services                map[string]int    `json:"services,omitempty"`
+	Example                 logPatternExample `json:"example"`
+
+	score float64
+}
+
+// logPatternWindow holds the patterns of one of the compared time windows.
+type logPatternWindow struct {
+	stats    map[int]*logPatternStats
+	scanned  int
+	complete bool
+	minutes  float64
+}
+
+func CompareLogPatternsHandler(ctx context.Context, arguments CompareLogPatternsHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	maxLogs, limit, err := validateLogPatternLimits(arguments.MaxLogs, arguments.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	minRateChange := arguments.MinRateChange
+	if minRateChange == 0 {
+		minRateChange = defaultLogPatternMinRateChange
+	}
+	if minRateChange <= 1 {
+		return nil, fmt.Errorf("min_rate_change must be greater than 1")
+	}
+
+	comparisonRequest, err := buildLogPatternsRequest(ctx, arguments.ComparisonTimeConfig, arguments.Filters, arguments.ExcludeFilters, arguments.Regex, arguments.Environments)
+	if err != nil {
+		return nil, err
+	}
+	baselineStartTime, baselineEndTime, err := utils.CalculateTimeRange(arguments.BaselineTimeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating baseline time range: %v", err)
+	}
+	baselineRequest := comparisonRequest
+	baselineRequest.StartTime = baselineStartTime
+	baselineRequest.EndTime = baselineEndTime
+
+	// Both windows share one miner so the same kind of line maps to the same template in each.
+	miner := newLogPatternMiner()
+	baseline, err := scanLogPatternWindow(ctx, miner, baselineRequest, maxLogs)
+	if err != nil {
+		return nil, err
+	}
+	comparison, err := scanLogPatternWindow(ctx, miner, comparisonRequest, maxLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	response := diffLogPatternWindows(miner, baseline, comparison, minRateChange, limit)
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling log pattern diff: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
+
+func scanLogPatternWindow(ctx context.Context, miner *logPatternMiner, request model.GetLogsRequest, maxLogs int) (*logPatternWindow, error) {
+	window := &logPatternWindow{stats: map[int]*logPatternStats{}}
+	oldest := int64(0)
+	scanned, complete, err := scanLogs(ctx, request, maxLogs, func(log model.Log) {
+		id := miner.add(log.Message)
+		if window.stats[id] == nil {
+			window.stats[id] = &logPatternStats{}
+		}
+		window.stats[id].add(log)
+		if oldest == 0 || log.Time < oldest {
+			oldest = log.Time
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	window.scanned = scanned
+	window.complete = complete
+	windowStart := time.Unix(request.StartTime, 0)
+	if !complete && oldest != 0 && time.UnixMilli(oldest).After(windowStart) {
+		windowStart = time.UnixMilli(oldest)
+	}
+	window.minutes = math.Max(time.Unix(request.EndTime, 0).Sub(windowStart).Minutes(), 1.0/60)
+	return window, nil
+}
+
+func diffLogPatternWindows(miner *logPatternMiner, baseline *logPatternWindow, comparison *logPatternWindow, minRateChange float64, limit int) logPatternDiffResponse {
+	response := logPatternDiffResponse{
+		Baseline:   baseline.summary(),
+		Comparison: comparison.summary(),
+		New:        []logPatternDiffEntry{},
+		Vanished:   []logPatternDiffEntry{},
+		Changed:    []logPatternDiffEntry{},
+	}
+
+	ids := map[int]struct{}{}
+	for id := range baseline.stats {
+		ids[id] = struct{}{}
+	}
+	for id := range comparison.stats {
+		ids[id] = struct{}{}
+	}
+
+	for id := range ids {
+		baselineStats, comparisonStats := baseline.stats[id], comparison.stats[id]
+		template := miner.template(id)
+
+		switch {
+		case baselineStats == nil:
+			entry := newLogPatternDiffEntry(template, comparisonStats, comparison.scanned, nil, comparisonStats, baseline, comparison)
+			entry.score = entry.ComparisonRatePerMinute
+			response.New = append(response.New, entry)
+		case comparisonStats == nil:
+			entry := newLogPatternDiffEntry(template, baselineStats, baseline.scanned, baselineStats, nil, baseline, comparison)
+			entry.score = entry.BaselineRatePerMinute
+			response.Vanished = append(response.Vanished, entry)
+		default:
+			if max(baselineStats.count, comparisonStats.count) < logPatternMinChangedCount {
+				continue
+			}
+			ratio := (float64(comparisonStats.count) / comparison.minutes) / (float64(baselineStats.count) / baseline.minutes)
+			if ratio < minRateChange && ratio > 1/minRateChange {
+				continue
+			}
+			entry := newLogPatternDiffEntry(template, comparisonStats, comparison.scanned, baselineStats, comparisonStats, baseline, comparison)
+			entry.RateChange = roundLogPatternNumber(ratio)
+			entry.score = math.Abs(math.Log(ratio)) * math.Abs(float64(comparisonStats.count)/comparison.minutes-float64(baselineStats.count)/baseline.minutes)
+			response.Changed = append(response.Changed, entry)
+		}
+	}
+
+	response.New = rankLogPatternDiffEntries(response.New, limit)
+	response.Vanished = rankLogPatternDiffEntries(response.Vanished, limit)
+	response.Changed = rankLogPatternDiffEntries(response.Changed, limit)
+	return response
+}
+
+// newLogPatternDiffEntry describes a pattern with the details and example taken from exampleStats.
+func newLogPatternDiffEntry(template string, exampleStats *logPatternStats, exampleScanned int, baselineStats *logPatternStats, comparisonStats *logPatternStats, baseline *logPatternWindow, comparison *logPatternWindow) logPatternDiffEntry {
+	details := exampleStats.response(template, exampleScanned)
+	entry := logPatternDiffEntry{
+		Template:   template,
+		FirstSeen:  details.FirstSeen,
+		LastSeen:   details.LastSeen,
+		Severities: details.Severities,
+		Services:   details.Services,
+		Example:    details.Example,
+	}
+	if baselineStats != nil {
+		entry.BaselineCount = baselineStats.count
+		entry.BaselineRatePerMinute = roundLogPatternNumber(float64(baselineStats.count) / baseline.minutes)
+	}
+	if comparisonStats != nil {
+		entry.ComparisonCount = comparisonStats.count
+		entry.ComparisonRatePerMinute = roundLogPatternNumber(float64(comparisonStats.count) / comparison.minutes)
+	}
+	return entry
+}
+
+func rankLogPatternDiffEntries(entries []logPatternDiffEntry, limit int) []logPatternDiffEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].score != entries[j].score {
+			return entries[i].score > entries[j].score
+		}
+		return entries[i].Template < entries[j].Template
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+func (w *logPatternWindow) summary() logPatternWindowSummary {
+	return logPatternWindowSummary{
+		LogsScanned:    w.scanned,
+		Complete:       w.complete,
+		ScannedMinutes: roundLogPatternNumber(w.minutes),
+	}
+}
+
+func roundLogPatternNumber(value float64) float64 {
+	return math.Round(value*100) / 100
+}
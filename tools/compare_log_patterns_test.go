@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+func newTestLogPatternWindow(miner *logPatternMiner, minutes float64, messages map[string]int) *logPatternWindow {
+	window := &logPatternWindow{stats: map[int]*logPatternStats{}, complete: true, minutes: minutes}
+	for message, count := range messages {
+		for i := 0; i < count; i++ {
+			id := miner.add(message)
+			if window.stats[id] == nil {
+				window.stats[id] = &logPatternStats{}
+			}
+			window.stats[id].add(model.Log{Time: int64(1771495200000 + i*1000), Message: message, ServiceName: "checkout"})
+			window.scanned++
+		}
+	}
+	return window
+}
+
+func TestDiffLogPatternWindows(t *testing.T) {
+	miner := newLogPatternMiner()
+	baseline := newTestLogPatternWindow(miner, 60, map[string]int{
+		"served request in 12ms":          100,
+		"cache miss for key user:42":      10,
+		"legacy endpoint called by bot 7": 8,
+		"slow query took 900ms":           6,
+	})
+	comparison := newTestLogPatternWindow(miner, 30, map[string]int{
+		"served request in 15ms":                      52,
+		"cache miss for key user:43":                  30,
+		"slow query took 950ms":                       3,
+		"failed to connect to payments: refused (42)": 4,
+	})
+
+	diff := diffLogPatternWindows(miner, baseline, comparison, 2, 20)
+
+	if len(diff.New) != 1 || diff.New[0].Template != "failed to connect to payments: refused <*>" {
+		t.Fatalf("unexpected new patterns %+v", diff.New)
+	}
+	if diff.New[0].ComparisonCount != 4 || diff.New[0].BaselineCount != 0 || diff.New[0].Example.Message == "" {
+		t.Fatalf("unexpected new pattern details %+v", diff.New[0])
+	}
+
+	if len(diff.Vanished) != 1 || diff.Vanished[0].Template != "legacy endpoint called by bot <*>" {
+		t.Fatalf("unexpected vanished patterns %+v", diff.Vanished)
+	}
+
+	// Served requests kept the same rate (100/60m vs 52/30m) and slow queries kept the same rate too.
+	if len(diff.Changed) != 1 || diff.Changed[0].Template != "cache miss for key user:<*>" {
+		t.Fatalf("unexpected changed patterns %+v", diff.Changed)
+	}
+	if diff.Changed[0].RateChange != 6 {
+		t.Fatalf("expected rate change of 6, got %v", diff.Changed[0].RateChange)
+	}
+}
+
+func TestDiffLogPatternWindowsRanksAndLimits(t *testing.T) {
+	miner := newLogPatternMiner()
+	baseline := newTestLogPatternWindow(miner, 10, map[string]int{})
+	comparison := newTestLogPatternWindow(miner, 10, map[string]int{
+		"alpha happened":   1,
+		"beta happened x":  5,
+		"gamma happened y": 3,
+	})
+
+	diff := diffLogPatternWindows(miner, baseline, comparison, 2, 2)
+	if len(diff.New) != 2 {
+		t.Fatalf("expected limit to apply, got %d", len(diff.New))
+	}
+	if diff.New[0].ComparisonCount != 5 || diff.New[1].ComparisonCount != 3 {
+		t.Fatalf("expected most frequent new patterns first, got %+v", diff.New)
+	}
+}
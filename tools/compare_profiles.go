@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+const (
+	profileDiffModeFunctions = "functions"
+	profileDiffModeFolded    = "folded"
+)
+
+type CompareProfilesHandlerArgs struct {
+	ServiceName              string            `json:"serviceName" jsonschema:"required,description=The name of the service to compare profiles for"`
+	BaselineTimeConfig       utils.TimeConfig  `json:"baseline_time_config" jsonschema:"required,description=The time period to compare against e.g. the hour before a new version was deployed. You can set a relative time period or an absolute time range with start_time and end_time"`
+	ComparisonTimeConfig     *utils.TimeConfig `json:"comparison_time_config,omitempty" jsonschema:"description=Optional time period to check for changes e.g. the hour after a new version was deployed. Defaults to the baseline time period which is useful to compare two sets of containers in the same period."`
+	BaselineContainerNames   []string          `json:"baselineContainerNames" jsonschema:"description=The container names to get the baseline profile for. If empty all containers of the service are used"`
+	ComparisonContainerNames []string          `json:"comparisonContainerNames" jsonschema:"description=The container names to get the comparison profile for. If empty all containers of the service are used"`
+	Mode                     string            `json:"mode,omitempty" jsonschema:"enum=functions,enum=folded,description=Optional output mode. functions (default) returns the functions whose share of cpu time grew or shrank the most. folded returns a differential folded stacks diff with the baseline and comparison time of each stack as used by difffolded.pl and flamegraph.pl."`
+	Limit                    int               `json:"limit,omitempty" jsonschema:"description=Optional number of functions to return for each of grew and shrank in functions mode. Defaults to 20 and can be at most 200."`
+	PruneRatio               *float64          `json:"prune_ratio,omitempty" jsonschema:"description=Optional share of the total duration below which calls are left out of the folded diff. Defaults to 0.01. Set 0 to keep all calls. The time of calls left out is counted as time of their caller."`
+}
+
+type profileDiffResponse struct {
+	BaselineTotalDuration   int64 `json:"baselineTotalDuration"`
+	ComparisonTotalDuration int64 `json:"comparisonTotalDuration"`
+	// Grew holds the functions whose share of the profile grew the most. Shares are compared rather than durations
+	// since the profiles can cover different lengths of time.
+	Grew []profileFunctionChange `json:"grew"`
+	// Shrank holds the functions whose share of the profile shrank the most.
+	Shrank []profileFunctionChange `json:"shrank"`
+}
+
+type profileFunctionChange struct {
+	Name                      string  `json:"name"`
+	BaselineSelfPercentage    float64 `json:"baselineSelfPercentage"`
+	ComparisonSelfPercentage  float64 `json:"comparisonSelfPercentage"`
+	SelfPercentageChange      float64 `json:"selfPercentageChange"`
+	BaselineTotalPercentage   float64 `json:"baselineTotalPercentage"`
+	ComparisonTotalPercentage float64 `json:"comparisonTotalPercentage"`
+	TotalPercentageChange     float64 `json:"totalPercentageChange"`
+
+	selfChange  float64
+	totalChange float64
+}
+
+func CompareProfilesHandler(ctx context.Context, arguments CompareProfilesHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	mode := strings.ToLower(strings.TrimSpace(arguments.Mode))
+	if mode == "" {
+		mode = profileDiffModeFunctions
+	}
+	if mode != profileDiffModeFunctions && mode != profileDiffModeFolded {
+		return nil, fmt.Errorf("mode must be %s or %s", profileDiffModeFunctions, profileDiffModeFolded)
+	}
+	pruneRatio, limit, err := validateProfileViewArgs(arguments.PruneRatio, arguments.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	comparisonTimeConfig := arguments.BaselineTimeConfig
+	if arguments.ComparisonTimeConfig != nil {
+		comparisonTimeConfig = *arguments.ComparisonTimeConfig
+	}
+
+	baseline, err := getProfileFlameGraph(ctx, arguments.ServiceName, arguments.BaselineTimeConfig, arguments.BaselineContainerNames)
+	if err != nil {
+		return nil, fmt.Errorf("error getting baseline profile: %v", err)
+	}
+	comparison, err := getProfileFlameGraph(ctx, arguments.ServiceName, comparisonTimeConfig, arguments.ComparisonContainerNames)
+	if err != nil {
+		return nil, fmt.Errorf("error getting comparison profile: %v", err)
+	}
+
+	if mode == profileDiffModeFolded {
+		pruneFlameGraphNodesBelowDurationThreshold(baseline, pruneRatio)
+		pruneFlameGraphNodesBelowDurationThreshold(comparison, pruneRatio)
+		return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(foldProfileStacksDiff(baseline, comparison))), nil
+	}
+
+	body, err := json.Marshal(diffProfileFunctions(baseline, comparison, limit))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling profile diff: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
+
+func getProfileFlameGraph(ctx context.Context, serviceName string, timeConfig utils.TimeConfig, containerNames []string) (*flameGraphNode, error) {
+	startTime, endTime, err := utils.CalculateTimeRange(timeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating time range: %v", err)
+	}
+
+	body, err := getProfilesMetoroCall(ctx, model.GetProfileRequest{
+		StartTime:      startTime,
+		EndTime:        endTime,
+		ServiceName:    serviceName,
+		ContainerNames: containerNames,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseFlameGraph(body)
+}
+
+// diffProfileFunctions compares the share of each function's self and total time of both profiles. Functions are
+// ranked by the change of their self time since that is where the time is actually spent.
+func diffProfileFunctions(baseline *flameGraphNode, comparison *flameGraphNode, limit int) profileDiffResponse {
+	baselineFunctions := profileTopFunctions(baseline, math.MaxInt).Functions
+	comparisonFunctions := profileTopFunctions(comparison, math.MaxInt).Functions
+
+	changes := map[string]*profileFunctionChange{}
+	change := func(name string) *profileFunctionChange {
+		if changes[name] == nil {
+			changes[name] = &profileFunctionChange{Name: name}
+		}
+		return changes[name]
+	}
+	for _, function := range baselineFunctions {
+		entry := change(function.Name)
+		entry.BaselineSelfPercentage = function.SelfPercentage
+		entry.BaselineTotalPercentage = function.TotalPercentage
+		entry.selfChange -= profileShare(function.Self, baseline.Duration)
+		entry.totalChange -= profileShare(function.Total, baseline.Duration)
+	}
+	for _, function := range comparisonFunctions {
+		entry := change(function.Name)
+		entry.ComparisonSelfPercentage = function.SelfPercentage
+		entry.ComparisonTotalPercentage = function.TotalPercentage
+		entry.selfChange += profileShare(function.Self, comparison.Duration)
+		entry.totalChange += profileShare(function.Total, comparison.Duration)
+	}
+
+	response := profileDiffResponse{
+		BaselineTotalDuration:   baseline.Duration,
+		ComparisonTotalDuration: comparison.Duration,
+		Grew:                    []profileFunctionChange{},
+		Shrank:                  []profileFunctionChange{},
+	}
+	for _, entry := range changes {
+		entry.SelfPercentageChange = math.Round(entry.selfChange*10000) / 100
+		entry.TotalPercentageChange = math.Round(entry.totalChange*10000) / 100
+		switch {
+		case entry.selfChange > 0 || (entry.selfChange == 0 && entry.totalChange > 0):
+			response.Grew = append(response.Grew, *entry)
+		case entry.selfChange < 0 || entry.totalChange < 0:
+			response.Shrank = append(response.Shrank, *entry)
+		}
+	}
+
+	response.Grew = rankProfileFunctionChanges(response.Grew, limit)
+	response.Shrank = rankProfileFunctionChanges(response.Shrank, limit)
+	return response
+}
+
+func rankProfileFunctionChanges(changes []profileFunctionChange, limit int) []profileFunctionChange {
+	sort.Slice(changes, func(i, j int) bool {
+		if math.Abs(changes[i].selfChange) != math.Abs(changes[j].selfChange) {
+			return math.Abs(changes[i].selfChange) > math.Abs(changes[j].selfChange)
+		}
+		if math.Abs(changes[i].totalChange) != math.Abs(changes[j].totalChange) {
+			return math.Abs(changes[i].totalChange) > math.Abs(changes[j].totalChange)
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes
+}
+
+// foldProfileStacksDiff returns the stacks of both profiles in the format of difffolded.pl, one line per stack with
+// the baseline and the comparison self time. Baseline times are scaled to the total of the comparison profile so
+// profiles covering different lengths of time can be compared.
+func foldProfileStacksDiff(baseline *flameGraphNode, comparison *flameGraphNode) string {
+	var stacks []string
+	baselineTimes, comparisonTimes := map[string]int64{}, map[string]int64{}
+	walkProfileStacks(baseline, func(stack string, self int64) {
+		if _, ok := baselineTimes[stack]; !ok {
+			stacks = append(stacks, stack)
+		}
+		baselineTimes[stack] += self
+	})
+	walkProfileStacks(comparison, func(stack string, self int64) {
+		if _, ok := baselineTimes[stack]; !ok {
+			if _, ok := comparisonTimes[stack]; !ok {
+				stacks = append(stacks, stack)
+			}
+		}
+		comparisonTimes[stack] += self
+	})
+
+	scale := 1.0
+	if baseline.Duration > 0 && comparison.Duration > 0 {
+		scale = float64(comparison.Duration) / float64(baseline.Duration)
+	}
+
+	lines := make([]string, 0, len(stacks))
+	for _, stack := range stacks {
+		lines = append(lines, fmt.Sprintf("%s %d %d", stack, int64(math.Round(float64(baselineTimes[stack])*scale)), comparisonTimes[stack]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func profileShare(value int64, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) / float64(total)
+}
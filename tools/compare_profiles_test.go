@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+// newTestSlowerFlameGraph returns the profile of newTestFlameGraph over twice the time where json encoding was added
+// and gc got cheaper.
+func newTestSlowerFlameGraph() *flameGraphNode {
+	return &flameGraphNode{
+		Name:     "total",
+		Duration: 2000,
+		Children: []*flameGraphNode{
+			{
+				Name:     "main",
+				Duration: 2000,
+				Children: []*flameGraphNode{
+					{
+						Name:     "handle",
+						Duration: 1900,
+						Children: []*flameGraphNode{
+							{Name: "parse", Duration: 600},
+							{Name: "encode", Duration: 1000},
+						},
+					},
+					{Name: "gc", Duration: 100},
+				},
+			},
+		},
+	}
+}
+
+func TestDiffProfileFunctions(t *testing.T) {
+	diff := diffProfileFunctions(newTestFlameGraph(), newTestSlowerFlameGraph(), 20)
+
+	if len(diff.Grew) != 2 || diff.Grew[0].Name != "encode" || diff.Grew[0].SelfPercentageChange != 50 || diff.Grew[0].BaselineSelfPercentage != 0 {
+		t.Fatalf("unexpected grew %+v", diff.Grew)
+	}
+	// handle spends the same share in itself but more in the functions it calls.
+	if diff.Grew[1].Name != "handle" || diff.Grew[1].SelfPercentageChange != 0 || diff.Grew[1].TotalPercentageChange != 15 {
+		t.Fatalf("unexpected handle change %+v", diff.Grew[1])
+	}
+
+	// parse went from 64.5% to 30% of the profile, gc from 15% to 5% and helper disappeared.
+	var names []string
+	for _, change := range diff.Shrank {
+		names = append(names, change.Name)
+	}
+	if strings.Join(names, ",") != "parse,gc,main,helper" {
+		t.Fatalf("unexpected shrank order %v", names)
+	}
+	if diff.Shrank[0].SelfPercentageChange != -34.5 || diff.Shrank[0].ComparisonSelfPercentage != 30 {
+		t.Fatalf("unexpected parse change %+v", diff.Shrank[0])
+	}
+
+	if limited := diffProfileFunctions(newTestFlameGraph(), newTestSlowerFlameGraph(), 2); len(limited.Shrank) != 2 {
+		t.Fatalf("expected limit to apply, got %d", len(limited.Shrank))
+	}
+}
+
+func TestFoldProfileStacksDiff(t *testing.T) {
+	expected := strings.Join([]string{
+		"main 100 0",
+		"main;handle 200 300",
+		"main;handle;parse 590 600",
+		"main;handle;parse;helper 10 0",
+		"main;handle;handle 100 0",
+		"main;handle;handle;parse 700 0",
+		"main;gc 300 100",
+		"main;handle;encode 0 1000",
+	}, "\n")
+	if folded := foldProfileStacksDiff(newTestFlameGraph(), newTestSlowerFlameGraph()); folded != expected {
+		t.Fatalf("unexpected folded diff\n%s", folded)
+	}
+}
+
+func TestCompareProfilesHandlerDefaultsToBaselineWindow(t *testing.T) {
+	var requests []model.GetProfileRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/profiles" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		var request model.GetProfileRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		requests = append(requests, request)
+
+		profile := newTestFlameGraph()
+		if len(requests) == 2 {
+			profile = newTestSlowerFlameGraph()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		serialized, _ := json.Marshal(profile)
+		_, _ = w.Write(serialized)
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	response, err := CompareProfilesHandler(context.Background(), CompareProfilesHandlerArgs{
+		ServiceName:              "checkout",
+		BaselineTimeConfig:       absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z"),
+		BaselineContainerNames:   []string{"checkout-v1"},
+		ComparisonContainerNames: []string{"checkout-v2"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(requests) != 2 || requests[0].StartTime != requests[1].StartTime || requests[0].EndTime != requests[1].EndTime {
+		t.Fatalf("expected both profiles to cover the baseline window, got %+v", requests)
+	}
+	if requests[0].ContainerNames[0] != "checkout-v1" || requests[1].ContainerNames[0] != "checkout-v2" {
+		t.Fatalf("unexpected container names %+v", requests)
+	}
+
+	var diff profileDiffResponse
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &diff); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if diff.BaselineTotalDuration != 1000 || diff.ComparisonTotalDuration != 2000 || diff.Grew[0].Name != "encode" {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+}
@@ -0,0 +1,404 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+const (
+	// compareTracesSampleSize is the number of traces looked at when a trace to compare has to be picked. They are
+	// read in pages of compareTracesPageSize, newest first.
+	compareTracesSampleSize = 500
+	compareTracesPageSize   = 100
+	compareTracesMaxEntries = 20
+	// compareTracesPathSeparator joins the service and span names from the root to a span.
+	compareTracesPathSeparator = " > "
+)
+
+type CompareTracesHandlerArgs struct {
+	TimeConfig     utils.TimeConfig `json:"time_config" jsonschema:"required,description=The time period the traces are in. e.g. if you want to compare traces of the last 30 minutes you would set time_period=30 and time_window=Minutes. You can also set an absolute time range by setting start_time and end_time"`
+	SlowTraceId    string           `json:"slow_trace_id,omitempty" jsonschema:"description=Optional traceId of the slow trace. If empty the slowest trace matching the filters is used."`
+	FastTraceId    string           `json:"fast_trace_id,omitempty" jsonschema:"description=Optional traceId of the fast trace to compare against. If empty a trace with the median duration of the traces matching the filters with the same root service and span name as the slow trace is used."`
+	Filters        []model.Filter   `json:"filters" jsonschema:"description=Filters used to pick the traces when slow_trace_id or fast_trace_id is empty. You have to get the possible filter keys from the get_attribute_keys tool and possible values of a filter key from the get_attribute_values tool. DO NOT GUESS THE FILTER KEYS OR VALUES. Example: [{key: 'service.name' values: ['/k8s/prod/myservice']}]"`
+	ExcludeFilters []model.Filter   `json:"excludeFilters" jsonschema:"description=Exclude filters used to pick the traces when slow_trace_id or fast_trace_id is empty. You have to get the possible exclude filter keys from the get_attribute_keys tool and possible value for the key from the get_attribute_values tool. DO NOT GUESS THE FILTER KEYS OR VALUES."`
+	Environments   []string         `json:"environments" jsonschema:"description=The environments to get the traces from. If empty all environments will be included"`
+	RootSpanName   string           `json:"root_span_name,omitempty" jsonschema:"description=Optional name of the root span of the traces to pick from e.g. GET /checkout. If empty and no trace id is set the traces are picked from the most common root span of the traces matching the filters."`
+	MinDuration    string           `json:"min_duration,omitempty" jsonschema:"description=Optional minimum duration of the slow trace e.g. 500ms or 2s. Only used when slow_trace_id is empty."`
+	ResponseContinuationArgs
+}
+
+type traceComparisonResponse struct {
+	Slow traceComparisonSummary `json:"slow"`
+	Fast traceComparisonSummary `json:"fast"`
+	// DurationDifferenceReadable is how much longer the slow trace took.
+	DurationDifferenceReadable string `json:"durationDifferenceReadable"`
+	// Added spans only appear in the slow trace, longest first.
+	Added []traceComparisonSpan `json:"added"`
+	// Removed spans only appear in the fast trace, longest first.
+	Removed []traceComparisonSpan `json:"removed"`
+	// Changed spans appear in both traces with a different duration or count, largest growth first.
+	Changed []traceComparisonChange `json:"changed"`
+}
+
+type traceComparisonSummary struct {
+	TraceId          string `json:"traceId"`
+	RootSpan         string `json:"rootSpan"`
+	SpanCount        int    `json:"spanCount"`
+	Duration         int64  `json:"duration"`
+	DurationReadable string `json:"durationReadable"`
+}
+
+type traceComparisonSpan struct {
+	// Path is the service and span names from the root of the trace to the span.
+	Path                  string `json:"path"`
+	Count                 int    `json:"count"`
+	TotalDurationReadable string `json:"totalDurationReadable"`
+
+	totalDuration int64
+}
+
+type traceComparisonChange struct {
+	Path                   string `json:"path"`
+	SlowCount              int    `json:"slowCount"`
+	FastCount              int    `json:"fastCount"`
+	SlowDurationReadable   string `json:"slowDurationReadable"`
+	FastDurationReadable   string `json:"fastDurationReadable"`
+	DurationChangeReadable string `json:"durationChangeReadable"`
+	SelfTimeChangeReadable string `json:"selfTimeChangeReadable"`
+
+	durationChange int64
+}
+
+// traceSpanGroup holds the spans of a trace with the same path, like the iterations of a loop.
+type traceSpanGroup struct {
+	count         int
+	totalDuration int64
+	selfTime      int64
+}
+
+func CompareTracesHandler(ctx context.Context, arguments CompareTracesHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	startTime, endTime, err := utils.CalculateTimeRange(arguments.TimeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating time range: %v", err)
+	}
+
+	var minDuration time.Duration
+	if arguments.MinDuration != "" {
+		minDuration, err = time.ParseDuration(arguments.MinDuration)
+		if err != nil || minDuration < 0 {
+			return nil, fmt.Errorf("min_duration must be a duration like 500ms or 2s")
+		}
+	}
+
+	var candidates []model.TraceEl
+	if arguments.SlowTraceId == "" || arguments.FastTraceId == "" {
+		candidates, err = sampleTracesToCompare(ctx, arguments, startTime, endTime)
+		if err != nil {
+			return nil, err
+		}
+		if arguments.RootSpanName != "" {
+			candidates = tracesWithRootSpanName(candidates, arguments.RootSpanName)
+		}
+	}
+
+	slowTraceId, fastTraceId := arguments.SlowTraceId, arguments.FastTraceId
+	if slowTraceId == "" {
+		slowTraceId, err = pickSlowTrace(candidates, fastTraceId, minDuration.Nanoseconds())
+		if err != nil {
+			return nil, err
+		}
+	}
+	slow, err := getTraceAnalysis(ctx, slowTraceId, startTime, endTime, arguments.Environments)
+	if err != nil {
+		return nil, err
+	}
+
+	if fastTraceId == "" {
+		fastTraceId, err = pickTypicalTrace(candidates, slow.root.span, slowTraceId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fast, err := getTraceAnalysis(ctx, fastTraceId, startTime, endTime, arguments.Environments)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(compareTraceAnalyses(slowTraceId, slow, fastTraceId, fast))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling trace comparison: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
+
+func sampleTracesToCompare(ctx context.Context, arguments CompareTracesHandlerArgs, startTime int64, endTime int64) ([]model.TraceEl, error) {
+	filters := model.FiltersToMap(arguments.Filters)
+	excludeFilters := model.FiltersToMap(arguments.ExcludeFilters)
+	err := CheckAttributes(ctx, model.Trace, filters, excludeFilters, []string{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages are read until the sample is full or there are no more traces, so the slow trace is the slowest of
+	// more than the latest page.
+	var traces []model.TraceEl
+	var prevEndTime *int64
+	for len(traces) < compareTracesSampleSize {
+		limit := min(compareTracesPageSize, compareTracesSampleSize-len(traces))
+		body, err := getTracesMetoroCall(ctx, model.GetTracesRequest{
+			StartTime:      startTime,
+			EndTime:        endTime,
+			Filters:        filters,
+			ExcludeFilters: excludeFilters,
+			Environments:   arguments.Environments,
+			PrevEndTime:    prevEndTime,
+			Limit:          &limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting traces: %v", err)
+		}
+
+		var tracesResponse model.GetTracesResponse
+		if err := json.Unmarshal(body, &tracesResponse); err != nil {
+			return nil, fmt.Errorf("error unmarshaling get traces response: %v", err)
+		}
+		traces = append(traces, tracesResponse.Traces...)
+		if len(tracesResponse.Traces) < limit {
+			break
+		}
+		next := pagePrevEndTime(tracesResponse.Traces[len(tracesResponse.Traces)-1].Time)
+		if prevEndTime != nil && *prevEndTime == next {
+			break
+		}
+		prevEndTime = &next
+	}
+	return traces, nil
+}
+
+func getTraceAnalysis(ctx context.Context, traceId string, startTime int64, endTime int64, environments []string) (*traceAnalysis, error) {
+	body, err := getTraceSpansMetoroCall(ctx, GetSpansForTraceRequest{
+		StartTime:                      startTime,
+		EndTime:                        endTime,
+		TraceId:                        traceId,
+		Environments:                   environments,
+		ShouldReturnNonMetoroEpbfSpans: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting trace spans: %v", err)
+	}
+
+	spans, err := parseTraceSpans(body)
+	if err != nil {
+		return nil, err
+	}
+	analysis := buildTraceAnalysis(spans)
+	if analysis.root == nil {
+		return nil, fmt.Errorf("no spans found for trace %s in the time period", traceId)
+	}
+	return analysis, nil
+}
+
+// pickSlowTrace returns the slowest trace that took at least minDuration nanoseconds. The traces are limited to the
+// root service and span name of the fast trace if it is among the candidates and otherwise to the most common root,
+// so a slow health check isn't compared when the agent is after the busiest endpoint.
+func pickSlowTrace(candidates []model.TraceEl, fastTraceId string, minDuration int64) (string, error) {
+	root, found := mostCommonTraceRoot(candidates)
+	for _, trace := range candidates {
+		if trace.TraceId == fastTraceId {
+			root, found = trace, true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("no traces matching the filters found to pick a slow trace from, set slow_trace_id or change the filters")
+	}
+
+	var slowest *model.TraceEl
+	for _, trace := range tracesWithRoot(candidates, root) {
+		if trace.TraceId != fastTraceId && trace.Duration >= minDuration && (slowest == nil || trace.Duration > slowest.Duration) {
+			slowest = &trace
+		}
+	}
+	if slowest == nil {
+		return "", fmt.Errorf("no traces of %s: %s taking at least %s found to pick a slow trace from, set slow_trace_id or change the filters", root.ServiceName, root.SpanName, formatHumanReadableDuration(minDuration))
+	}
+	return slowest.TraceId, nil
+}
+
+// mostCommonTraceRoot returns a trace with the most common root service and span name of the traces. Ties go to the
+// root seen first.
+func mostCommonTraceRoot(traces []model.TraceEl) (model.TraceEl, bool) {
+	counts := map[[2]string]int{}
+	var root model.TraceEl
+	best := 0
+	for _, trace := range traces {
+		key := [2]string{trace.ServiceName, trace.SpanName}
+		counts[key]++
+		if counts[key] > best {
+			root, best = trace, counts[key]
+		}
+	}
+	return root, best > 0
+}
+
+// pickTypicalTrace returns the trace with the median duration among the traces with the same root as the slow trace.
+func pickTypicalTrace(candidates []model.TraceEl, slowRoot model.TraceEl, slowTraceId string) (string, error) {
+	var traces []model.TraceEl
+	for _, trace := range tracesWithRoot(candidates, slowRoot) {
+		if trace.TraceId != slowTraceId {
+			traces = append(traces, trace)
+		}
+	}
+	if len(traces) == 0 {
+		return "", fmt.Errorf("no other traces of %s: %s found to compare against, set fast_trace_id or change the filters", slowRoot.ServiceName, slowRoot.SpanName)
+	}
+
+	sort.SliceStable(traces, func(i, j int) bool {
+		return traces[i].Duration < traces[j].Duration
+	})
+	return traces[(len(traces)-1)/2].TraceId, nil
+}
+
+func tracesWithRootSpanName(traces []model.TraceEl, spanName string) []model.TraceEl {
+	var matching []model.TraceEl
+	for _, trace := range traces {
+		if trace.SpanName == spanName {
+			matching = append(matching, trace)
+		}
+	}
+	return matching
+}
+
+func tracesWithRoot(traces []model.TraceEl, root model.TraceEl) []model.TraceEl {
+	var matching []model.TraceEl
+	for _, trace := range traces {
+		if trace.ServiceName == root.ServiceName && trace.SpanName == root.SpanName {
+			matching = append(matching, trace)
+		}
+	}
+	return matching
+}
+
+// compareTraceAnalyses aligns the span trees of both traces by the service and span names on the path from the root.
+func compareTraceAnalyses(slowTraceId string, slow *traceAnalysis, fastTraceId string, fast *traceAnalysis) traceComparisonResponse {
+	slowGroups, fastGroups := groupTraceSpansByPath(slow), groupTraceSpansByPath(fast)
+	slowDuration, fastDuration := slow.root.end-slow.root.start, fast.root.end-fast.root.start
+
+	response := traceComparisonResponse{
+		Slow:                       newTraceComparisonSummary(slowTraceId, slow),
+		Fast:                       newTraceComparisonSummary(fastTraceId, fast),
+		DurationDifferenceReadable: formatDurationChange(slowDuration - fastDuration),
+		Added:                      []traceComparisonSpan{},
+		Removed:                    []traceComparisonSpan{},
+		Changed:                    []traceComparisonChange{},
+	}
+
+	for path, slowGroup := range slowGroups {
+		fastGroup, ok := fastGroups[path]
+		if !ok {
+			response.Added = append(response.Added, newTraceComparisonSpan(path, slowGroup))
+			continue
+		}
+		if slowGroup.totalDuration == fastGroup.totalDuration && slowGroup.count == fastGroup.count {
+			continue
+		}
+		response.Changed = append(response.Changed, traceComparisonChange{
+			Path:                   path,
+			SlowCount:              slowGroup.count,
+			FastCount:              fastGroup.count,
+			SlowDurationReadable:   formatHumanReadableDuration(slowGroup.totalDuration),
+			FastDurationReadable:   formatHumanReadableDuration(fastGroup.totalDuration),
+			DurationChangeReadable: formatDurationChange(slowGroup.totalDuration - fastGroup.totalDuration),
+			SelfTimeChangeReadable: formatDurationChange(slowGroup.selfTime - fastGroup.selfTime),
+			durationChange:         slowGroup.totalDuration - fastGroup.totalDuration,
+		})
+	}
+	for path, fastGroup := range fastGroups {
+		if _, ok := slowGroups[path]; !ok {
+			response.Removed = append(response.Removed, newTraceComparisonSpan(path, fastGroup))
+		}
+	}
+
+	sortTraceComparisonSpans(response.Added)
+	sortTraceComparisonSpans(response.Removed)
+	sort.Slice(response.Changed, func(i, j int) bool {
+		if response.Changed[i].durationChange != response.Changed[j].durationChange {
+			return response.Changed[i].durationChange > response.Changed[j].durationChange
+		}
+		return response.Changed[i].Path < response.Changed[j].Path
+	})
+
+	response.Added = response.Added[:min(len(response.Added), compareTracesMaxEntries)]
+	response.Removed = response.Removed[:min(len(response.Removed), compareTracesMaxEntries)]
+	response.Changed = response.Changed[:min(len(response.Changed), compareTracesMaxEntries)]
+	return response
+}
+
+func groupTraceSpansByPath(analysis *traceAnalysis) map[string]*traceSpanGroup {
+	groups := map[string]*traceSpanGroup{}
+	var visit func(node *traceSpanNode, parentPath string)
+	visit = func(node *traceSpanNode, parentPath string) {
+		path := traceSpanLabel(node)
+		if parentPath != "" {
+			path = parentPath + compareTracesPathSeparator + path
+		}
+		if groups[path] == nil {
+			groups[path] = &traceSpanGroup{}
+		}
+		groups[path].count++
+		groups[path].totalDuration += node.end - node.start
+		groups[path].selfTime += node.selfTime
+
+		for _, child := range node.children {
+			visit(child, path)
+		}
+	}
+	for _, root := range analysis.roots {
+		visit(root, "")
+	}
+	return groups
+}
+
+func newTraceComparisonSummary(traceId string, analysis *traceAnalysis) traceComparisonSummary {
+	duration := analysis.root.end - analysis.root.start
+	return traceComparisonSummary{
+		TraceId:          traceId,
+		RootSpan:         traceSpanLabel(analysis.root),
+		SpanCount:        len(analysis.spans),
+		Duration:         duration,
+		DurationReadable: formatHumanReadableDuration(duration),
+	}
+}
+
+func newTraceComparisonSpan(path string, group *traceSpanGroup) traceComparisonSpan {
+	return traceComparisonSpan{
+		Path:                  path,
+		Count:                 group.count,
+		TotalDurationReadable: formatHumanReadableDuration(group.totalDuration),
+		totalDuration:         group.totalDuration,
+	}
+}
+
+func sortTraceComparisonSpans(spans []traceComparisonSpan) {
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].totalDuration != spans[j].totalDuration {
+			return spans[i].totalDuration > spans[j].totalDuration
+		}
+		return spans[i].Path < spans[j].Path
+	})
+}
+
+// formatDurationChange formats a signed difference of two durations in nanoseconds.
+func formatDurationChange(durationNs int64) string {
+	if durationNs < 0 {
+		return "-" + formatHumanReadableDuration(-durationNs)
+	}
+	return "+" + formatHumanReadableDuration(durationNs)
+}
@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+// newTestSlowTraceSpans returns the checkout trace of newTestTraceSpans where the payment insert took longer, the
+// cart was looked up eight times instead of four and a fraud check was added.
+func newTestSlowTraceSpans() []model.TraceEl {
+	spans := []model.TraceEl{
+		newTestSpan("root", "", "frontend", "GET /checkout", 0, 400),
+		newTestSpan("auth", "root", "auth", "verify", 0, 10),
+		newTestSpan("charge", "root", "payments", "charge", 20, 370),
+		newTestSpan("fraud", "charge", "fraud", "score", 25, 40),
+		newTestSpan("insert", "charge", "payments-db", "INSERT payments", 70, 300),
+	}
+	for i := 0; i < 8; i++ {
+		spans = append(spans, newTestSpan("redis-"+string(rune('a'+i)), "root", "cart", "redis GET", int64(2+i), 1))
+	}
+	return spans
+}
+
+func TestCompareTraceAnalyses(t *testing.T) {
+	slow := buildTraceAnalysis(newTestSlowTraceSpans())
+	fast := buildTraceAnalysis(newTestTraceSpans())
+
+	comparison := compareTraceAnalyses("slow", slow, "fast", fast)
+
+	if comparison.Slow.DurationReadable != "400.00 milliseconds" || comparison.DurationDifferenceReadable != "+300.00 milliseconds" {
+		t.Fatalf("unexpected summary %+v %s", comparison.Slow, comparison.DurationDifferenceReadable)
+	}
+	if len(comparison.Added) != 1 || comparison.Added[0].Path != "frontend: GET /checkout > payments: charge > fraud: score" {
+		t.Fatalf("unexpected added spans %+v", comparison.Added)
+	}
+	if len(comparison.Removed) != 0 {
+		t.Fatalf("unexpected removed spans %+v", comparison.Removed)
+	}
+
+	if len(comparison.Changed) != 4 {
+		t.Fatalf("expected 4 changed spans, got %+v", comparison.Changed)
+	}
+	top := comparison.Changed[0]
+	if top.Path != "frontend: GET /checkout" || top.DurationChangeReadable != "+300.00 milliseconds" {
+		t.Fatalf("expected the root to grow most, got %+v", top)
+	}
+	insert := comparison.Changed[2]
+	if insert.Path != "frontend: GET /checkout > payments: charge > payments-db: INSERT payments" || insert.SelfTimeChangeReadable != "+250.00 milliseconds" {
+		t.Fatalf("unexpected insert change %+v", insert)
+	}
+	cart := comparison.Changed[3]
+	if cart.SlowCount != 8 || cart.FastCount != 4 || cart.DurationChangeReadable != "+4.00 milliseconds" {
+		t.Fatalf("unexpected cart change %+v", cart)
+	}
+}
+
+func TestPickTracesToCompare(t *testing.T) {
+	candidates := []model.TraceEl{
+		{TraceId: "a", ServiceName: "frontend", SpanName: "GET /checkout", Duration: 100},
+		{TraceId: "b", ServiceName: "frontend", SpanName: "GET /checkout", Duration: 900},
+		{TraceId: "c", ServiceName: "frontend", SpanName: "GET /checkout", Duration: 300},
+		{TraceId: "d", ServiceName: "frontend", SpanName: "GET /health", Duration: 5000},
+		{TraceId: "e", ServiceName: "frontend", SpanName: "GET /checkout", Duration: 200},
+	}
+
+	slow, err := pickSlowTrace(candidates, "", 0)
+	if err != nil || slow != "b" {
+		t.Fatalf("expected the slowest trace of the most common root, got %s %v", slow, err)
+	}
+	slow, err = pickSlowTrace(tracesWithRootSpanName(candidates, "GET /health"), "", 0)
+	if err != nil || slow != "d" {
+		t.Fatalf("expected the slowest trace of the root span, got %s %v", slow, err)
+	}
+	slow, err = pickSlowTrace(candidates, "a", 0)
+	if err != nil || slow != "b" {
+		t.Fatalf("expected the slowest trace with the root of the fast trace, got %s %v", slow, err)
+	}
+	if _, err := pickSlowTrace(candidates, "", 1000); err == nil {
+		t.Fatalf("expected error when no trace takes at least the min duration")
+	}
+	if _, err := pickSlowTrace(nil, "", 0); err == nil {
+		t.Fatalf("expected error without traces")
+	}
+
+	fast, err := pickTypicalTrace(candidates, candidates[1], "b")
+	if err != nil || fast != "e" {
+		t.Fatalf("expected the median trace, got %s %v", fast, err)
+	}
+	if _, err := pickTypicalTrace(candidates, candidates[3], "d"); err == nil {
+		t.Fatalf("expected error when there is no other trace with the same root")
+	}
+}
+
+func TestCompareTracesHandlerPicksTraces(t *testing.T) {
+	var spanRequests []GetSpansForTraceRequest
+	var traceRequests []model.GetTracesRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":[]}`))
+		case "/api/v1/traces":
+			body, _ := io.ReadAll(r.Body)
+			var request model.GetTracesRequest
+			if err := json.Unmarshal(body, &request); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			traceRequests = append(traceRequests, request)
+
+			// A full first page of health checks and the checkout traces on the second page.
+			var traces []model.TraceEl
+			if request.PrevEndTime == nil {
+				for i := 0; i < *request.Limit; i++ {
+					traces = append(traces, model.TraceEl{TraceId: fmt.Sprintf("health-%d", i), ServiceName: "frontend", SpanName: "GET /health", Time: 1771495500000 - int64(i), Duration: 900000000})
+				}
+			} else {
+				traces = []model.TraceEl{
+					{TraceId: "fast", ServiceName: "frontend", SpanName: "GET /checkout", Time: 1771495300000, Duration: 100000000},
+					{TraceId: "slow", ServiceName: "frontend", SpanName: "GET /checkout", Time: 1771495200000, Duration: 400000000},
+				}
+			}
+			serialized, _ := json.Marshal(model.GetTracesResponse{Traces: traces})
+			_, _ = w.Write(serialized)
+		case "/api/v1/spans":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatalf("failed to read request body: %v", err)
+			}
+			var request GetSpansForTraceRequest
+			if err := json.Unmarshal(body, &request); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			spanRequests = append(spanRequests, request)
+
+			spans := newTestTraceSpans()
+			if request.TraceId == "slow" {
+				spans = newTestSlowTraceSpans()
+			}
+			serialized, _ := json.Marshal(map[string]any{"spans": spans})
+			_, _ = w.Write(serialized)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	response, err := CompareTracesHandler(context.Background(), CompareTracesHandlerArgs{
+		TimeConfig:   absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z"),
+		RootSpanName: "GET /checkout",
+		MinDuration:  "200ms",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(traceRequests) != 2 || *traceRequests[1].PrevEndTime != (1771495500000-99)*1000000 {
+		t.Fatalf("expected the sample to continue on the next page, got %+v", traceRequests)
+	}
+
+	if len(spanRequests) != 2 || spanRequests[0].TraceId != "slow" || spanRequests[1].TraceId != "fast" {
+		t.Fatalf("unexpected span requests %+v", spanRequests)
+	}
+
+	var comparison traceComparisonResponse
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &comparison); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if comparison.Slow.TraceId != "slow" || comparison.Fast.TraceId != "fast" || len(comparison.Added) != 1 {
+		t.Fatalf("unexpected comparison %+v", comparison)
+	}
+}
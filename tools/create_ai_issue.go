@@ -44,7 +44,7 @@ func CreateAIIssueHandler(ctx context.Context, arguments CreateAIIssueHandlerArg
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	responseBody, err := utils.MakeMetoroAPIRequest("POST", "aiIssue", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIWriteRequest(ctx, "POST", "aiIssue", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AI issue: %w", err)
 	}
@@ -13,36 +13,49 @@ import (
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
+// defaultAlertBucketSize is the bucket size of alerts whose timeseries don't set one.
+const defaultAlertBucketSize = int64(60)
+
 type CreateAlertHandlerArgs struct {
 	AlertName         string                  `json:"alert_name" jsonschema:"required,description=The name of the alert to create"`
 	AlertDescription  string                  `json:"alert_description" jsonschema:"required,description=The description of the alert to create"`
 	Timeseries        []model.MetricSpecifier `json:"timeseries" jsonschema:"required,description=Array of timeseries data to get. Each item in this array corresponds to a single timeseries. You can then use the formulas to combine these timeseries. If you only want to see the combination of timeseries via defining formulas and if you dont want to see the individual timeseries data when setting formulas you can set shouldNotReturn to true. For each timeseries make sure to set the type."`
 	Formula           model.Formula           `json:"formula" jsonschema:"description=Optional formula to combine timeseries. Formula should only consist of formulaIdentifier of the timeseries in the timeseries array. e.g. a + b + c if a b c appears in the formulaIdentifier of the timeseries array. You can ONLY do the following operations: Arithmetic operations:+ (for add) - (for substract) * (for multiply) / (for division) % (for modulus) ^ or ** (for exponent). Comparison: == != < > <= >= . Logical:! (for not) && (for AND) || (for OR). Conditional operations: ?: (ternary) e.g. (a || b) ? 1 : 0. Do not guess the operations. Just use these available ones!"`
-	Condition         string                  `json:"condition" jsonschema:"required,enum=GreaterThan,enum=LessThan,enum=GreaterThanOrEqual,enum=LessThanOrEqual,description=the arithmetic comparison to use to evaluate whether an alert is firing or not. This is used to determine whether the alert should be triggered based on the threshold value."`
-	Threshold         float64                 `json:"threshold" jsonschema:"required,description=The threshold value for the alert. This is the value that will be used together with the the arithmetic condition to see whether the alert should be triggered or not. For example if you set the condition to GreaterThan and the threshold to 100 then the alert will fire if the value of the timeseries is greater than 100."`
-	DatapointsToAlarm int64                   `json:"datapoints_to_alarm" jsonschema:"required,description=The number of datapoints that need to breach the threshold for the alert to be triggered"`
-	EvaluationWindow  int64                   `json:"evaluation_window" jsonschema:"required,description=The evaluation window in number of datapoints. This is the number of datapoints that will be considered for evaluating the alert condition. For example if you set this to then the last 5 datapoints will be considered for evaluating the alert condition. This is useful for smoothing out spikes in the data and preventing false positives."`
+	Condition         string                  `json:"condition,omitempty" jsonschema:"enum=GreaterThan,enum=LessThan,enum=GreaterThanOrEqual,enum=LessThanOrEqual,description=the arithmetic comparison to use to evaluate whether an alert is firing or not. This is used to determine whether the alert should be triggered based on the threshold value. Required unless evaluation_rules is set."`
+	Threshold         *float64                `json:"threshold,omitempty" jsonschema:"description=Required unless evaluation_rules is set. The threshold value for the alert. This is the value that will be used together with the the arithmetic condition to see whether the alert should be triggered or not. For example if you set the condition to GreaterThan and the threshold to 100 then the alert will fire if the value of the timeseries is greater than 100."`
+	DatapointsToAlarm int64                   `json:"datapoints_to_alarm,omitempty" jsonschema:"description=The number of datapoints that need to breach the threshold for the alert to be triggered. Required unless evaluation_rules is set."`
+	EvaluationWindow  int64                   `json:"evaluation_window,omitempty" jsonschema:"description=Required unless evaluation_rules is set. The evaluation window in number of datapoints. This is the number of datapoints that will be considered for evaluating the alert condition. For example if you set this to then the last 5 datapoints will be considered for evaluating the alert condition. This is useful for smoothing out spikes in the data and preventing false positives."`
+	Destinations      []AlertDestination      `json:"destinations,omitempty" jsonschema:"description=Optional destinations to notify when the alert fires. Use list_alert_destinations to get the destinations the existing alerts notify. Without destinations the alert notifies nobody."`
+	EvaluationRules   []AlertEvaluationRule   `json:"evaluation_rules,omitempty" jsonschema:"description=Optional evaluation rules instead of condition threshold datapoints_to_alarm and evaluation_window. Use them for severity tiers e.g. a warning rule at 80 and a critical rule at 95 with their own persistence and destinations or for OutsideRange rules."`
 }
 
 func CreateAlertHandler(ctx context.Context, arguments CreateAlertHandlerArgs) (*mcpgolang.ToolResponse, error) {
-	alert, err := createAlertFromTimeseries(ctx, arguments.AlertName, arguments.AlertDescription, arguments.Timeseries, arguments.Formula, arguments.Condition, arguments.Threshold, arguments.DatapointsToAlarm, arguments.EvaluationWindow)
+	evaluationRules, err := createAlertConditions(arguments)
+	if err != nil {
+		return nil, err
+	}
+	alert, err := createAlertFromTimeseries(ctx, arguments.AlertName, arguments.AlertDescription, arguments.Timeseries, arguments.Formula, evaluationRules)
 	if err != nil {
 		return nil, fmt.Errorf("error creating alert properties: %v", err)
 	}
 
+	if err := resolveAlertActionTypes(ctx, &alert); err != nil {
+		return nil, err
+	}
+
 	newAlertRequest := model.CreateUpdateAlertRequest{
 		Alert: alert,
 	}
 
 	resp, err := setAlertMetoroCall(ctx, newAlertRequest)
 	if err != nil {
-		return nil, fmt.Errorf("error setting dashboard: %v", err)
+		return nil, fmt.Errorf("error setting alert: %v", err)
 	}
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(fmt.Sprintf("%s", string(resp)))), nil
 }
 
 // TODO: Implement the conversion logic.
-func createAlertFromTimeseries(ctx context.Context, alertName, alertDescription string, timeseries []model.MetricSpecifier, formula model.Formula, condition string, threshold float64, datapointsToAlarm int64, evaluationWindow int64) (model.Alert, error) {
+func createAlertFromTimeseries(ctx context.Context, alertName, alertDescription string, timeseries []model.MetricSpecifier, formula model.Formula, evaluationRules []model.Condition) (model.Alert, error) {
 	// Create dummy time range for the last 10 minutes to validate the timeseries
 	endTime := time.Now().Unix()
 	startTime := endTime - 600 // 10 minutes ago
@@ -59,40 +72,15 @@ func createAlertFromTimeseries(ctx context.Context, alertName, alertDescription
 		return model.Alert{}, fmt.Errorf("error converting metric specifiers to MetoroQL: %v", err)
 	}
 
-	// Convert condition string to OperatorType
-	var operatorType model.OperatorType
-	switch condition {
-	case "GreaterThan":
-		operatorType = model.GREATER_THAN
-	case "LessThan":
-		operatorType = model.LESS_THAN
-	case "GreaterThanOrEqual":
-		operatorType = model.GREATER_THAN_OR_EQUAL
-	case "LessThanOrEqual":
-		operatorType = model.LESS_THAN_OR_EQUAL
-	default:
-		return model.Alert{}, fmt.Errorf("invalid condition: %s", condition)
-	}
-
 	// Determine bucket size from the timeseries
-	bucketSize := int64(60) // default to 60 seconds
+	bucketSize := defaultAlertBucketSize
 	if len(timeseries) > 0 && timeseries[0].BucketSize > 0 {
 		bucketSize = timeseries[0].BucketSize
 	}
 
-	// Use the first MetoroQL query (usually the combined formula result)
-	query := ""
-	if len(metoroQlQueries) > 0 {
-		for _, q := range metoroQlQueries {
-			if q != "" {
-				query = q
-				break
-			}
-		}
-	}
+	query := alertMetoroQLQuery(metoroQlQueries, formula)
 
 	// Create the alert
-	conditionType := model.STATIC
 	timeseriesType := model.TIMESERIES
 	alert := model.Alert{
 		Metadata: model.MetadataObject{
@@ -108,30 +96,47 @@ func createAlertFromTimeseries(ctx context.Context, alertName, alertDescription
 					BucketSize: bucketSize,
 				},
 			},
-			EvaluationRules: []model.Condition{
-				{
-					Name: "Alert Condition",
-					Type: &conditionType,
-					Static: &model.StaticCondition{
-						Operators: []model.OperatorConfig{
-							{
-								Operator:  operatorType,
-								Threshold: threshold,
-							},
-						},
-						PersistenceSettings: model.PersistenceSettings{
-							DatapointsToAlarm:            datapointsToAlarm,
-							DatapointsInEvaluationWindow: evaluationWindow,
-						},
-					},
-				},
-			},
+			EvaluationRules: evaluationRules,
 		},
 	}
 
 	return alert, nil
 }
 
+// alertOperatorType converts the condition argument of the alert tools to an OperatorType.
+func alertOperatorType(condition string) (model.OperatorType, error) {
+	switch condition {
+	case "GreaterThan":
+		return model.GREATER_THAN, nil
+	case "LessThan":
+		return model.LESS_THAN, nil
+	case "GreaterThanOrEqual":
+		return model.GREATER_THAN_OR_EQUAL, nil
+	case "LessThanOrEqual":
+		return model.LESS_THAN_OR_EQUAL, nil
+	default:
+		return "", fmt.Errorf("invalid condition: %s", condition)
+	}
+}
+
+// alertMetoroQLQuery picks the query the alert evaluates from the converted queries. The queries of the timeseries are
+// returned before the query of the formula, so with a formula the last query is used and otherwise the first one.
+func alertMetoroQLQuery(queries []string, formula model.Formula) string {
+	var nonEmpty []string
+	for _, query := range queries {
+		if query != "" {
+			nonEmpty = append(nonEmpty, query)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return ""
+	}
+	if formula.Formula != "" {
+		return nonEmpty[len(nonEmpty)-1]
+	}
+	return nonEmpty[0]
+}
+
 func convertMetricSpecifierToMetoroQL(ctx context.Context, metricSpecs []model.MetricSpecifier, formulas []model.Formula) ([]string, error) {
 	req := model.MetricSpecifiersRequest{
 		MetricSpecifiers: metricSpecs,
@@ -141,7 +146,7 @@ func convertMetricSpecifierToMetoroQL(ctx context.Context, metricSpecs []model.M
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling MetricSpecifiersRequest: %v", err)
 	}
-	resp, err := utils.MakeMetoroAPIRequest("POST", "metoroql/convert/metricSpecifierToMetoroql", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	resp, err := utils.MakeMetoroAPIRequest(ctx, "POST", "metoroql/convert/metricSpecifierToMetoroql", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("error making MetoroQL conversion request: %v", err)
 	}
@@ -160,7 +165,7 @@ func setAlertMetoroCall(ctx context.Context, request model.CreateUpdateAlertRequ
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling alert request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "alerts/update", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIWriteRequest(ctx, "POST", "alerts/update", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
 
 // convertMetricSpecifierToSingleTimeseries converts MetricSpecifier to SingleTimeseriesRequest
@@ -184,3 +189,25 @@ func convertMetricSpecifierToSingleTimeseries(metricSpecs []model.MetricSpecifie
 	}
 	return result
 }
+
+// convertSingleTimeseriesToMetricSpecifier converts SingleTimeseriesRequest to MetricSpecifier
+func convertSingleTimeseriesToMetricSpecifier(timeseries []model.SingleTimeseriesRequest) []model.MetricSpecifier {
+	result := make([]model.MetricSpecifier, len(timeseries))
+	for i, ts := range timeseries {
+		result[i] = model.MetricSpecifier{
+			MetricType:        ts.Type,
+			MetricName:        ts.MetricName,
+			Filters:           model.FiltersToMap(ts.Filters),
+			ExcludeFilters:    model.FiltersToMap(ts.ExcludeFilters),
+			Regexes:           ts.Regexes,
+			ExcludeRegexes:    ts.ExcludeRegexes,
+			Splits:            ts.Splits,
+			Aggregation:       ts.Aggregation,
+			BucketSize:        ts.BucketSize,
+			Functions:         ts.Functions,
+			ShouldNotReturn:   ts.ShouldNotReturn,
+			FormulaIdentifier: ts.FormulaIdentifier,
+		}
+	}
+	return result
+}
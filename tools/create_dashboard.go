@@ -42,5 +42,5 @@ func setDashboardMetoroCall(ctx context.Context, request model.SetDashboardReque
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling dashboard request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "dashboard", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIWriteRequest(ctx, "POST", "dashboard", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+// sloErrorRatioFormula is the share of bad events of timeseries a (good) and b (total) of sloTimeseries.
+const sloErrorRatioFormula = "(b - a) / b"
+
+type CreateSLOAlertsHandlerArgs struct {
+	SLODefinition
+	PageDestinations   []AlertDestination `json:"page_destinations,omitempty" jsonschema:"description=Optional destinations the page alerts notify e.g. the PagerDuty service of the on-call. Use list_alert_destinations to get the destinations the existing alerts notify."`
+	TicketDestinations []AlertDestination `json:"ticket_destinations,omitempty" jsonschema:"description=Optional destinations the ticket alerts notify e.g. the slack channel of the team. Use list_alert_destinations to get the destinations the existing alerts notify."`
+}
+
+type createdSLOAlert struct {
+	Name     string          `json:"name"`
+	Severity string          `json:"severity"`
+	Response json.RawMessage `json:"response"`
+}
+
+func CreateSLOAlertsHandler(ctx context.Context, arguments CreateSLOAlertsHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	slo, err := normalizeSLODefinition(arguments.SLODefinition)
+	if err != nil {
+		return nil, err
+	}
+
+	pageActions, err := alertDestinationsToActions(arguments.PageDestinations)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page_destinations: %v", err)
+	}
+	ticketActions, err := alertDestinationsToActions(arguments.TicketDestinations)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ticket_destinations: %v", err)
+	}
+
+	// All alerts are built before any is created, so invalid timeseries don't leave some of the alerts behind.
+	alerts := make([]model.Alert, 0, len(sloBurnRateAlerts))
+	for _, burnRateAlert := range sloBurnRateAlerts {
+		alert, err := createSLOBurnRateAlert(ctx, slo, burnRateAlert)
+		if err != nil {
+			return nil, fmt.Errorf("error creating alert properties: %v", err)
+		}
+		if burnRateAlert.Severity == sloSeverityPage {
+			setAlertActions(&alert, pageActions)
+		} else {
+			setAlertActions(&alert, ticketActions)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	alertPointers := make([]*model.Alert, len(alerts))
+	for i := range alerts {
+		alertPointers[i] = &alerts[i]
+	}
+	if err := resolveAlertActionTypes(ctx, alertPointers...); err != nil {
+		return nil, err
+	}
+
+	created := []createdSLOAlert{}
+	for i, alert := range alerts {
+		resp, err := setAlertMetoroCall(ctx, model.CreateUpdateAlertRequest{Alert: alert})
+		if err != nil {
+			if len(created) == 0 {
+				return nil, fmt.Errorf("error setting alert %s: %v", alert.Metadata.Name, err)
+			}
+			names := make([]string, len(created))
+			for j, createdAlert := range created {
+				names[j] = createdAlert.Name
+			}
+			return nil, fmt.Errorf("error setting alert %s: %v. These alerts were already created: %s. Retrying creates them again, delete them first or only create the missing alerts",
+				alert.Metadata.Name, err, strings.Join(names, ", "))
+		}
+		created = append(created, createdSLOAlert{Name: alert.Metadata.Name, Severity: sloBurnRateAlerts[i].Severity, Response: rawJSONResponse(resp)})
+	}
+
+	body, err := json.Marshal(created)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling created alerts: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
+
+// createSLOBurnRateAlert creates an alert on the error ratio of the SLO in buckets of the short window. A Metoro alert
+// can't compare two windows, so the long window condition is checked with two rules over the short window buckets of
+// the long window, both of which imply that the long and the short window burn faster than the burn rate:
+//   - sustained fires when every bucket burns faster than the burn rate.
+//   - fast fires when a few buckets burn so fast that they alone spend the budget share of the long window, so big
+//     outages alert within the short window rather than after the long window.
+func createSLOBurnRateAlert(ctx context.Context, slo SLODefinition, burnRateAlert sloBurnRateAlert) (model.Alert, error) {
+	burnRate := burnRateAlert.burnRate(slo.WindowDays)
+	longWindow, shortWindow := formatSLOWindow(burnRateAlert.LongWindow), formatSLOWindow(burnRateAlert.ShortWindow)
+	name := fmt.Sprintf("%s SLO burn rate %s/%s", slo.Name, longWindow, shortWindow)
+	description := fmt.Sprintf("%s: the %s SLO of %g%% over %d days is burning its error budget more than %gx as fast as allowed over the last %s and %s, spending %g%% of the budget in %s.",
+		burnRateAlert.Severity, slo.Name, slo.Target, slo.WindowDays, roundTimeseriesValue(burnRate), longWindow, shortWindow, burnRateAlert.BudgetShare*100, longWindow)
+
+	timeseries := sloTimeseries(slo, int64(burnRateAlert.ShortWindow.Seconds()))
+	for i := range timeseries {
+		timeseries[i].ShouldNotReturn = true
+	}
+	return createAlertFromTimeseries(ctx, name, description, convertSingleTimeseriesToMetricSpecifier(timeseries), model.Formula{Formula: sloErrorRatioFormula}, sloBurnRateConditions(slo, burnRateAlert))
+}
+
+func sloBurnRateConditions(slo SLODefinition, burnRateAlert sloBurnRateAlert) []model.Condition {
+	errorBudget := 1 - slo.Target/100
+	burnRate := burnRateAlert.burnRate(slo.WindowDays)
+	buckets := int64(burnRateAlert.LongWindow / burnRateAlert.ShortWindow)
+
+	condition := func(name string, threshold float64, datapointsToAlarm int64) model.Condition {
+		operators := []model.OperatorConfig{{Operator: model.GREATER_THAN, Threshold: roundTimeseriesValue(threshold)}}
+		return staticAlertCondition(name, operators, datapointsToAlarm, buckets, nil)
+	}
+
+	conditions := []model.Condition{
+		condition(fmt.Sprintf("Sustained burn rate above %gx", roundTimeseriesValue(burnRate)), burnRate*errorBudget, buckets),
+	}
+	// The error ratio can't be above 1, so the budget share of the long window may need more than one bucket.
+	fastBuckets := int64(math.Floor(burnRate*float64(buckets)*errorBudget)) + 1
+	if fastBuckets < buckets {
+		conditions = append(conditions, condition(fmt.Sprintf("Fast burn rate above %gx", roundTimeseriesValue(burnRate*float64(buckets)/float64(fastBuckets))),
+			burnRate*float64(buckets)*errorBudget/float64(fastBuckets), fastBuckets))
+	}
+	return conditions
+}
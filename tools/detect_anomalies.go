@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+const (
+	defaultAnomalyBaselineDays = 7
+	maxAnomalyBaselineDays     = 30
+	maxAnomalyIntervals        = 50
+)
+
+type DetectAnomaliesHandlerArgs struct {
+	TimeConfig   utils.TimeConfig                `json:"time_config" jsonschema:"required,description=The time period to check for anomalies e.g. the last 1 hour. You can set a relative time period or an absolute time range with start_time and end_time"`
+	Timeseries   []model.SingleTimeseriesRequest `json:"timeseries" jsonschema:"required,description=Array of timeseries to check. Same as the timeseries argument of get_timeseries_data. Use splits to find out which service or endpoint etc. is anomalous."`
+	Formulas     []model.Formula                 `json:"formulas" jsonschema:"description=Optional formulas to combine timeseries. Same as the formulas argument of get_timeseries_data e.g. a / b for an error rate."`
+	BaselineDays int                             `json:"baseline_days,omitempty" jsonschema:"description=Optional number of days before the time period that are used as the baseline of normal behaviour. Defaults to 7 and can be at most 30. The baseline is shortened if it would start more than 30 days ago. The seasonal detector needs at least 2 days."`
+	Detectors    []string                        `json:"detectors,omitempty" jsonschema:"description=Optional detectors to run. robust_zscore flags values far from the baseline median. seasonal flags values unusual for the time of day. changepoint flags sustained shifts of the level of a series. Defaults to all of them."`
+	Threshold    float64                         `json:"threshold,omitempty" jsonschema:"description=Optional robust z-score above which values are anomalous. Defaults to 3.5. Lower it to find subtler anomalies."`
+}
+
+type detectAnomaliesResponse struct {
+	Start          string            `json:"start"`
+	End            string            `json:"end"`
+	BaselineStart  string            `json:"baselineStart"`
+	Detectors      []string          `json:"detectors"`
+	SeriesChecked  int               `json:"seriesChecked"`
+	Anomalies      []anomalyInterval `json:"anomalies"`
+	TotalAnomalies int               `json:"totalAnomalies"`
+}
+
+func DetectAnomaliesHandler(ctx context.Context, arguments DetectAnomaliesHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	if len(arguments.Timeseries) == 0 {
+		return nil, fmt.Errorf("no timeseries data provided")
+	}
+	detectors, err := normalizeAnomalyDetectors(arguments.Detectors)
+	if err != nil {
+		return nil, err
+	}
+	baselineDays := arguments.BaselineDays
+	if baselineDays == 0 {
+		baselineDays = defaultAnomalyBaselineDays
+	}
+	if baselineDays < 0 || baselineDays > maxAnomalyBaselineDays {
+		return nil, fmt.Errorf("baseline_days must be between 1 and %d", maxAnomalyBaselineDays)
+	}
+	threshold := arguments.Threshold
+	if threshold == 0 {
+		threshold = defaultAnomalyThreshold
+	}
+	if threshold < 0 {
+		return nil, fmt.Errorf("threshold must be positive")
+	}
+
+	startTime, endTime, err := utils.CalculateTimeRange(arguments.TimeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating time range: %v", err)
+	}
+	baselineStartTime := startTime - int64(baselineDays)*int64(anomalySeasonPeriod.Seconds())
+	// The baseline is shortened rather than reaching back further than time ranges may start. It starts a minute
+	// later than allowed so the API, which checks against its own clock, doesn't reject it.
+	if earliestStartTime, limited := utils.EarliestStartTime(time.Now()); limited && baselineStartTime < earliestStartTime.Unix()+60 {
+		baselineStartTime = min(earliestStartTime.Unix()+60, startTime)
+	}
+
+	err = checkTimeseries(ctx, arguments.Timeseries, baselineStartTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// The baseline and the checked window are fetched together so both have the same buckets.
+	body, err := getMultiMetricMetoroCall(ctx, model.GetMultiMetricRequest{
+		StartTime: baselineStartTime,
+		EndTime:   endTime,
+		Metrics:   convertTimeseriesToAPITimeseries(arguments.Timeseries, baselineStartTime, endTime),
+		Formulas:  sanitizeFormulas(arguments.Formulas),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting metric: %v", err)
+	}
+
+	var response metricsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling metrics response: %v", err)
+	}
+
+	result := detectAnomaliesResponse{
+		Start:         time.Unix(startTime, 0).UTC().Format(time.RFC3339),
+		End:           time.Unix(endTime, 0).UTC().Format(time.RFC3339),
+		BaselineStart: time.Unix(baselineStartTime, 0).UTC().Format(time.RFC3339),
+		Detectors:     detectors,
+		Anomalies:     []anomalyInterval{},
+	}
+	names := metricsResponseSeriesNames(arguments.Timeseries, arguments.Formulas, len(response.Metrics))
+	for i, item := range response.Metrics {
+		var series []metricSeries
+		if rawSeries, ok := item["metric"]; ok {
+			if err := json.Unmarshal(rawSeries, &series); err != nil {
+				return nil, fmt.Errorf("error unmarshaling metric series: %v", err)
+			}
+		}
+		for _, single := range series {
+			result.SeriesChecked++
+			for _, interval := range detectSeriesAnomalies(newAnomalySeries(single, startTime), detectors, threshold) {
+				interval.Series = names[i]
+				interval.Attributes = single.Attributes
+				result.Anomalies = append(result.Anomalies, interval)
+			}
+		}
+	}
+
+	sort.SliceStable(result.Anomalies, func(i, j int) bool {
+		left, right := result.Anomalies[i], result.Anomalies[j]
+		if anomalySeverityRank(left.Severity) != anomalySeverityRank(right.Severity) {
+			return anomalySeverityRank(left.Severity) > anomalySeverityRank(right.Severity)
+		}
+		if left.Score != right.Score {
+			return left.Score > right.Score
+		}
+		return left.start < right.start
+	})
+	result.TotalAnomalies = len(result.Anomalies)
+	if len(result.Anomalies) > maxAnomalyIntervals {
+		result.Anomalies = result.Anomalies[:maxAnomalyIntervals]
+	}
+
+	serialized, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling anomalies: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(serialized))), nil
+}
+
+func detectSeriesAnomalies(series anomalySeries, detectors []string, threshold float64) []anomalyInterval {
+	var intervals []anomalyInterval
+	for _, detector := range detectors {
+		switch detector {
+		case anomalyDetectorRobustZScore:
+			intervals = append(intervals, detectRobustZScoreAnomalies(series, threshold)...)
+		case anomalyDetectorSeasonal:
+			intervals = append(intervals, detectSeasonalAnomalies(series, threshold)...)
+		case anomalyDetectorChangePoint:
+			intervals = append(intervals, detectChangePoints(series)...)
+		}
+	}
+	return intervals
+}
+
+func normalizeAnomalyDetectors(detectors []string) ([]string, error) {
+	if len(detectors) == 0 {
+		return anomalyDetectors, nil
+	}
+	var normalized []string
+	for _, detector := range detectors {
+		detector = strings.ToLower(strings.TrimSpace(detector))
+		if !slices.Contains(anomalyDetectors, detector) {
+			return nil, fmt.Errorf("detectors must be one of %s", strings.Join(anomalyDetectors, ", "))
+		}
+		if !slices.Contains(normalized, detector) {
+			normalized = append(normalized, detector)
+		}
+	}
+	return normalized, nil
+}
+
+// metricsResponseSeriesNames names the items of the metrics response, which are the returned timeseries followed by
+// the formulas in the order they were requested. Items are numbered if the response doesn't line up with the request.
+func metricsResponseSeriesNames(timeseries []model.SingleTimeseriesRequest, formulas []model.Formula, count int) []string {
+	var names []string
+	for i, ts := range timeseries {
+		if ts.ShouldNotReturn {
+			continue
+		}
+		switch {
+		case ts.Label != "":
+			names = append(names, ts.Label)
+		case ts.FormulaIdentifier != "":
+			names = append(names, ts.FormulaIdentifier)
+		default:
+			names = append(names, fmt.Sprintf("timeseries %d", i+1))
+		}
+	}
+	for _, formula := range formulas {
+		if formula.Label != "" {
+			names = append(names, formula.Label)
+		} else {
+			names = append(names, formula.Formula)
+		}
+	}
+	if len(names) == count {
+		return names
+	}
+
+	names = make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("timeseries %d", i+1)
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+type ExplainTimeseriesQueryHandlerArgs struct {
+	Timeseries []model.SingleTimeseriesRequest `json:"timeseries" jsonschema:"required,description=Array of timeseries to convert in the same shape as the timeseries of get_timeseries_data."`
+	Formulas   []model.Formula                 `json:"formulas" jsonschema:"description=Optional formulas combining the timeseries in the same shape as the formulas of get_timeseries_data."`
+}
+
+type explainedTimeseriesQuery struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+func ExplainTimeseriesQueryHandler(ctx context.Context, arguments ExplainTimeseriesQueryHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	if len(arguments.Timeseries) == 0 {
+		return nil, fmt.Errorf("no timeseries data provided")
+	}
+
+	queries, err := convertMetricSpecifierToMetoroQL(ctx, convertSingleTimeseriesToMetricSpecifier(arguments.Timeseries), sanitizeFormulas(arguments.Formulas))
+	if err != nil {
+		return nil, fmt.Errorf("error converting timeseries to MetoroQL: %v", err)
+	}
+
+	names := metricsResponseSeriesNames(arguments.Timeseries, arguments.Formulas, len(queries))
+	explained := []explainedTimeseriesQuery{}
+	for i, query := range queries {
+		if query == "" {
+			continue
+		}
+		explained = append(explained, explainedTimeseriesQuery{Name: names[i], Query: query})
+	}
+
+	body, err := json.Marshal(explained)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling MetoroQL queries: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
+
+// validateMetoroQL only checks that the brackets of the query are balanced and its strings are terminated. Everything
+// else is validated by metoro when the query is used.
+func validateMetoroQL(query string) error {
+	if query == "" {
+		return fmt.Errorf("query is required")
+	}
+
+	closing := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var open []rune
+	var openAt []int
+	var quote rune
+	quoteAt := 0
+	escaped := false
+	for i, char := range query {
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case char == '\\':
+				escaped = true
+			case char == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch char {
+		case '"', '\'', '`':
+			quote, quoteAt = char, i
+		case '(', '[', '{':
+			open = append(open, char)
+			openAt = append(openAt, i)
+		case ')', ']', '}':
+			if len(open) == 0 || open[len(open)-1] != closing[char] {
+				return fmt.Errorf("invalid MetoroQL query: unexpected %q at position %d", char, i)
+			}
+			open, openAt = open[:len(open)-1], openAt[:len(openAt)-1]
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("invalid MetoroQL query: unterminated string starting at position %d", quoteAt)
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("invalid MetoroQL query: unclosed %q at position %d", open[len(open)-1], openAt[len(openAt)-1])
+	}
+	return nil
+}
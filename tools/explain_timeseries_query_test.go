@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+func TestValidateMetoroQL(t *testing.T) {
+	valid := []string{
+		`sum(rate(http_requests_total{service="checkout"}[5m]))`,
+		`count(traces{service.name="a(b"}) / 2`,
+		`count(logs{message="say \"hi\""})`,
+	}
+	for _, query := range valid {
+		if err := validateMetoroQL(query); err != nil {
+			t.Fatalf("expected %s to be valid, got %v", query, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                           "query is required",
+		`sum(rate(requests[5m])`:     "unclosed '(' at position 3",
+		`sum(requests{service="a")}`: "unexpected ')' at position 24",
+		`count(logs{message="oops})`: "unterminated string starting at position 19",
+		`avg(cpu) + max(memory))`:    "unexpected ')' at position 22",
+	}
+	for query, expected := range invalid {
+		err := validateMetoroQL(query)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q for %s, got %v", expected, query, err)
+		}
+	}
+}
+
+func TestExplainTimeseriesQueryHandler(t *testing.T) {
+	var request model.MetricSpecifiersRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/metoroql/convert/metricSpecifierToMetoroql" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		requestBody, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(requestBody, &request); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"queries":["count(traces{service.name=\"checkout\"})","","errors / total"]}`))
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	response, err := ExplainTimeseriesQueryHandler(context.Background(), ExplainTimeseriesQueryHandlerArgs{
+		Timeseries: []model.SingleTimeseriesRequest{
+			{Type: model.Trace, Aggregation: model.AggregationCount, FormulaIdentifier: "a", Filters: []model.Filter{{Key: "service.name", Values: []string{"checkout"}}}},
+			{Type: model.Trace, Aggregation: model.AggregationCount, FormulaIdentifier: "b", ShouldNotReturn: true},
+		},
+		Formulas: []model.Formula{{Formula: "a / b", Label: "Share"}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if request.MetricSpecifiers[0].Filters["service.name"][0] != "checkout" || request.Formulas[0].Label != "" {
+		t.Fatalf("unexpected conversion request %+v", request)
+	}
+
+	var explained []explainedTimeseriesQuery
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &explained); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(explained) != 2 || explained[1].Name != "timeseries 3" || explained[1].Query != "errors / total" {
+		t.Fatalf("unexpected queries %+v", explained)
+	}
+}
@@ -16,7 +16,7 @@ type GetAIIssueHandlerArgs struct {
 
 func GetAIIssueHandler(ctx context.Context, arguments GetAIIssueHandlerArgs) (*mcpgolang.ToolResponse, error) {
 	endpoint := fmt.Sprintf("aiIssue?uuid=%s", arguments.IssueUUID)
-	responseBody, err := utils.MakeMetoroAPIRequest("GET", endpoint, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIRequest(ctx, "GET", endpoint, nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch AI issue: %w", err)
 	}
@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+type GetAlertHandlerArgs struct {
+	AlertId string `json:"alert_id" jsonschema:"required,description=The ID of the alert to get. Use get_alerts to find the ID of an alert."`
+}
+
+func GetAlertHandler(ctx context.Context, arguments GetAlertHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	if arguments.AlertId == "" {
+		return nil, fmt.Errorf("alert_id is required")
+	}
+	alert, err := getAlert(ctx, arguments.AlertId)
+	if err != nil {
+		return nil, err
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(alert.raw))), nil
+}
+
+// getAlert returns the current definition of the alert. There is no endpoint to get a single alert so it is looked up
+// in the alerts returned by searchAlerts.
+func getAlert(ctx context.Context, alertId string) (searchedAlert, error) {
+	if alertId == "" {
+		return searchedAlert{}, fmt.Errorf("alert_id is required")
+	}
+	alerts, err := searchAlerts(ctx)
+	if err != nil {
+		return searchedAlert{}, err
+	}
+	for _, alert := range alerts {
+		if alert.Metadata.Id == alertId {
+			return alert, nil
+		}
+	}
+	return searchedAlert{}, fmt.Errorf("alert %q not found. Use get_alerts to find the ID of an alert", alertId)
+}
@@ -2,15 +2,88 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
-type GetAlertHandlerArgs struct{}
+type GetAlertsHandlerArgs struct{}
 
-func GetAlertsHandler(ctx context.Context, arguments GetAlertHandlerArgs) (*mcpgolang.ToolResponse, error) {
+// searchAlertsResponse is the response of searchAlerts. The alerts are decoded leniently so fields metoro adds to the
+// response don't break the tools reading it.
+type searchAlertsResponse struct {
+	Alerts []searchedAlert `json:"alerts"`
+}
+
+// searchedAlert keeps the fields of an alert the tools need and the JSON of the whole alert.
+type searchedAlert struct {
+	Metadata struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Timeseries struct {
+		Expression struct {
+			MetoroQLTimeseries *struct {
+				Query string `json:"query"`
+			} `json:"metoroQLTimeseries,omitempty"`
+		} `json:"expression"`
+		EvaluationRules []struct {
+			Actions []searchedAlertAction `json:"actions"`
+		} `json:"evaluationRules"`
+	} `json:"timeseries"`
+	raw json.RawMessage
+}
+
+type searchedAlertAction struct {
+	Type                 string                            `json:"type"`
+	SlackDestination     *model.ActionSlackDestination     `json:"slackDestination,omitempty"`
+	PagerDutyDestination *model.ActionPagerDutyDestination `json:"pagerDutyDestination,omitempty"`
+	EmailDestination     *model.ActionEmailDestination     `json:"emailDestination,omitempty"`
+	WebhookDestination   *model.ActionWebhookDestination   `json:"webhookDestination,omitempty"`
+}
+
+func (a searchedAlertAction) kind() string {
+	return alertActionKind(a.SlackDestination != nil, a.PagerDutyDestination != nil, a.EmailDestination != nil, a.WebhookDestination != nil)
+}
+
+func (a *searchedAlert) UnmarshalJSON(data []byte) error {
+	type alert searchedAlert
+	if err := json.Unmarshal(data, (*alert)(a)); err != nil {
+		return err
+	}
+	a.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (a searchedAlert) query() string {
+	if a.Timeseries.Expression.MetoroQLTimeseries == nil {
+		return ""
+	}
+	return a.Timeseries.Expression.MetoroQLTimeseries.Query
+}
+
+func (a searchedAlert) actions() []searchedAlertAction {
+	var actions []searchedAlertAction
+	for _, rule := range a.Timeseries.EvaluationRules {
+		actions = append(actions, rule.Actions...)
+	}
+	return actions
+}
+
+// model decodes the whole alert strictly. The tools changing alerts send the alert back, so an alert with fields the
+// model doesn't know is rejected instead of losing them.
+func (a searchedAlert) model() (model.Alert, error) {
+	var alert model.Alert
+	if err := json.Unmarshal(a.raw, &alert); err != nil {
+		return model.Alert{}, fmt.Errorf("error unmarshaling alert %s: %v", a.Metadata.Id, err)
+	}
+	return alert, nil
+}
+
+func GetAlertsHandler(ctx context.Context, arguments GetAlertsHandlerArgs) (*mcpgolang.ToolResponse, error) {
 	body, err := getAlertsMetoroCall(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error getting alerts: %v", err)
@@ -18,6 +91,18 @@ func GetAlertsHandler(ctx context.Context, arguments GetAlertHandlerArgs) (*mcpg
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(fmt.Sprintf("%s", string(body)))), nil
 }
 
+func searchAlerts(ctx context.Context) ([]searchedAlert, error) {
+	body, err := getAlertsMetoroCall(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting alerts: %v", err)
+	}
+	var response searchAlertsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling alerts: %v", err)
+	}
+	return response.Alerts, nil
+}
+
 func getAlertsMetoroCall(ctx context.Context) ([]byte, error) {
-	return utils.MakeMetoroAPIRequest("GET", "searchAlerts", nil, utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "GET", "searchAlerts", nil, utils.GetAPIRequirementsFromRequest(ctx))
 }
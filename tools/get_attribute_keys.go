@@ -52,7 +52,7 @@ func GetAttributeKeysHandler(ctx context.Context, arguments GetAttributeKeysHand
 		return nil, fmt.Errorf("error marshaling request: %v", err)
 	}
 
-	resp, err := utils.MakeMetoroAPIRequest("POST", "metrics/attributes", bytes.NewBuffer(jsonBody), utils.GetAPIRequirementsFromRequest(ctx))
+	resp, err := utils.MakeMetoroAPIRequest(ctx, "POST", "metrics/attributes", bytes.NewBuffer(jsonBody), utils.GetAPIRequirementsFromRequest(ctx))
 
 	if err != nil {
 		return nil, fmt.Errorf("error making Metoro call: %v", err)
@@ -92,7 +92,7 @@ func GetAttributeValuesHandler(ctx context.Context, arguments GetAttributeValues
 		return nil, fmt.Errorf("error marshaling request: %v", err)
 	}
 
-	resp, err := utils.MakeMetoroAPIRequest("POST", "metrics/attribute/values", bytes.NewBuffer(jsonBody), utils.GetAPIRequirementsFromRequest(ctx))
+	resp, err := utils.MakeMetoroAPIRequest(ctx, "POST", "metrics/attribute/values", bytes.NewBuffer(jsonBody), utils.GetAPIRequirementsFromRequest(ctx))
 
 	if err != nil {
 		return nil, fmt.Errorf("error making Metoro call: %v", err)
@@ -20,5 +20,5 @@ func GetEnvironmentsHandler(ctx context.Context, arguments GetEnvironmentHandler
 }
 
 func getEnvironmentsMetoroCall(ctx context.Context) ([]byte, error) {
-	return utils.MakeMetoroAPIRequest("GET", "environments", nil, utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "GET", "environments", nil, utils.GetAPIRequirementsFromRequest(ctx))
 }
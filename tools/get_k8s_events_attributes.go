@@ -20,7 +20,7 @@ func GetK8sEventsAttributesHandler(ctx context.Context, arguments GetK8sEventsAt
 		return nil, err
 	}
 
-	resp, err := utils.MakeMetoroAPIRequest("GET", path, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	resp, err := utils.MakeMetoroAPIRequest(ctx, "GET", path, nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, err
 	}
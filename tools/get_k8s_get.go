@@ -102,5 +102,5 @@ func getK8sGetMetoroCall(ctx context.Context, request GetK8sGetRequest) ([]byte,
 		return nil, fmt.Errorf("error marshaling k8s get request: %v", err)
 	}
 
-	return utils.MakeMetoroAPIRequest("POST", "k8s/get", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "k8s/get", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
@@ -89,5 +89,5 @@ func getK8sGetEventsMetoroCall(ctx context.Context, request GetK8sGetEventsReque
 		return nil, fmt.Errorf("error marshaling k8s get events request: %v", err)
 	}
 
-	return utils.MakeMetoroAPIRequest("POST", "k8s/getEvents", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "k8s/getEvents", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
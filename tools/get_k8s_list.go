@@ -20,6 +20,8 @@ type GetK8sListHandlerArgs struct {
 	ResourceKind       string           `json:"resource_kind" jsonschema:"required,description=Kind of the kubernetes resource such as Pod Deployment StatefulSet"`
 	Limit              *int             `json:"limit" jsonschema:"description=Optional page size. Must be greater than zero"`
 	NextPageToken      string           `json:"next_page_token" jsonschema:"description=Optional token from previous page response"`
+	ResponseContinuationArgs
+	OutputFormatArgs
 }
 
 type GetK8sListRequest struct {
@@ -92,5 +94,5 @@ func getK8sListMetoroCall(ctx context.Context, request GetK8sListRequest) ([]byt
 		return nil, fmt.Errorf("error marshaling k8s list request: %v", err)
 	}
 
-	return utils.MakeMetoroAPIRequest("POST", "k8s/list", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "k8s/list", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
@@ -51,5 +51,5 @@ func getLogContextMetoroCall(ctx context.Context, request GetContainerContextLog
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling log context request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "logs/container/context", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "logs/container/context", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
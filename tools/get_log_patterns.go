@@ -0,0 +1,298 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+const (
+	defaultLogPatternsMaxLogs = 2000
+	maxLogPatternsMaxLogs     = 10000
+	defaultLogPatternsLimit   = 20
+	maxLogPatternsLimit       = 100
+	logPatternsPageSize       = 500
+
+	logPatternExampleLengthLimit = 500
+	logPatternTopAttributeValues = 5
+)
+
+type GetLogPatternsHandlerArgs struct {
+	TimeConfig     utils.TimeConfig `json:"time_config" jsonschema:"required,description=The time period to find log patterns in. e.g. if you want the patterns of the last hour you would set time_period=1 and time_window=Hours. You can also set an absoulute time range by setting start_time and end_time"`
+	Filters        []model.Filter   `json:"attributeFilters" jsonschema:"description=You must use get_attribute_keys and get_attribute_values before setting this. Log attributes to restrict the search to. Keys are ANDed together and values for a key are ORed. Example: [{key: 'service.name' values: ['/k8s/test/test']} {key: 'log_level' values: ['error']}]"`
+	ExcludeFilters []model.Filter   `json:"attributeExcludeFilters" jsonschema:"description=You must use get_attribute_keys and get_attribute_values before setting this. Log attributes to exclude from the search. Keys are ANDed together and values for a key are ORed."`
+	Regex          string           `json:"regex" jsonschema:"description=Regex to apply to the log search re2 format. Only logs with a message matching the regex are used"`
+	Environments   []string         `json:"environments" jsonschema:"description=The environments to get logs from. If empty logs from all environments will be used"`
+	MaxLogs        int              `json:"max_logs,omitempty" jsonschema:"description=Optional maximum number of log lines to scan. Defaults to 2000 and can be at most 10000. The newest logs in the time period are scanned first."`
+	Limit          int              `json:"limit,omitempty" jsonschema:"description=Optional number of patterns to return. Defaults to 20 and can be at most 100. The most frequent patterns are returned."`
+	ResponseContinuationArgs
+	OutputFormatArgs
+}
+
+type logPatternsResponse struct {
+	LogsScanned int `json:"logsScanned"`
+	// Complete is false when max_logs was reached before all logs in the time period were scanned.
+	Complete     bool                 `json:"complete"`
+	PatternCount int                  `json:"patternCount"`
+	Patterns     []logPatternResponse `json:"patterns"`
+}
+
+type logPatternResponse struct {
+	Template     string            `json:"template"`
+	Count        int               `json:"count"`
+	Percentage   float64           `json:"percentage"`
+	FirstSeen    string            `json:"firstSeen"`
+	LastSeen     string            `json:"lastSeen"`
+	Severities   map[string]int    `json:"severities,omitempty"`
+	Services     map[string]int    `json:"services,omitempty"`
+	Environments map[string]int    `json:"environments,omitempty"`
+	Example      logPatternExample `json:"example"`
+}
+
+type logPatternExample struct {
+	Time          string            `json:"time"`
+	Message       string            `json:"message"`
+	LogAttributes map[string]string `json:"logAttributes,omitempty"`
+}
+
+// logPatternStats accumulates what is known about the lines of one template.
+type logPatternStats struct {
+	count        int
+	firstSeen    int64
+	lastSeen     int64
+	severities   map[string]int
+	services     map[string]int
+	environments map[string]int
+	example      model.Log
+}
+
+func GetLogPatternsHandler(ctx context.Context, arguments GetLogPatternsHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	maxLogs, limit, err := validateLogPatternLimits(arguments.MaxLogs, arguments.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := buildLogPatternsRequest(ctx, arguments.TimeConfig, arguments.Filters, arguments.ExcludeFilters, arguments.Regex, arguments.Environments)
+	if err != nil {
+		return nil, err
+	}
+
+	miner := newLogPatternMiner()
+	stats := map[int]*logPatternStats{}
+	scanned, complete, err := scanLogs(ctx, request, maxLogs, func(log model.Log) {
+		id := miner.add(log.Message)
+		if stats[id] == nil {
+			stats[id] = &logPatternStats{}
+		}
+		stats[id].add(log)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := logPatternsResponse{
+		LogsScanned:  scanned,
+		Complete:     complete,
+		PatternCount: len(stats),
+		Patterns:     []logPatternResponse{},
+	}
+	for _, id := range sortLogPatternIDs(stats) {
+		if len(response.Patterns) == limit {
+			break
+		}
+		response.Patterns = append(response.Patterns, stats[id].response(miner.template(id), scanned))
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling log patterns: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
+
+func validateLogPatternLimits(maxLogs int, limit int) (int, int, error) {
+	if maxLogs <= 0 {
+		maxLogs = defaultLogPatternsMaxLogs
+	}
+	if maxLogs > maxLogPatternsMaxLogs {
+		return 0, 0, fmt.Errorf("max_logs can be at most %d", maxLogPatternsMaxLogs)
+	}
+	if limit <= 0 {
+		limit = defaultLogPatternsLimit
+	}
+	if limit > maxLogPatternsLimit {
+		return 0, 0, fmt.Errorf("limit can be at most %d", maxLogPatternsLimit)
+	}
+	return maxLogs, limit, nil
+}
+
+func buildLogPatternsRequest(ctx context.Context, timeConfig utils.TimeConfig, filterList []model.Filter, excludeFilterList []model.Filter, regex string, environments []string) (model.GetLogsRequest, error) {
+	startTime, endTime, err := utils.CalculateTimeRange(timeConfig)
+	if err != nil {
+		return model.GetLogsRequest{}, fmt.Errorf("error calculating time range: %v", err)
+	}
+
+	var regexes = []string{}
+	if regex != "" {
+		regexes = append(regexes, regex)
+	}
+
+	filters := model.FiltersToMap(filterList)
+	excludeFilters := model.FiltersToMap(excludeFilterList)
+
+	err = CheckAttributes(ctx, model.Logs, filters, excludeFilters, []string{}, nil)
+	if err != nil {
+		return model.GetLogsRequest{}, err
+	}
+
+	return model.GetLogsRequest{
+		StartTime:      startTime,
+		EndTime:        endTime,
+		Filters:        filters,
+		ExcludeFilters: excludeFilters,
+		Regexes:        regexes,
+		Environments:   environments,
+	}, nil
+}
+
+// scanLogs pages through the logs matching the request, newest first, until maxLogs lines were read or there are
+// no more logs. It reports whether all logs of the time period were read.
+func scanLogs(ctx context.Context, request model.GetLogsRequest, maxLogs int, visit func(model.Log)) (int, bool, error) {
+	scanned := 0
+	for scanned < maxLogs {
+		pageSize := min(logPatternsPageSize, maxLogs-scanned)
+		request.ExportLimit = &pageSize
+
+		body, err := getLogsMetoroCall(ctx, request)
+		if err != nil {
+			return scanned, false, fmt.Errorf("error getting logs: %v", err)
+		}
+
+		var logsResponse model.GetLogsResponse
+		if err := json.Unmarshal(body, &logsResponse); err != nil {
+			return scanned, false, fmt.Errorf("error unmarshaling logs response: %v", err)
+		}
+		if len(logsResponse.Logs) == 0 {
+			return scanned, true, nil
+		}
+
+		for _, log := range logsResponse.Logs[:min(len(logsResponse.Logs), maxLogs-scanned)] {
+			visit(log)
+			scanned++
+		}
+
+		prevEndTime := pagePrevEndTime(logsResponse.Logs[len(logsResponse.Logs)-1].Time)
+		if request.PrevEndTime != nil && *request.PrevEndTime == prevEndTime {
+			return scanned, true, nil
+		}
+		request.PrevEndTime = &prevEndTime
+	}
+
+	return scanned, false, nil
+}
+
+func (s *logPatternStats) add(log model.Log) {
+	if s.count == 0 || log.Time < s.firstSeen {
+		s.firstSeen = log.Time
+	}
+	if s.count == 0 || log.Time > s.lastSeen {
+		s.lastSeen = log.Time
+		s.example = log
+	}
+	s.count++
+
+	s.severities = incrementLogPatternValue(s.severities, log.Severity)
+	s.services = incrementLogPatternValue(s.services, log.ServiceName)
+	s.environments = incrementLogPatternValue(s.environments, log.Environment)
+}
+
+func (s *logPatternStats) response(template string, scanned int) logPatternResponse {
+	message, _ := truncateWithSuffix(s.example.Message, logPatternExampleLengthLimit)
+	attributes := make(map[string]string, len(s.example.LogAttributes))
+	for key, value := range s.example.LogAttributes {
+		attributes[key] = value
+	}
+	trimLogAttributeValues(attributes)
+
+	percentage := 0.0
+	if scanned > 0 {
+		percentage = math.Round(float64(s.count)/float64(scanned)*10000) / 100
+	}
+
+	return logPatternResponse{
+		Template:     template,
+		Count:        s.count,
+		Percentage:   percentage,
+		FirstSeen:    formatLogTime(s.firstSeen),
+		LastSeen:     formatLogTime(s.lastSeen),
+		Severities:   topLogPatternValues(s.severities),
+		Services:     topLogPatternValues(s.services),
+		Environments: topLogPatternValues(s.environments),
+		Example: logPatternExample{
+			Time:          formatLogTime(s.example.Time),
+			Message:       message,
+			LogAttributes: attributes,
+		},
+	}
+}
+
+func incrementLogPatternValue(values map[string]int, value string) map[string]int {
+	if value == "" {
+		return values
+	}
+	if values == nil {
+		values = map[string]int{}
+	}
+	values[value]++
+	return values
+}
+
+// topLogPatternValues keeps the most frequent values so patterns seen on many pods or services stay small.
+func topLogPatternValues(values map[string]int) map[string]int {
+	if len(values) <= logPatternTopAttributeValues {
+		return values
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if values[keys[i]] != values[keys[j]] {
+			return values[keys[i]] > values[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	top := make(map[string]int, logPatternTopAttributeValues)
+	for _, key := range keys[:logPatternTopAttributeValues] {
+		top[key] = values[key]
+	}
+	return top
+}
+
+func sortLogPatternIDs(stats map[int]*logPatternStats) []int {
+	ids := make([]int, 0, len(stats))
+	for id := range stats {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if stats[ids[i]].count != stats[ids[j]].count {
+			return stats[ids[i]].count > stats[ids[j]].count
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// formatLogTime formats the time of a log, which the API returns in milliseconds.
+func formatLogTime(value int64) string {
+	return time.UnixMilli(value).UTC().Format(time.RFC3339Nano)
+}
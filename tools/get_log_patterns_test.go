@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+func TestLogPatternMinerGroupsVariableTokens(t *testing.T) {
+	miner := newLogPatternMiner()
+
+	first := miner.add("connection to 10.0.0.1:5432 timed out after 30s")
+	second := miner.add("connection to 10.0.0.2:5432 timed out after 45s")
+	third := miner.add("user 3f2b9c1e-4f1a-9b2c-8d7e-6a5b4c3d2e1f logged in")
+	fourth := miner.add("payment failed for order=123 reason=card_declined")
+	fifth := miner.add("payment failed for order=456 reason=insufficient_funds")
+
+	if first != second {
+		t.Fatalf("expected timeouts to share a template")
+	}
+	if first == third || third == fourth {
+		t.Fatalf("expected different messages to get different templates")
+	}
+	if fourth != fifth {
+		t.Fatalf("expected payment failures to share a template")
+	}
+
+	if template := miner.template(first); template != "connection to <*> timed out after <*>" {
+		t.Fatalf("unexpected template %q", template)
+	}
+	if template := miner.template(fourth); template != "payment failed for order=<*> <*>" {
+		t.Fatalf("unexpected template %q", template)
+	}
+	if template := miner.template(third); template != "user <*> logged in" {
+		t.Fatalf("unexpected template %q", template)
+	}
+}
+
+func TestLogPatternMinerUsesFirstLineOfStackTraces(t *testing.T) {
+	miner := newLogPatternMiner()
+
+	first := miner.add("panic: nil pointer dereference\n\tat main.go:10\n\tat server.go:20")
+	second := miner.add("panic: nil pointer dereference\n\tat handler.go:99")
+	if first != second {
+		t.Fatalf("expected stack traces with the same message to share a template")
+	}
+}
+
+func TestGetLogPatternsHandlerPagesThroughLogs(t *testing.T) {
+	var requests []model.GetLogsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":[]}`))
+		case "/api/v1/logs":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatalf("failed to read request body: %v", err)
+			}
+			var request model.GetLogsRequest
+			if err := json.Unmarshal(body, &request); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			requests = append(requests, request)
+
+			// Three pages of two logs, newest first, then an empty page.
+			page := len(requests) - 1
+			response := model.GetLogsResponse{Logs: []model.Log{}}
+			if page < 3 {
+				for i := 0; i < 2; i++ {
+					time := int64(1771495500000 - (page*2+i)*1000)
+					message := fmt.Sprintf("request %d failed with status 500", page*2+i)
+					if i == 1 {
+						message = "cache miss for key user:42"
+					}
+					response.Logs = append(response.Logs, model.Log{Time: time, Message: message, Severity: "ERROR", ServiceName: "checkout"})
+				}
+			}
+			serialized, _ := json.Marshal(response)
+			_, _ = w.Write(serialized)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	response, err := GetLogPatternsHandler(context.Background(), GetLogPatternsHandlerArgs{
+		TimeConfig: absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(requests) != 4 {
+		t.Fatalf("expected 4 log requests, got %d", len(requests))
+	}
+	if requests[0].PrevEndTime != nil || requests[1].PrevEndTime == nil || *requests[1].PrevEndTime != 1771495499000000000 {
+		t.Fatalf("expected pages to continue from the last log time in nanoseconds, got %+v", requests[1].PrevEndTime)
+	}
+
+	var patterns logPatternsResponse
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &patterns); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if patterns.LogsScanned != 6 || !patterns.Complete || patterns.PatternCount != 2 {
+		t.Fatalf("unexpected summary %+v", patterns)
+	}
+	top := patterns.Patterns[0]
+	if top.Count != 3 || top.Percentage != 50 || top.Services["checkout"] != 3 {
+		t.Fatalf("unexpected top pattern %+v", top)
+	}
+	if top.FirstSeen != "2026-02-19T10:04:56Z" || top.LastSeen != "2026-02-19T10:05:00Z" {
+		t.Fatalf("unexpected first/last seen %s %s", top.FirstSeen, top.LastSeen)
+	}
+}
+
+func TestValidateLogPatternLimits(t *testing.T) {
+	maxLogs, limit, err := validateLogPatternLimits(0, 0)
+	if err != nil || maxLogs != defaultLogPatternsMaxLogs || limit != defaultLogPatternsLimit {
+		t.Fatalf("unexpected defaults %d %d %v", maxLogs, limit, err)
+	}
+	if _, _, err := validateLogPatternLimits(maxLogPatternsMaxLogs+1, 0); err == nil {
+		t.Fatalf("expected error for max_logs above the cap")
+	}
+	if _, _, err := validateLogPatternLimits(0, maxLogPatternsLimit+1); err == nil {
+		t.Fatalf("expected error for limit above the cap")
+	}
+}
@@ -17,12 +17,15 @@ type GetLogsHandlerArgs struct {
 	ExcludeFilters []model.Filter   `json:"attributeExcludeFilters" jsonschema:"description=You must use get_attribute_keys and get_attribute_values before setting this. Log attributes to exclude from the search. Keys are ANDed together and values for a key are ORed. Get the possible filter keys from the get_attribute_keys tool and possible values of a filter key from the get_attribute_values tool."`
 	Regex          string           `json:"regex" jsonschema:"description=Regex to apply to the log search re2 format. Any match in the log message will cause it to be returned. Use the filters parameter log_level if you want to look for logs of a certain severity"`
 	Environments   []string         `json:"environments" jsonschema:"description=The environments to get logs from. If empty logs from all environments will be returned"`
+	PaginationArgs
+	ResponseContinuationArgs
+	OutputFormatArgs
 }
 
 func GetLogsHandler(ctx context.Context, arguments GetLogsHandlerArgs) (*mcpgolang.ToolResponse, error) {
-	startTime, endTime, err := utils.CalculateTimeRange(arguments.TimeConfig)
+	page, err := resolvePageQuery(pageKindLogs, arguments.PaginationArgs, arguments.TimeConfig)
 	if err != nil {
-		return nil, fmt.Errorf("error calculating time range: %v", err)
+		return nil, err
 	}
 
 	var regexes = []string{}
@@ -38,16 +41,16 @@ func GetLogsHandler(ctx context.Context, arguments GetLogsHandlerArgs) (*mcpgola
 	if err != nil {
 		return nil, err
 	}
-	limit := 20
-
 	request := model.GetLogsRequest{
-		StartTime:      startTime,
-		EndTime:        endTime,
+		StartTime:      page.StartTime,
+		EndTime:        page.EndTime,
 		Filters:        filters,
 		ExcludeFilters: excludeFilters,
+		PrevEndTime:    page.PrevEndTime,
 		Regexes:        regexes,
+		Ascending:      page.Ascending,
 		Environments:   arguments.Environments,
-		ExportLimit:    &limit,
+		ExportLimit:    &page.Limit,
 	}
 
 	resp, err := getLogsMetoroCall(ctx, request)
@@ -58,6 +61,10 @@ func GetLogsHandler(ctx context.Context, arguments GetLogsHandlerArgs) (*mcpgola
 	if err != nil {
 		return nil, fmt.Errorf("error trimming logs: %v", err)
 	}
+	respTrimmed, err = addNextPageCursor(respTrimmed, pageKindLogs, page)
+	if err != nil {
+		return nil, err
+	}
 
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(fmt.Sprintf("%s", string(respTrimmed)))), nil
 }
@@ -67,7 +74,7 @@ func getLogsMetoroCall(ctx context.Context, request model.GetLogsRequest) ([]byt
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling logs request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "logs", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "logs", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
 
 func trimLogsResponse(response []byte) ([]byte, error) {
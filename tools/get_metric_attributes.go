@@ -44,5 +44,5 @@ func getMetricAttributesMetoroCall(ctx context.Context, request model.MetricAttr
 	if err != nil {
 		return nil, err
 	}
-	return utils.MakeMetoroAPIRequest("POST", "metricAttributes", bytes.NewBuffer(jsonData), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "metricAttributes", bytes.NewBuffer(jsonData), utils.GetAPIRequirementsFromRequest(ctx))
 }
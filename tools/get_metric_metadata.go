@@ -22,5 +22,5 @@ func GetMetricMetadata(ctx context.Context, arguments GetMetricMetadataHandlerAr
 }
 
 func getMetricMetadataMetoroCall(ctx context.Context, metricName string) ([]byte, error) {
-	return utils.MakeMetoroAPIRequest("GET", "metric/metadata?name="+metricName, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "GET", "metric/metadata?name="+metricName, nil, utils.GetAPIRequirementsFromRequest(ctx))
 }
@@ -41,5 +41,5 @@ func getMetricNamesMetoroCall(ctx context.Context, request model.FuzzyMetricsReq
 	if err != nil {
 		return nil, err
 	}
-	return utils.MakeMetoroAPIRequest("POST", "fuzzyMetricsNames", bytes.NewBuffer(jsonData), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "fuzzyMetricsNames", bytes.NewBuffer(jsonData), utils.GetAPIRequirementsFromRequest(ctx))
 }
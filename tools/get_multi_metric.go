@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/metoro-io/metoro-mcp-server/model"
 	"github.com/metoro-io/metoro-mcp-server/utils"
@@ -19,14 +20,32 @@ type GetMultiMetricHandlerArgs struct {
 	TimeConfig utils.TimeConfig                `json:"time_config" jsonschema:"required,description=The time period to get the timeseries data for. e.g. if you want to get the timeseries data for the last 5 minutes you would set time_period=5 and time_window=Minutes. You can also set an absoulute time range by setting start_time and end_time"`
 	Timeseries []model.SingleTimeseriesRequest `json:"timeseries" jsonschema:"required,description=Array of timeseries data to get. Each item in this array corresponds to a single timeseries. You can then use the formulas to combine these timeseries. If you only want to see the combination of timeseries via defining formulas and if you dont want to see the individual timeseries data when setting formulas you can set shouldNotReturn to true"`
 	Formulas   []model.Formula                 `json:"formulas" jsonschema:"description=Optional formulas to combine timeseries. Formula should only consist of formulaIdentifier of the timeseries in the timeseries array. e.g. a + b + c if a b c appears in the formulaIdentifier of the timeseries array. You can ONLY do the following operations: Arithmetic operations:+ (for add) - (for substract) * (for multiply) / (for division) % (for modulus) ^ or ** (for exponent). Comparison: == != < > <= >= . Logical:! (for not) && (for AND) || (for OR). Conditional operations: ?: (ternary) e.g. (a || b) ? 1 : 0. Do not guess the operations. Just use these available ones!"`
+	Mode       string                          `json:"mode,omitempty" jsonschema:"enum=raw,enum=summary,description=Optional output mode. raw (default) returns every bucket of every series. summary returns the min max avg p50 p95 and last value the trend per minute the largest step change between two buckets and a sparkline of each series instead which is much smaller and usually enough to see how the data changed."`
+	Points     int                             `json:"points,omitempty" jsonschema:"description=Optional number of points to keep of each series in summary mode. The series are downsampled with LTTB which keeps spikes and dips. Defaults to 0 which leaves out the points. Can be at most 500."`
+	CompareTo  string                          `json:"compare_to,omitempty" jsonschema:"description=Optional period to compare with. previous_period compares with the period of the same length right before the time period. 1d and 7d compare with the same time 1 day or 7 days ago. You can also set any other offset like 6h or 2d or 2w. Returns both the current and the earlier value of every bucket and split with the absolute and percent delta."`
+	ResponseContinuationArgs
 }
 
 func GetMultiMetricHandler(ctx context.Context, arguments GetMultiMetricHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	mode, err := parseTimeseriesMode(arguments.Mode, arguments.Points)
+	if err != nil {
+		return nil, err
+	}
+
 	startTime, endTime, err := utils.CalculateTimeRange(arguments.TimeConfig)
 	if err != nil {
 		return nil, fmt.Errorf("error calculating time range: %v", err)
 	}
 
+	compareTo := strings.ToLower(strings.TrimSpace(arguments.CompareTo))
+	var compareToOffset time.Duration
+	if compareTo != "" {
+		compareToOffset, err = parseCompareToOffset(compareTo, startTime, endTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	err = checkTimeseries(ctx, arguments.Timeseries, startTime, endTime)
 	if err != nil {
 		return nil, err
@@ -47,15 +66,65 @@ func GetMultiMetricHandler(ctx context.Context, arguments GetMultiMetricHandlerA
 	if err != nil {
 		return nil, fmt.Errorf("error getting metric: %v", err)
 	}
+	if compareTo != "" {
+		return compareMultiMetricPeriods(ctx, arguments, mode, compareTo, compareToOffset, body, startTime, endTime)
+	}
+	if mode == timeseriesModeSummary {
+		body, err = summarizeMetricsResponse(body, arguments.Points)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(fmt.Sprintf("%s", string(body)))), nil
 }
 
+// compareMultiMetricPeriods fetches the same timeseries for the comparison period and returns both periods side by side.
+func compareMultiMetricPeriods(ctx context.Context, arguments GetMultiMetricHandlerArgs, mode string, compareTo string, offset time.Duration, body []byte, startTime int64, endTime int64) (*mcpgolang.ToolResponse, error) {
+	comparisonStartTime, comparisonEndTime, err := utils.CalculateTimeRangeWithOffset(arguments.TimeConfig, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating comparison time range: %v", err)
+	}
+
+	comparisonBody, err := getMultiMetricMetoroCall(ctx, model.GetMultiMetricRequest{
+		StartTime: comparisonStartTime,
+		EndTime:   comparisonEndTime,
+		Metrics:   convertTimeseriesToAPITimeseries(arguments.Timeseries, comparisonStartTime, comparisonEndTime),
+		Formulas:  sanitizeFormulas(arguments.Formulas),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting metric for comparison period: %v", err)
+	}
+
+	var response metricsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling metrics response: %v", err)
+	}
+	names := metricsResponseSeriesNames(arguments.Timeseries, arguments.Formulas, len(response.Metrics))
+	items, err := compareMetricsResponses(body, comparisonBody, names, offset, mode, arguments.Points)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized, err := json.Marshal(timeseriesComparisonResponse{
+		CompareTo:       compareTo,
+		Start:           time.Unix(startTime, 0).UTC().Format(time.RFC3339),
+		End:             time.Unix(endTime, 0).UTC().Format(time.RFC3339),
+		ComparisonStart: time.Unix(comparisonStartTime, 0).UTC().Format(time.RFC3339),
+		ComparisonEnd:   time.Unix(comparisonEndTime, 0).UTC().Format(time.RFC3339),
+		Metrics:         items,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling timeseries comparison: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(serialized))), nil
+}
+
 func getMultiMetricMetoroCall(ctx context.Context, request model.GetMultiMetricRequest) ([]byte, error) {
 	requestBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling metric request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "metrics", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "metrics", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
 
 func sanitizeFormulas(formulas []model.Formula) []model.Formula {
@@ -169,7 +238,7 @@ func CheckAttributes(ctx context.Context, requestType model.MetricType, filters
 		return fmt.Errorf("error marshaling request: %v", err)
 	}
 
-	attributeResp, err := utils.MakeMetoroAPIRequest("POST", "metrics/attributes", bytes.NewBuffer(jsonBody), utils.GetAPIRequirementsFromRequest(ctx))
+	attributeResp, err := utils.MakeMetoroAPIRequest(ctx, "POST", "metrics/attributes", bytes.NewBuffer(jsonBody), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return fmt.Errorf("error making Metoro call: %v", err)
 	}
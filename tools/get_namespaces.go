@@ -28,7 +28,7 @@ func getNamespacesMetoroCall(ctx context.Context, arguments GetNamespacesHandler
 		return nil, err
 	}
 
-	return utils.MakeMetoroAPIRequest("GET", path, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "GET", path, nil, utils.GetAPIRequirementsFromRequest(ctx))
 }
 
 func buildGetNamespacesPath(arguments GetNamespacesHandlerArgs) (string, error) {
@@ -29,7 +29,7 @@ func getNodeInfoMetoroCall(ctx context.Context, arguments GetNodeInfoHandlerArgs
 		return nil, err
 	}
 
-	return utils.MakeMetoroAPIRequest("GET", path, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "GET", path, nil, utils.GetAPIRequirementsFromRequest(ctx))
 }
 
 func buildGetNodeInfoPath(arguments GetNodeInfoHandlerArgs) (string, error) {
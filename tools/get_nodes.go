@@ -48,5 +48,5 @@ func getNodesMetoroCall(ctx context.Context, request model.GetAllNodesRequest) (
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling nodes request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "infrastructure/nodes", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "infrastructure/nodes", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
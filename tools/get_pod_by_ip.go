@@ -62,5 +62,5 @@ func getPodByIpMetoroCall(ctx context.Context, request GetResourcesByIpRequest)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling pod by IP request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "k8s/resources/byIp", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "k8s/resources/byIp", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	mcpgolang "github.com/metoro-io/mcp-golang"
 	"github.com/metoro-io/metoro-mcp-server/model"
@@ -15,9 +16,36 @@ type GetProfileHandlerArgs struct {
 	TimeConfig     utils.TimeConfig `json:"time_config" jsonschema:"required,description=The time period to get the profiles data. e.g. if you want to get profiles for the last 5 minutes you would set time_period=5 and time_window=Minutes. You can also set an absoulute time range by setting start_time and end_time"`
 	ServiceName    string           `json:"serviceName" jsonschema:"required,description=The name of the service to get profiles for"`
 	ContainerNames []string         `json:"containerNames" jsonschema:"description=The container names to get profiles for"`
+	Mode           string           `json:"mode,omitempty" jsonschema:"enum=tree,enum=top,enum=folded,description=Optional output mode. tree (default) returns the flame graph as a nested tree. top returns a flat table of the functions taking the most time with their self and total time merged across call sites. folded returns the stacks in the folded stacks format used by flamegraph.pl and speedscope."`
+	Limit          int              `json:"limit,omitempty" jsonschema:"description=Optional number of functions to return in top mode. Defaults to 20 and can be at most 200."`
+	ExportFormat   string           `json:"export_format,omitempty" jsonschema:"enum=pprof,enum=speedscope,description=Optional file format to export the whole profile in instead of returning it as text. pprof is a gzipped profile.proto for go tool pprof and speedscope is a speedscope json file. The file is returned as an embedded resource so the client can save it. Only set this when the user asks for a profile file."`
+	PruneRatio     *float64         `json:"prune_ratio,omitempty" jsonschema:"description=Optional share of the total duration below which calls are left out of the tree and folded modes e.g. 0.05 to only keep calls taking at least 5% of the time. Defaults to 0.01. Set 0 to keep all calls. The time of calls left out is counted as time of their caller."`
 }
 
+const (
+	profileModeTree   = "tree"
+	profileModeTop    = "top"
+	profileModeFolded = "folded"
+)
+
 func GetProfilesHandler(ctx context.Context, arguments GetProfileHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	mode := strings.ToLower(strings.TrimSpace(arguments.Mode))
+	switch mode {
+	case "", profileModeTree, profileModeTop, profileModeFolded:
+	default:
+		return nil, fmt.Errorf("mode must be one of %s, %s or %s", profileModeTree, profileModeTop, profileModeFolded)
+	}
+
+	exportFormat := strings.ToLower(strings.TrimSpace(arguments.ExportFormat))
+	if exportFormat != "" && exportFormat != profileExportFormatPprof && exportFormat != profileExportFormatSpeedscope {
+		return nil, fmt.Errorf("export_format must be %s or %s", profileExportFormatPprof, profileExportFormatSpeedscope)
+	}
+
+	pruneRatio, limit, err := validateProfileViewArgs(arguments.PruneRatio, arguments.Limit)
+	if err != nil {
+		return nil, err
+	}
+
 	startTime, endTime, err := utils.CalculateTimeRange(arguments.TimeConfig)
 	if err != nil {
 		return nil, fmt.Errorf("error calculating time range: %v", err)
@@ -34,7 +62,35 @@ func GetProfilesHandler(ctx context.Context, arguments GetProfileHandlerArgs) (*
 		return nil, fmt.Errorf("error getting profiles: %v", err)
 	}
 
-	trimmedBody, err := trimProfilesResponse(body)
+	if exportFormat != "" {
+		root, err := parseFlameGraph(body)
+		if err != nil {
+			return nil, err
+		}
+		return exportProfileResponse(root, exportFormat, arguments.ServiceName, startTime, endTime)
+	}
+
+	switch mode {
+	case profileModeTop:
+		root, err := parseFlameGraph(body)
+		if err != nil {
+			return nil, err
+		}
+		topBody, err := json.Marshal(profileTopFunctions(root, limit))
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling top functions: %v", err)
+		}
+		return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(topBody))), nil
+	case profileModeFolded:
+		root, err := parseFlameGraph(body)
+		if err != nil {
+			return nil, err
+		}
+		pruneFlameGraphNodesBelowDurationThreshold(root, pruneRatio)
+		return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(foldProfileStacks(root))), nil
+	}
+
+	trimmedBody, err := trimProfilesResponse(body, pruneRatio)
 	if err != nil {
 		return nil, fmt.Errorf("error trimming profiles: %v", err)
 	}
@@ -42,10 +98,28 @@ func GetProfilesHandler(ctx context.Context, arguments GetProfileHandlerArgs) (*
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(fmt.Sprintf("%s", string(trimmedBody)))), nil
 }
 
+func validateProfileViewArgs(pruneRatioArg *float64, limit int) (float64, int, error) {
+	pruneRatio := defaultProfilePruneRatio
+	if pruneRatioArg != nil {
+		pruneRatio = *pruneRatioArg
+	}
+	if pruneRatio < 0 || pruneRatio >= 1 {
+		return 0, 0, fmt.Errorf("prune_ratio must be at least 0 and less than 1")
+	}
+
+	if limit <= 0 {
+		limit = defaultProfileTopFunctions
+	}
+	if limit > maxProfileTopFunctions {
+		return 0, 0, fmt.Errorf("limit can be at most %d", maxProfileTopFunctions)
+	}
+	return pruneRatio, limit, nil
+}
+
 func getProfilesMetoroCall(ctx context.Context, request model.GetProfileRequest) ([]byte, error) {
 	requestBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling profiles request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "profiles", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "profiles", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
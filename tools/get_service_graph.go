@@ -69,5 +69,5 @@ func getServiceGraphMetoroCall(ctx context.Context, request GetServiceGraphReque
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling service graph request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "serviceGraph", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "serviceGraph", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
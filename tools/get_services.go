@@ -29,7 +29,7 @@ func getServicesMetoroCall(ctx context.Context, arguments GetServicesHandlerArgs
 		return nil, err
 	}
 
-	return utils.MakeMetoroAPIRequest("GET", path, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "GET", path, nil, utils.GetAPIRequirementsFromRequest(ctx))
 }
 
 func buildGetServicesPath(arguments GetServicesHandlerArgs) (string, error) {
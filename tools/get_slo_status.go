@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+type GetSLOStatusHandlerArgs struct {
+	SLODefinition
+}
+
+func GetSLOStatusHandler(ctx context.Context, arguments GetSLOStatusHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	slo, err := normalizeSLODefinition(arguments.SLODefinition)
+	if err != nil {
+		return nil, err
+	}
+
+	endTime := time.Now().Unix()
+	startTime := endTime - int64(slo.WindowDays)*int64((24*time.Hour).Seconds())
+	burnStartTime := endTime - int64(sloBurnLookback.Seconds())
+
+	err = checkTimeseries(ctx, sloTimeseries(slo, sloWindowBucketSize), startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	windowBuckets, err := getSLOBuckets(ctx, slo, startTime, endTime, sloWindowBucketSize)
+	if err != nil {
+		return nil, err
+	}
+	burnBuckets, err := getSLOBuckets(ctx, slo, burnStartTime, endTime, sloBurnBucketSize)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(computeSLOStatus(slo, windowBuckets, burnBuckets, startTime, endTime))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling SLO status: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
@@ -5,15 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	mcpgolang "github.com/metoro-io/mcp-golang"
 	"github.com/metoro-io/metoro-mcp-server/utils"
 )
 
+const (
+	traceSpansModeRaw       = "raw"
+	traceSpansModeAnalysis  = "analysis"
+	traceSpansModeWaterfall = "waterfall"
+	traceSpansModeMermaid   = "mermaid"
+)
+
 type GetTraceSpansHandlerArgs struct {
 	TimeConfig   utils.TimeConfig `json:"time_config" jsonschema:"required,description=The time period to get trace spans for. e.g. if you want to get spans for the last 5 minutes you would set time_period=5 and time_window=Minutes. You can also set an absolute time range by setting start_time and end_time"`
 	TraceId      string           `json:"trace_id" jsonschema:"required,description=The traceId of the trace to get the associated spans. get_traces tool will return list of traceIds which should be used for this field."`
 	Environments []string         `json:"environments" jsonschema:"description=The environments to get the spans for. If empty all environments will be included"`
+	Mode         string           `json:"mode,omitempty" jsonschema:"enum=raw,enum=analysis,enum=waterfall,enum=mermaid,description=Optional output mode. raw (default) returns the spans as returned by the API. analysis returns a compact span tree with the self time of each span the critical path through the trace the spans contributing most to its latency and the error spans with their first erroring ancestor. waterfall returns a text waterfall with a bar for each span and mermaid returns a Mermaid Gantt chart. Both are markdown code blocks that can be put in the markdown of an investigation."`
 }
 
 type GetSpansForTraceRequest struct {
@@ -29,6 +38,13 @@ type GetSpansForTraceRequest struct {
 }
 
 func GetTraceSpansHandler(ctx context.Context, arguments GetTraceSpansHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	mode := strings.ToLower(strings.TrimSpace(arguments.Mode))
+	switch mode {
+	case "", traceSpansModeRaw, traceSpansModeAnalysis, traceSpansModeWaterfall, traceSpansModeMermaid:
+	default:
+		return nil, fmt.Errorf("mode must be one of %s, %s, %s or %s", traceSpansModeRaw, traceSpansModeAnalysis, traceSpansModeWaterfall, traceSpansModeMermaid)
+	}
+
 	startTime, endTime, err := utils.CalculateTimeRange(arguments.TimeConfig)
 	if err != nil {
 		return nil, fmt.Errorf("error calculating time range: %v", err)
@@ -46,6 +62,25 @@ func GetTraceSpansHandler(ctx context.Context, arguments GetTraceSpansHandlerArg
 	if err != nil {
 		return nil, fmt.Errorf("error getting trace spans: %v", err)
 	}
+
+	if mode != "" && mode != traceSpansModeRaw {
+		spans, err := parseTraceSpans(body)
+		if err != nil {
+			return nil, err
+		}
+		analysis := buildTraceAnalysis(spans)
+
+		var rendered string
+		switch mode {
+		case traceSpansModeAnalysis:
+			rendered = analysis.render(arguments.TraceId)
+		case traceSpansModeWaterfall:
+			rendered = analysis.renderWaterfall(arguments.TraceId)
+		case traceSpansModeMermaid:
+			rendered = analysis.renderMermaidGantt(arguments.TraceId)
+		}
+		return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(rendered)), nil
+	}
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(fmt.Sprintf("%s", string(body)))), nil
 }
 
@@ -54,5 +89,5 @@ func getTraceSpansMetoroCall(ctx context.Context, request GetSpansForTraceReques
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling trace spans request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "spans", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "spans", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
@@ -15,12 +15,15 @@ type GetTracesHandlerArgs struct {
 	TimeConfig     utils.TimeConfig `json:"time_config" jsonschema:"required,description=The time period to get traces for. e.g. if you want to get traces for the last 5 minutes you would set time_period=5 and time_window=Minutes. You can also set an absoulute time range by setting start_time and end_time. Try to use a time period 1 hour or less unless its requested."`
 	Filters        []model.Filter   `json:"filters" jsonschema:"description=Filters to apply to the traces. Only the traces that match these filters will be returned. You have to get the possible filter keys from the get_attribute_keys tool and possible values of a filter key from the get_attribute_values tool. DO NOT GUESS THE FILTER KEYS OR VALUES. Multiple filter keys are ANDed together and values for a filter key are ORed together. Example: [{key: 'service.name' values: ['/k8s/prod/myservice']}]"`
 	ExcludeFilters []model.Filter   `json:"excludeFilters" jsonschema:"description=The exclude filters to exclude/eliminate the traces. Traces matching the exclude traces will not be returned. You have to get the possible exclude filter keys from the get_attribute_keys tool and possible value for the key from the get_attribute_values tool. DO NOT GUESS THE FILTER KEYS OR VALUES. Multiple keys are ORed together and values for a filter key are ANDed together. Example: [{key: 'http.status_code' values: ['200']}]"`
+	PaginationArgs
+	ResponseContinuationArgs
+	OutputFormatArgs
 }
 
 func GetTracesHandler(ctx context.Context, arguments GetTracesHandlerArgs) (*mcpgolang.ToolResponse, error) {
-	startTime, endTime, err := utils.CalculateTimeRange(arguments.TimeConfig)
+	page, err := resolvePageQuery(pageKindTraces, arguments.PaginationArgs, arguments.TimeConfig)
 	if err != nil {
-		return nil, fmt.Errorf("error calculating time range: %v", err)
+		return nil, err
 	}
 
 	// Convert Filter slice to map format for internal API
@@ -32,14 +35,14 @@ func GetTracesHandler(ctx context.Context, arguments GetTracesHandlerArgs) (*mcp
 		return nil, err
 	}
 
-	limit := 20
-
 	request := model.GetTracesRequest{
-		StartTime:      startTime,
-		EndTime:        endTime,
+		StartTime:      page.StartTime,
+		EndTime:        page.EndTime,
 		Filters:        filters,
 		ExcludeFilters: excludeFilters,
-		Limit:          &limit,
+		PrevEndTime:    page.PrevEndTime,
+		Ascending:      page.Ascending,
+		Limit:          &page.Limit,
 	}
 
 	body, err := getTracesMetoroCall(ctx, request)
@@ -52,6 +55,10 @@ func GetTracesHandler(ctx context.Context, arguments GetTracesHandlerArgs) (*mcp
 	if err != nil {
 		return nil, fmt.Errorf("error adding human readable duration: %v", err)
 	}
+	bodyWithDuration, err = addNextPageCursor(bodyWithDuration, pageKindTraces, page)
+	if err != nil {
+		return nil, err
+	}
 
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(fmt.Sprintf("%s", string(bodyWithDuration)))), nil
 }
@@ -61,7 +68,7 @@ func getTracesMetoroCall(ctx context.Context, request model.GetTracesRequest) ([
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling traces request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "traces", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "traces", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
 
 func addHumanReadableDuration(response []byte) ([]byte, error) {
@@ -76,26 +83,27 @@ func addHumanReadableDuration(response []byte) ([]byte, error) {
 		trace := &tracesResponse.Traces[i]
 		durationNs := trace.Duration
 
-		// Convert duration to human readable format
-		var humanReadable string
-		switch {
-		case durationNs < 1000: // Less than 1 microsecond
-			humanReadable = fmt.Sprintf("%d nanoseconds", durationNs)
-		case durationNs < 1000000: // Less than 1 millisecond
-			humanReadable = fmt.Sprintf("%.2f microseconds", float64(durationNs)/1000)
-		case durationNs < 1000000000: // Less than 1 second
-			humanReadable = fmt.Sprintf("%.2f milliseconds", float64(durationNs)/1000000)
-		case durationNs < 60000000000: // Less than 1 minute
-			humanReadable = fmt.Sprintf("%.2f seconds", float64(durationNs)/1000000000)
-		default: // 1 minute or more
-			minutes := durationNs / 60000000000
-			seconds := (durationNs % 60000000000) / 1000000000
-			humanReadable = fmt.Sprintf("%d minutes %.2f seconds", minutes, float64(seconds))
-		}
-
 		// Add human readable duration to span attributes
-		trace.DurationReadable = humanReadable
+		trace.DurationReadable = formatHumanReadableDuration(durationNs)
 	}
 
 	return json.Marshal(tracesResponse)
 }
+
+// formatHumanReadableDuration formats a duration in nanoseconds the way durations are shown to the agent.
+func formatHumanReadableDuration(durationNs int64) string {
+	switch {
+	case durationNs < 1000: // Less than 1 microsecond
+		return fmt.Sprintf("%d nanoseconds", durationNs)
+	case durationNs < 1000000: // Less than 1 millisecond
+		return fmt.Sprintf("%.2f microseconds", float64(durationNs)/1000)
+	case durationNs < 1000000000: // Less than 1 second
+		return fmt.Sprintf("%.2f milliseconds", float64(durationNs)/1000000)
+	case durationNs < 60000000000: // Less than 1 minute
+		return fmt.Sprintf("%.2f seconds", float64(durationNs)/1000000000)
+	default: // 1 minute or more
+		minutes := durationNs / 60000000000
+		seconds := (durationNs % 60000000000) / 1000000000
+		return fmt.Sprintf("%d minutes %.2f seconds", minutes, float64(seconds))
+	}
+}
@@ -55,5 +55,5 @@ func getTracesDistributionMetoroCall(ctx context.Context, request model.GetTrace
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling traces distribution request: %v", err)
 	}
-	return utils.MakeMetoroAPIRequest("POST", "traces/distribution", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	return utils.MakeMetoroAPIRequest(ctx, "POST", "traces/distribution", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 }
@@ -16,7 +16,7 @@ type ListAIIssueEventsHandlerArgs struct {
 
 func ListAIIssueEventsHandler(ctx context.Context, arguments ListAIIssueEventsHandlerArgs) (*mcpgolang.ToolResponse, error) {
 	endpoint := fmt.Sprintf("aiIssue/events?issueUuid=%s", arguments.IssueUUID)
-	responseBody, err := utils.MakeMetoroAPIRequest("GET", endpoint, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIRequest(ctx, "GET", endpoint, nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list AI issue events: %w", err)
 	}
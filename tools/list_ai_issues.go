@@ -23,7 +23,7 @@ func ListAIIssuesHandler(ctx context.Context, arguments ListAIIssueHandlerArgs)
 		endpoint += "?openOnly=true"
 	}
 
-	responseBody, err := utils.MakeMetoroAPIRequest("GET", endpoint, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIRequest(ctx, "GET", endpoint, nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list AI issues: %w", err)
 	}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+type ListAlertDestinationsHandlerArgs struct{}
+
+// listedAlertDestination is a destination the existing alerts notify and the number of alerts notifying it.
+type listedAlertDestination struct {
+	AlertDestination
+	Alerts int `json:"alerts"`
+}
+
+func ListAlertDestinationsHandler(ctx context.Context, arguments ListAlertDestinationsHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	alerts, err := searchAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(listAlertDestinations(alerts))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling alert destinations: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
+
+// listAlertDestinations returns the destinations of the alerts in the shape of the destinations argument, most used
+// first. There is no endpoint listing the configured destinations so destinations no alert notifies are not listed.
+func listAlertDestinations(alerts []searchedAlert) []listedAlertDestination {
+	destinations := []listedAlertDestination{}
+	index := map[string]int{}
+	for _, alert := range alerts {
+		seen := map[string]bool{}
+		for _, action := range alert.actions() {
+			destination, ok := action.destination()
+			if !ok {
+				continue
+			}
+			key, _ := json.Marshal(destination)
+			if seen[string(key)] {
+				continue
+			}
+			seen[string(key)] = true
+			if i, ok := index[string(key)]; ok {
+				destinations[i].Alerts++
+				continue
+			}
+			index[string(key)] = len(destinations)
+			destinations = append(destinations, listedAlertDestination{AlertDestination: destination, Alerts: 1})
+		}
+	}
+	sort.SliceStable(destinations, func(i, j int) bool {
+		return destinations[i].Alerts > destinations[j].Alerts
+	})
+	return destinations
+}
+
+// destination converts the action back to the destination it was created from. Whether to notify on resolve is a
+// setting of the alert rather than of the destination so it is left out.
+func (a searchedAlertAction) destination() (AlertDestination, bool) {
+	value := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return strings.TrimSpace(*value)
+	}
+	destination := AlertDestination{Type: a.kind()}
+	switch destination.Type {
+	case alertDestinationSlack:
+		destination.Channel = value(a.SlackDestination.Channel)
+	case alertDestinationPagerDuty:
+		destination.ServiceId = value(a.PagerDutyDestination.ServiceId)
+		destination.ServiceName = value(a.PagerDutyDestination.ServiceName)
+	case alertDestinationEmail:
+		destination.Emails = a.EmailDestination.Emails
+	case alertDestinationWebhook:
+		destination.WebhookUuid = value(a.WebhookDestination.Uuid)
+		destination.WebhookName = value(a.WebhookDestination.Name)
+		destination.ResolvedUuid = value(a.WebhookDestination.ResolvedUuid)
+		destination.ResolvedName = value(a.WebhookDestination.ResolvedName)
+	default:
+		return AlertDestination{}, false
+	}
+	return destination, true
+}
@@ -39,7 +39,7 @@ func ListInvestigationsHandler(ctx context.Context, arguments ListInvestigations
 	}
 
 	// Make the API request
-	responseBody, err := utils.MakeMetoroAPIRequest("POST", "investigations/list", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIRequest(ctx, "POST", "investigations/list", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list investigations: %w", err)
 	}
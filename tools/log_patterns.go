@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	logPatternWildcard = "<*>"
+
+	// Drain parameters, see "Drain: An Online Log Parsing Approach with Fixed Depth Tree" (He et al. 2017).
+	logPatternTreeDepth           = 4
+	logPatternSimilarityThreshold = 0.4
+	logPatternMaxChildren         = 100
+	logPatternMaxTokens           = 80
+)
+
+// logPatternVariableRegexes match tokens that are almost always variables, like ids and numbers. They are
+// replaced with the wildcard before clustering so lines only differing in them end up in the same template.
+var logPatternVariableRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+	regexp.MustCompile(`^\d{1,3}(\.\d{1,3}){3}(:\d+)?$`),
+	regexp.MustCompile(`^(0x)?[0-9a-fA-F]{12,}$`),
+	regexp.MustCompile(`^[-+]?\d+([.,:]\d+)*([eE][-+]?\d+)?(ns|us|µs|ms|s|m|h|b|kb|mb|gb|%)?$`),
+	regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`),
+}
+
+var logPatternTokenTrimCharacters = `"'()[]{},;`
+
+type logPatternCluster struct {
+	template []string
+	id       int
+}
+
+type logPatternNode struct {
+	children map[string]*logPatternNode
+	clusters []*logPatternCluster
+}
+
+// logPatternMiner groups log lines into templates with the Drain algorithm. Lines are routed through a fixed
+// depth tree keyed by their token count and leading tokens, then matched against the templates in the leaf by
+// the share of positions with equal tokens. Positions where lines of a template differ become wildcards.
+type logPatternMiner struct {
+	root     *logPatternNode
+	clusters []*logPatternCluster
+}
+
+func newLogPatternMiner() *logPatternMiner {
+	return &logPatternMiner{root: &logPatternNode{children: map[string]*logPatternNode{}}}
+}
+
+// add clusters the message and returns the id of its template.
+func (m *logPatternMiner) add(message string) int {
+	tokens := tokenizeLogMessage(message)
+
+	leaf := m.leafFor(tokens)
+	if cluster := bestLogPatternCluster(leaf.clusters, tokens); cluster != nil {
+		for i, token := range tokens {
+			if cluster.template[i] != token {
+				cluster.template[i] = logPatternWildcard
+			}
+		}
+		return cluster.id
+	}
+
+	cluster := &logPatternCluster{template: append([]string{}, tokens...), id: len(m.clusters)}
+	m.clusters = append(m.clusters, cluster)
+	leaf.clusters = append(leaf.clusters, cluster)
+	return cluster.id
+}
+
+func (m *logPatternMiner) template(id int) string {
+	return strings.Join(m.clusters[id].template, " ")
+}
+
+func (m *logPatternMiner) leafFor(tokens []string) *logPatternNode {
+	node := m.root.child(strconv.Itoa(len(tokens)))
+	for depth := 0; depth < logPatternTreeDepth-2 && depth < len(tokens); depth++ {
+		key := tokens[depth]
+		if strings.ContainsAny(key, "0123456789") {
+			key = logPatternWildcard
+		}
+
+		if _, ok := node.children[key]; !ok && len(node.children) >= logPatternMaxChildren {
+			key = logPatternWildcard
+		}
+		node = node.child(key)
+	}
+	return node
+}
+
+func (n *logPatternNode) child(key string) *logPatternNode {
+	child, ok := n.children[key]
+	if !ok {
+		child = &logPatternNode{children: map[string]*logPatternNode{}}
+		n.children[key] = child
+	}
+	return child
+}
+
+func bestLogPatternCluster(clusters []*logPatternCluster, tokens []string) *logPatternCluster {
+	var best *logPatternCluster
+	bestSimilarity := -1.0
+	bestWildcards := -1
+	for _, cluster := range clusters {
+		equal, wildcards := 0, 0
+		for i, token := range cluster.template {
+			if token == logPatternWildcard {
+				wildcards++
+				continue
+			}
+			if token == tokens[i] {
+				equal++
+			}
+		}
+
+		similarity := 1.0
+		if len(tokens) > 0 {
+			similarity = float64(equal) / float64(len(tokens))
+		}
+		if similarity > bestSimilarity || (similarity == bestSimilarity && wildcards > bestWildcards) {
+			best, bestSimilarity, bestWildcards = cluster, similarity, wildcards
+		}
+	}
+
+	if best == nil || bestSimilarity < logPatternSimilarityThreshold {
+		return nil
+	}
+	return best
+}
+
+// tokenizeLogMessage splits the first line of the message on whitespace and masks variable looking tokens. Only
+// the first line is used so stack traces cluster by their error message.
+func tokenizeLogMessage(message string) []string {
+	firstLine := strings.TrimSpace(message)
+	if index := strings.IndexAny(firstLine, "\r\n"); index >= 0 {
+		firstLine = strings.TrimSpace(firstLine[:index])
+	}
+
+	fields := strings.Fields(firstLine)
+	if len(fields) > logPatternMaxTokens {
+		fields = fields[:logPatternMaxTokens]
+	}
+
+	tokens := make([]string, len(fields))
+	for i, field := range fields {
+		tokens[i] = maskLogToken(field)
+	}
+	return tokens
+}
+
+func maskLogToken(token string) string {
+	if isLogVariable(token) {
+		return logPatternWildcard
+	}
+
+	// Keep keys of key=value pairs so "user=123" and "user=456" become "user=<*>".
+	if index := strings.IndexAny(token, "=:"); index > 0 && index < len(token)-1 {
+		value := token[index+1:]
+		if isLogVariable(value) {
+			return token[:index+1] + logPatternWildcard
+		}
+	}
+	return token
+}
+
+func isLogVariable(token string) bool {
+	trimmed := strings.Trim(token, logPatternTokenTrimCharacters)
+	if trimmed == "" {
+		return false
+	}
+	for _, variableRegex := range logPatternVariableRegexes {
+		if variableRegex.MatchString(trimmed) {
+			return true
+		}
+	}
+	return false
+}
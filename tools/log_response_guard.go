@@ -22,8 +22,6 @@ var strictLogAttributeKeys = map[string]struct{}{
 	"error":        {},
 }
 
-var LogsToolResponseGuard = NewToolResponseGuard(trimLargeLogFieldsInToolResponse, ToolResponseGuardOptions{})
-
 func trimLargeLogFieldsInToolResponse(_ string, response *mcpgolang.ToolResponse) (*mcpgolang.ToolResponse, error) {
 	for _, content := range response.Content {
 		if content == nil || content.Type != mcpgolang.ContentTypeText || content.TextContent == nil {
@@ -0,0 +1,361 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+const (
+	outputFormatJSON     = "json"
+	outputFormatCSV      = "csv"
+	outputFormatTSV      = "tsv"
+	outputFormatMarkdown = "markdown"
+	outputFormatCompact  = "compact"
+
+	// maxFlattenedOutputColumns stops nested objects from being spread over an unreadable number of columns. Past
+	// it nested objects are kept as JSON in a single cell.
+	maxFlattenedOutputColumns = 64
+)
+
+// OutputFormatArgs is embedded in the arguments of list style tools to let the agent ask for the rows as a table
+// instead of JSON with the keys repeated on every row.
+type OutputFormatArgs struct {
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"enum=json,enum=csv,enum=tsv,enum=markdown,enum=compact,description=Optional format of the returned rows. json (default) returns the raw response. csv/tsv/markdown return the rows as a table with one header line. compact returns JSON with the column names once and each row as an array of values. The table formats fit several times more rows into a response."`
+}
+
+func (a OutputFormatArgs) outputFormat() string {
+	return a.OutputFormat
+}
+
+type outputFormatArgs interface {
+	outputFormat() string
+}
+
+type compactTable struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+}
+
+func normalizeOutputFormat(format string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(format))
+	switch normalized {
+	case "":
+		return outputFormatJSON, nil
+	case outputFormatJSON, outputFormatCSV, outputFormatTSV, outputFormatMarkdown, outputFormatCompact:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("output_format must be one of json, csv, tsv, markdown or compact")
+	}
+}
+
+// outputFormatModifier formats the response after the modifier of the tool has run on the JSON. It is the last
+// modifier of the default guard, so the token budget is checked against what the agent actually receives.
+func outputFormatModifier(modifier ToolResponseModifier, format string) ToolResponseModifier {
+	if format == outputFormatJSON {
+		return modifier
+	}
+
+	return func(toolName string, response *mcpgolang.ToolResponse) (*mcpgolang.ToolResponse, error) {
+		if modifier != nil {
+			var err error
+			response, err = modifier(toolName, response)
+			if err != nil {
+				return nil, err
+			}
+			if response == nil {
+				return nil, fmt.Errorf("tool response modifier returned nil response")
+			}
+		}
+		return formatToolResponse(response, format)
+	}
+}
+
+// withOutputFormat formats the response once the guard accepted it. It is used for tools with their own guard, which
+// expects the JSON of the handler.
+func withOutputFormat(guard ToolResponseGuard, format string) ToolResponseGuard {
+	if format == outputFormatJSON {
+		return guard
+	}
+
+	return func(toolName string, response *mcpgolang.ToolResponse) (*mcpgolang.ToolResponse, error) {
+		guardedResponse, err := guard(toolName, response)
+		if err != nil || guardedResponse == nil {
+			return guardedResponse, err
+		}
+		return formatToolResponse(guardedResponse, format)
+	}
+}
+
+// formatToolResponse rewrites every text content holding a JSON list of objects into the requested format. Content
+// without such a list, like truncation notes, is left as it is.
+func formatToolResponse(response *mcpgolang.ToolResponse, format string) (*mcpgolang.ToolResponse, error) {
+	content := make([]*mcpgolang.Content, 0, len(response.Content))
+	for _, item := range response.Content {
+		if item == nil || item.Type != mcpgolang.ContentTypeText || item.TextContent == nil {
+			content = append(content, item)
+			continue
+		}
+
+		formatted, err := formatTextContent(item.TextContent.Text, format)
+		if err != nil {
+			return nil, err
+		}
+		if formatted == nil {
+			content = append(content, item)
+			continue
+		}
+		for _, text := range formatted {
+			content = append(content, mcpgolang.NewTextContent(text))
+		}
+	}
+
+	return mcpgolang.NewToolResponse(content...), nil
+}
+
+func formatTextContent(text string, format string) ([]string, error) {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, nil
+	}
+
+	path, rows, ok := findTableRows(document)
+	if !ok {
+		return nil, nil
+	}
+	columns, cells := tabulateRows(rows)
+
+	if format == outputFormatCompact {
+		compacted, err := marshalJSONWithoutHTMLEscape(setJSONValue(document, path, compactTable{Columns: columns, Rows: cells}))
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling compact response: %v", err)
+		}
+		return []string{compacted}, nil
+	}
+
+	table, err := renderTable(columns, cells, format)
+	if err != nil {
+		return nil, err
+	}
+	formatted := []string{table}
+
+	// Anything next to the rows, like page tokens, is kept as JSON.
+	remainder := removeJSONValue(document, path)
+	if remainderObject, ok := remainder.(map[string]any); ok && len(remainderObject) > 0 {
+		serialized, err := marshalJSONWithoutHTMLEscape(remainderObject)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling response metadata: %v", err)
+		}
+		formatted = append(formatted, serialized)
+	}
+	return formatted, nil
+}
+
+// findTableRows returns the largest array in the document whose items are all objects.
+func findTableRows(document any) ([]string, []map[string]any, bool) {
+	if items, ok := document.([]any); ok {
+		if rows, ok := objectRows(items); ok {
+			return nil, rows, true
+		}
+	}
+
+	for _, candidate := range findTruncatableArrays(document) {
+		if rows, ok := objectRows(candidate.items); ok {
+			return candidate.path, rows, true
+		}
+	}
+	return nil, nil, false
+}
+
+func objectRows(items []any) ([]map[string]any, bool) {
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	rows := make([]map[string]any, len(items))
+	for i, item := range items {
+		row, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		rows[i] = row
+	}
+	return rows, true
+}
+
+// tabulateRows turns the rows into cells under a sorted union of their keys. Nested objects are flattened into
+// dotted column names unless that creates too many columns.
+func tabulateRows(rows []map[string]any) ([]string, [][]any) {
+	flattened := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		flattened[i] = map[string]any{}
+		flattenRow(flattened[i], "", row)
+	}
+	columns := collectColumns(flattened)
+
+	if len(columns) > maxFlattenedOutputColumns {
+		for i, row := range rows {
+			flattened[i] = row
+		}
+		columns = collectColumns(flattened)
+	}
+
+	cells := make([][]any, len(rows))
+	for i, row := range flattened {
+		cells[i] = make([]any, len(columns))
+		for j, column := range columns {
+			cells[i][j] = row[column]
+		}
+	}
+	return columns, cells
+}
+
+func flattenRow(target map[string]any, prefix string, value map[string]any) {
+	for key, child := range value {
+		column := key
+		if prefix != "" {
+			column = prefix + "." + key
+		}
+
+		if nested, ok := child.(map[string]any); ok && len(nested) > 0 {
+			flattenRow(target, column, nested)
+			continue
+		}
+		target[column] = child
+	}
+}
+
+func collectColumns(rows []map[string]any) []string {
+	seen := map[string]struct{}{}
+	var columns []string
+	for _, row := range rows {
+		for key := range row {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			columns = append(columns, key)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func renderTable(columns []string, cells [][]any, format string) (string, error) {
+	if format == outputFormatMarkdown {
+		return renderMarkdownTable(columns, cells), nil
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if format == outputFormatTSV {
+		writer.Comma = '\t'
+	}
+
+	if err := writer.Write(columns); err != nil {
+		return "", fmt.Errorf("error writing table header: %v", err)
+	}
+	record := make([]string, len(columns))
+	for _, row := range cells {
+		for i, value := range row {
+			record[i] = formatTableCell(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return "", fmt.Errorf("error writing table row: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("error writing table: %v", err)
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+func renderMarkdownTable(columns []string, cells [][]any) string {
+	escape := strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+
+	var builder strings.Builder
+	builder.WriteString("|")
+	for _, column := range columns {
+		builder.WriteString(" " + escape.Replace(column) + " |")
+	}
+	builder.WriteString("\n|")
+	for range columns {
+		builder.WriteString(" --- |")
+	}
+	for _, row := range cells {
+		builder.WriteString("\n|")
+		for _, value := range row {
+			builder.WriteString(" " + escape.Replace(formatTableCell(value)) + " |")
+		}
+	}
+	return builder.String()
+}
+
+func formatTableCell(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case json.Number:
+		return typed.String()
+	case bool:
+		if typed {
+			return "true"
+		}
+		return "false"
+	default:
+		serialized, err := marshalJSONWithoutHTMLEscape(typed)
+		if err != nil {
+			return fmt.Sprintf("%v", typed)
+		}
+		return serialized
+	}
+}
+
+// removeJSONValue returns a copy of document without the object key at path. Array elements are not removed, only
+// descended into.
+func removeJSONValue(document any, path []string) any {
+	if len(path) == 0 {
+		return nil
+	}
+
+	switch typed := document.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(typed))
+		for key, child := range typed {
+			copied[key] = child
+		}
+		if len(path) == 1 {
+			delete(copied, path[0])
+		} else {
+			copied[path[0]] = removeJSONValue(typed[path[0]], path[1:])
+		}
+		return copied
+	case []any:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(typed) || len(path) == 1 {
+			return document
+		}
+		copied := append([]any{}, typed...)
+		copied[index] = removeJSONValue(typed[index], path[1:])
+		return copied
+	default:
+		return document
+	}
+}
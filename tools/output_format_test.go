@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+const outputFormatTestBody = `{"logs":[{"time":1,"message":"a,b","attrs":{"pod":"p1"}},{"time":2,"message":"c|d","attrs":{"pod":"p2","node":"n1"}}],"nextPageToken":"abc"}`
+
+func TestFormatToolResponseCSV(t *testing.T) {
+	response := mcpgolang.NewToolResponse(mcpgolang.NewTextContent(outputFormatTestBody))
+
+	formatted, err := formatToolResponse(response, outputFormatCSV)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(formatted.Content) != 2 {
+		t.Fatalf("expected table and metadata content, got %d", len(formatted.Content))
+	}
+
+	expected := "attrs.node,attrs.pod,message,time\n,p1,\"a,b\",1\nn1,p2,c|d,2"
+	if formatted.Content[0].TextContent.Text != expected {
+		t.Fatalf("unexpected csv:\n%s", formatted.Content[0].TextContent.Text)
+	}
+	if formatted.Content[1].TextContent.Text != `{"nextPageToken":"abc"}` {
+		t.Fatalf("unexpected metadata %s", formatted.Content[1].TextContent.Text)
+	}
+}
+
+func TestFormatToolResponseMarkdownEscapesPipes(t *testing.T) {
+	response := mcpgolang.NewToolResponse(mcpgolang.NewTextContent(outputFormatTestBody))
+
+	formatted, err := formatToolResponse(response, outputFormatMarkdown)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	lines := strings.Split(formatted.Content[0].TextContent.Text, "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, separator and 2 rows, got %q", lines)
+	}
+	if lines[3] != `| n1 | p2 | c\|d | 2 |` {
+		t.Fatalf("unexpected markdown row %q", lines[3])
+	}
+}
+
+func TestFormatToolResponseCompact(t *testing.T) {
+	response := mcpgolang.NewToolResponse(mcpgolang.NewTextContent(outputFormatTestBody))
+
+	formatted, err := formatToolResponse(response, outputFormatCompact)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var body struct {
+		Logs          compactTable `json:"logs"`
+		NextPageToken string       `json:"nextPageToken"`
+	}
+	if err := json.Unmarshal([]byte(formatted.Content[0].TextContent.Text), &body); err != nil {
+		t.Fatalf("failed to decode compact response: %v", err)
+	}
+	if len(body.Logs.Columns) != 4 || len(body.Logs.Rows) != 2 || body.NextPageToken != "abc" {
+		t.Fatalf("unexpected compact response %+v", body)
+	}
+}
+
+func TestFormatToolResponseLeavesNonTabularContent(t *testing.T) {
+	response := mcpgolang.NewToolResponse(
+		mcpgolang.NewTextContent(`{"truncated":true,"remainingItems":3}`),
+		mcpgolang.NewTextContent("plain text"),
+	)
+
+	formatted, err := formatToolResponse(response, outputFormatTSV)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if formatted.Content[0].TextContent.Text != `{"truncated":true,"remainingItems":3}` || formatted.Content[1].TextContent.Text != "plain text" {
+		t.Fatalf("expected content to be left untouched")
+	}
+}
+
+func TestWrappedHandlerRejectsUnknownOutputFormat(t *testing.T) {
+	type testArgs struct {
+		OutputFormatArgs
+	}
+
+	called := false
+	tool := MetoroTools{
+		Name: "test_tool",
+		Handler: func(ctx context.Context, arguments testArgs) (*mcpgolang.ToolResponse, error) {
+			called = true
+			return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(outputFormatTestBody)), nil
+		},
+	}
+	wrapped := tool.WrappedHandler().(func(context.Context, testArgs) (*mcpgolang.ToolResponse, error))
+
+	if _, err := wrapped(context.Background(), testArgs{OutputFormatArgs{OutputFormat: "xml"}}); err == nil {
+		t.Fatalf("expected unknown output format error")
+	}
+	if called {
+		t.Fatalf("expected handler not to be called for an unknown output format")
+	}
+
+	response, err := wrapped(context.Background(), testArgs{OutputFormatArgs{OutputFormat: "TSV"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(response.Content[0].TextContent.Text, "attrs.node\tattrs.pod\tmessage\ttime") {
+		t.Fatalf("expected tsv header, got %q", response.Content[0].TextContent.Text)
+	}
+}
+
+// decodeCompactLogs returns the message and stack trace cells of the compact logs table.
+func decodeCompactLogs(t *testing.T, response *mcpgolang.ToolResponse) ([]string, []string) {
+	t.Helper()
+
+	var body struct {
+		Logs compactTable `json:"logs"`
+	}
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &body); err != nil {
+		t.Fatalf("failed to decode compact response: %v", err)
+	}
+	var messages, stackTraces []string
+	for _, row := range body.Logs.Rows {
+		for i, column := range body.Logs.Columns {
+			value, _ := row[i].(string)
+			switch column {
+			case "message":
+				messages = append(messages, value)
+			case "logAttributes.stacktrace":
+				stackTraces = append(stackTraces, value)
+			}
+		}
+	}
+	return messages, stackTraces
+}
+
+func TestWrappedHandlerTrimsLogsBeforeFormatting(t *testing.T) {
+	type testArgs struct {
+		OutputFormatArgs
+	}
+
+	body, _ := json.Marshal(map[string]any{"logs": []map[string]any{
+		{"time": 1, "message": strings.Repeat("m", 5000), "logAttributes": map[string]string{"stacktrace": strings.Repeat("s", 1000)}},
+		{"time": 2, "message": "short", "logAttributes": map[string]string{"stacktrace": "short"}},
+	}})
+	tool := MetoroTools{
+		Name: "test_tool",
+		Handler: func(ctx context.Context, arguments testArgs) (*mcpgolang.ToolResponse, error) {
+			return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+		},
+		ResponseModifier: trimLargeLogFieldsInToolResponse,
+	}
+	wrapped := tool.WrappedHandler().(func(context.Context, testArgs) (*mcpgolang.ToolResponse, error))
+
+	response, err := wrapped(context.Background(), testArgs{OutputFormatArgs{OutputFormat: outputFormatCompact}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	messages, stackTraces := decodeCompactLogs(t, response)
+	if len(messages) != 2 || len([]rune(messages[0])) != logMessageLengthLimit || !strings.HasSuffix(messages[0], truncatedValueSuffix) {
+		t.Fatalf("expected the message to be trimmed, got %d runes", len([]rune(messages[0])))
+	}
+	if len(stackTraces) != 2 || len([]rune(stackTraces[0])) != stackTraceValueLengthLimit {
+		t.Fatalf("expected the stack trace to be trimmed, got %v", stackTraces)
+	}
+
+	response, err = wrapped(context.Background(), testArgs{OutputFormatArgs{OutputFormat: outputFormatCSV}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.Contains(response.Content[0].TextContent.Text, strings.Repeat("m", logMessageLengthLimit)) {
+		t.Fatalf("expected the message to be trimmed in the csv")
+	}
+}
+
+func TestGetLogsCompactOutputTrimsLongMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":[]}`))
+		case "/api/v1/logs":
+			body, _ := json.Marshal(map[string]any{"logs": []map[string]any{
+				{"time": 1771495200000, "message": strings.Repeat("m", 5000)},
+				{"time": 1771495201000, "message": "short"},
+			}})
+			_, _ = w.Write(body)
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	var getLogs func(context.Context, GetLogsHandlerArgs) (*mcpgolang.ToolResponse, error)
+	for _, tool := range MetoroToolsList {
+		if tool.Name == "get_logs" {
+			getLogs = tool.WrappedHandler().(func(context.Context, GetLogsHandlerArgs) (*mcpgolang.ToolResponse, error))
+		}
+	}
+	response, err := getLogs(context.Background(), GetLogsHandlerArgs{
+		TimeConfig:       absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T11:00:00Z"),
+		OutputFormatArgs: OutputFormatArgs{OutputFormat: outputFormatCompact},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	messages, _ := decodeCompactLogs(t, response)
+	if len(messages) != 2 || len([]rune(messages[0])) != logMessageLengthLimit {
+		t.Fatalf("expected the message to be trimmed, got %v", messages)
+	}
+}
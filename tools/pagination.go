@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+
+	pageOrderDescending = "descending"
+	pageOrderAscending  = "ascending"
+
+	pageKindLogs   = "logs"
+	pageKindTraces = "traces"
+
+	// nextPageCursorField is left out of truncated responses, the rest of the page has to be fetched with
+	// continuation_cursor first so no items are skipped.
+	nextPageCursorField = "next_cursor"
+)
+
+// PaginationArgs is embedded in the arguments of tools that can walk through a time window page by page.
+type PaginationArgs struct {
+	Limit  int    `json:"limit,omitempty" jsonschema:"description=Optional number of items to return. Defaults to 20 and can be at most 100."`
+	Order  string `json:"order,omitempty" jsonschema:"enum=descending,enum=ascending,description=Optional order of the items by time. descending (default) returns the newest items first and ascending returns the oldest items first."`
+	Cursor string `json:"cursor,omitempty" jsonschema:"description=Optional next_cursor from a previous response of this tool to get the next page. The cursor keeps the time range and order of the first page so the other arguments should stay the same. Truncated responses have no next_cursor, get the rest of the page with continuation_cursor first and its last response has the next_cursor."`
+}
+
+// pageCursor is the decoded form of next_cursor. It pins the absolute time range of the first page so relative
+// time configs don't shift between pages.
+type pageCursor struct {
+	Kind      string `json:"kind"`
+	StartTime int64  `json:"startTime"`
+	EndTime   int64  `json:"endTime"`
+	Ascending bool   `json:"ascending"`
+	// PrevEndTime is in nanoseconds, as the API expects it.
+	PrevEndTime int64 `json:"prevEndTime"`
+}
+
+type pageQuery struct {
+	StartTime   int64
+	EndTime     int64
+	Ascending   bool
+	PrevEndTime *int64
+	Limit       int
+}
+
+func resolvePageQuery(kind string, arguments PaginationArgs, timeConfig utils.TimeConfig) (pageQuery, error) {
+	limit := arguments.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		return pageQuery{}, fmt.Errorf("limit can be at most %d, use cursor to get more items", maxPageLimit)
+	}
+
+	if strings.TrimSpace(arguments.Cursor) != "" {
+		cursor, err := decodePageCursor(arguments.Cursor)
+		if err != nil {
+			return pageQuery{}, err
+		}
+		if cursor.Kind != kind {
+			return pageQuery{}, fmt.Errorf("cursor is for %s, it can't be used to get %s", cursor.Kind, kind)
+		}
+		if order := strings.ToLower(strings.TrimSpace(arguments.Order)); order != "" && (order == pageOrderAscending) != cursor.Ascending {
+			return pageQuery{}, fmt.Errorf("cursor was issued for a different order, use the same order as the first page or leave out the cursor")
+		}
+		prevEndTime := cursor.PrevEndTime
+		return pageQuery{
+			StartTime:   cursor.StartTime,
+			EndTime:     cursor.EndTime,
+			Ascending:   cursor.Ascending,
+			PrevEndTime: &prevEndTime,
+			Limit:       limit,
+		}, nil
+	}
+
+	var ascending bool
+	switch strings.ToLower(strings.TrimSpace(arguments.Order)) {
+	case "", pageOrderDescending:
+		ascending = false
+	case pageOrderAscending:
+		ascending = true
+	default:
+		return pageQuery{}, fmt.Errorf("order must be ascending or descending")
+	}
+
+	startTime, endTime, err := utils.CalculateTimeRange(timeConfig)
+	if err != nil {
+		return pageQuery{}, fmt.Errorf("error calculating time range: %v", err)
+	}
+
+	return pageQuery{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Ascending: ascending,
+		Limit:     limit,
+	}, nil
+}
+
+// pagePrevEndTime converts the time of the last log or trace of a page, which the Metoro API returns in milliseconds,
+// to the prevEndTime of the request for the next page, which it expects in nanoseconds.
+func pagePrevEndTime(itemTime int64) int64 {
+	return time.UnixMilli(itemTime).UnixNano()
+}
+
+// addNextPageCursor adds next_cursor to the response when a full page was returned, meaning more items may be
+// left in the time range. The time of the last item is passed back to the API as prevEndTime, which is how the
+// Metoro API pages through logs and traces.
+func addNextPageCursor(response []byte, kind string, query pageQuery) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(response, &fields); err != nil {
+		return nil, fmt.Errorf("error unmarshaling %s response: %v", kind, err)
+	}
+
+	var items []struct {
+		Time int64 `json:"time"`
+	}
+	if raw, ok := fields[kind]; ok {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("error unmarshaling %s: %v", kind, err)
+		}
+	}
+	if len(items) < query.Limit || len(items) == 0 {
+		return response, nil
+	}
+
+	cursor, err := json.Marshal(pageCursor{
+		Kind:        kind,
+		StartTime:   query.StartTime,
+		EndTime:     query.EndTime,
+		Ascending:   query.Ascending,
+		PrevEndTime: pagePrevEndTime(items[len(items)-1].Time),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling cursor: %v", err)
+	}
+	fields[nextPageCursorField], err = json.Marshal(base64.RawURLEncoding.EncodeToString(cursor))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling cursor: %v", err)
+	}
+
+	return json.Marshal(fields)
+}
+
+func decodePageCursor(raw string) (*pageCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor, pass the next_cursor value from the previous response unchanged")
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil || cursor.Kind == "" {
+		return nil, fmt.Errorf("invalid cursor, pass the next_cursor value from the previous response unchanged")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+func TestResolvePageQueryDefaults(t *testing.T) {
+	query, err := resolvePageQuery(pageKindLogs, PaginationArgs{}, absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z"))
+	if err != nil {
+		t.Fatalf("expected no error got %v", err)
+	}
+	if query.Limit != defaultPageLimit || query.Ascending || query.PrevEndTime != nil {
+		t.Fatalf("unexpected default query %+v", query)
+	}
+	if query.EndTime-query.StartTime != 300 {
+		t.Fatalf("expected a 5 minute window, got %d seconds", query.EndTime-query.StartTime)
+	}
+}
+
+func TestResolvePageQueryValidatesArguments(t *testing.T) {
+	timeConfig := absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z")
+
+	if _, err := resolvePageQuery(pageKindLogs, PaginationArgs{Limit: maxPageLimit + 1}, timeConfig); err == nil {
+		t.Fatalf("expected error for a limit above the cap")
+	}
+	if _, err := resolvePageQuery(pageKindLogs, PaginationArgs{Order: "sideways"}, timeConfig); err == nil {
+		t.Fatalf("expected error for an unknown order")
+	}
+	if _, err := resolvePageQuery(pageKindLogs, PaginationArgs{Cursor: "???"}, timeConfig); err == nil {
+		t.Fatalf("expected error for an invalid cursor")
+	}
+}
+
+func TestNextPageCursorRoundTrip(t *testing.T) {
+	query, err := resolvePageQuery(pageKindTraces, PaginationArgs{Limit: 2, Order: "ascending"}, absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z"))
+	if err != nil {
+		t.Fatalf("expected no error got %v", err)
+	}
+
+	body, err := addNextPageCursor([]byte(`{"traces":[{"time":10},{"time":20}]}`), pageKindTraces, query)
+	if err != nil {
+		t.Fatalf("expected no error got %v", err)
+	}
+	var response struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.NextCursor == "" {
+		t.Fatalf("expected next_cursor for a full page, got %s", body)
+	}
+
+	// The cursor keeps the original window and order even if the arguments change.
+	next, err := resolvePageQuery(pageKindTraces, PaginationArgs{Limit: 2, Cursor: response.NextCursor}, absoluteTimeConfig("2026-02-20T10:00:00Z", "2026-02-20T11:00:00Z"))
+	if err != nil {
+		t.Fatalf("expected no error got %v", err)
+	}
+	if next.StartTime != query.StartTime || next.EndTime != query.EndTime || !next.Ascending {
+		t.Fatalf("expected cursor to keep the first page query, got %+v", next)
+	}
+	if next.PrevEndTime == nil || *next.PrevEndTime != 20_000_000 {
+		t.Fatalf("expected prevEndTime of the last item in nanoseconds, got %v", next.PrevEndTime)
+	}
+
+	if _, err := resolvePageQuery(pageKindLogs, PaginationArgs{Cursor: response.NextCursor}, absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z")); err == nil {
+		t.Fatalf("expected a traces cursor to be rejected for logs")
+	}
+	if _, err := resolvePageQuery(pageKindTraces, PaginationArgs{Cursor: response.NextCursor, Order: "descending"}, absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z")); err == nil {
+		t.Fatalf("expected a cursor to be rejected for a different order")
+	}
+	if _, err := resolvePageQuery(pageKindTraces, PaginationArgs{Cursor: response.NextCursor, Order: "Ascending"}, absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z")); err != nil {
+		t.Fatalf("expected the cursor to be accepted with the same order, got %v", err)
+	}
+}
+
+func TestAddNextPageCursorSkipsPartialPage(t *testing.T) {
+	body := []byte(`{"logs":[{"time":10}]}`)
+	paged, err := addNextPageCursor(body, pageKindLogs, pageQuery{Limit: 20})
+	if err != nil {
+		t.Fatalf("expected no error got %v", err)
+	}
+	if string(paged) != string(body) {
+		t.Fatalf("expected partial page to be returned unchanged, got %s", paged)
+	}
+}
+
+func TestGetLogsNextPageSendsPrevEndTimeInNanoseconds(t *testing.T) {
+	var requests []model.GetLogsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":[]}`))
+		case "/api/v1/logs":
+			body, _ := io.ReadAll(r.Body)
+			var request model.GetLogsRequest
+			if err := json.Unmarshal(body, &request); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			requests = append(requests, request)
+			_, _ = w.Write([]byte(`{"logs":[{"time":1771495260000,"message":"a"},{"time":1771495200000,"message":"b"}]}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	arguments := GetLogsHandlerArgs{
+		TimeConfig:     absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T11:00:00Z"),
+		PaginationArgs: PaginationArgs{Limit: 2},
+	}
+	response, err := GetLogsHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var page struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &page); err != nil || page.NextCursor == "" {
+		t.Fatalf("expected next_cursor, got %s", response.Content[0].TextContent.Text)
+	}
+
+	arguments.Cursor = page.NextCursor
+	if _, err := GetLogsHandler(context.Background(), arguments); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if requests[0].PrevEndTime != nil || requests[1].PrevEndTime == nil || *requests[1].PrevEndTime != 1771495200000000000 {
+		t.Fatalf("expected the time of the last log in nanoseconds as prevEndTime, got %+v", requests[1].PrevEndTime)
+	}
+}
+
+func TestTruncatedPageLeavesOutNextCursor(t *testing.T) {
+	type testArgs struct {
+		ResponseContinuationArgs
+	}
+
+	handler := func(ctx context.Context, arguments testArgs) (*mcpgolang.ToolResponse, error) {
+		logs := make([]map[string]any, 20)
+		for i := range logs {
+			logs[i] = map[string]any{"time": 1771495200000 - int64(i)*1000, "message": strings.Repeat("x", 200)}
+		}
+		body, _ := json.Marshal(map[string]any{"logs": logs})
+		paged, err := addNextPageCursor(body, pageKindLogs, pageQuery{Limit: 20})
+		if err != nil {
+			return nil, err
+		}
+		return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(paged))), nil
+	}
+	tool := MetoroTools{
+		Name:                      "test_tool",
+		Handler:                   handler,
+		ResponseGuard:             NewToolResponseGuard(nil, ToolResponseGuardOptions{MaxTokens: 1000, TokenEstimator: RuneTokenEstimator{}}),
+		TruncateOversizedResponse: true,
+	}
+	wrapped := tool.WrappedHandler().(func(context.Context, testArgs) (*mcpgolang.ToolResponse, error))
+
+	var arguments testArgs
+	for page := 0; page < 20; page++ {
+		response, err := wrapped(context.Background(), arguments)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var body struct {
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Content) == 1 {
+			if body.NextCursor == "" {
+				t.Fatalf("expected next_cursor with the last items of the page")
+			}
+			if page == 0 {
+				t.Fatalf("expected the first response to be truncated")
+			}
+			return
+		}
+		if body.NextCursor != "" {
+			t.Fatalf("expected no next_cursor in a truncated response")
+		}
+		var note responseTruncationNote
+		if err := json.Unmarshal([]byte(response.Content[1].TextContent.Text), &note); err != nil {
+			t.Fatalf("failed to decode truncation note: %v", err)
+		}
+		arguments.ContinuationCursor = note.ContinuationCursor
+	}
+	t.Fatalf("expected the page to be finished with continuation_cursor")
+}
@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	profileExportFormatPprof      = "pprof"
+	profileExportFormatSpeedscope = "speedscope"
+
+	pprofMimeType      = "application/octet-stream"
+	speedscopeMimeType = "application/json"
+
+	// The durations of the flame graph returned by the profiles endpoint are cpu time in nanoseconds.
+	profileSampleType = "cpu"
+	profileSampleUnit = "nanoseconds"
+)
+
+// Field numbers of profile.proto, see https://github.com/google/pprof/blob/main/proto/profile.proto.
+const (
+	pprofProfileSampleType    protowire.Number = 1
+	pprofProfileSample        protowire.Number = 2
+	pprofProfileLocation      protowire.Number = 4
+	pprofProfileFunction      protowire.Number = 5
+	pprofProfileStringTable   protowire.Number = 6
+	pprofProfileTimeNanos     protowire.Number = 9
+	pprofProfileDurationNanos protowire.Number = 10
+	pprofProfilePeriodType    protowire.Number = 11
+	pprofProfilePeriod        protowire.Number = 12
+
+	pprofValueTypeType protowire.Number = 1
+	pprofValueTypeUnit protowire.Number = 2
+
+	pprofSampleLocationId protowire.Number = 1
+	pprofSampleValue      protowire.Number = 2
+
+	pprofLocationId   protowire.Number = 1
+	pprofLocationLine protowire.Number = 4
+
+	pprofLineFunctionId protowire.Number = 1
+
+	pprofFunctionId         protowire.Number = 1
+	pprofFunctionName       protowire.Number = 2
+	pprofFunctionSystemName protowire.Number = 3
+)
+
+// exportProfileResponse returns the profile file as an embedded resource blob, so clients can save it without the
+// agent reading it, next to a short description of it.
+func exportProfileResponse(root *flameGraphNode, exportFormat string, serviceName string, startTime int64, endTime int64) (*mcpgolang.ToolResponse, error) {
+	var (
+		file        []byte
+		err         error
+		mimeType    string
+		extension   string
+		description string
+	)
+	switch exportFormat {
+	case profileExportFormatPprof:
+		file, err = exportPprofProfile(root, startTime, endTime)
+		mimeType, extension, description = pprofMimeType, "pb.gz", "a gzipped pprof profile.proto, open it with go tool pprof"
+	default:
+		file, err = exportSpeedscopeProfile(root, fmt.Sprintf("%s cpu", serviceName))
+		mimeType, extension, description = speedscopeMimeType, "speedscope.json", "a speedscope profile, open it at https://www.speedscope.app"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("metoro://profiles/%s/%d-%d.%s", url.PathEscape(serviceName), startTime, endTime, extension)
+	summary := fmt.Sprintf("Exported the cpu profile of %s from %s to %s as %s. The file (%d bytes) is attached as the embedded resource %s.",
+		serviceName, time.Unix(startTime, 0).UTC().Format(time.RFC3339), time.Unix(endTime, 0).UTC().Format(time.RFC3339), description, len(file), uri)
+	return mcpgolang.NewToolResponse(
+		mcpgolang.NewTextContent(summary),
+		mcpgolang.NewBlobResourceContent(uri, base64.StdEncoding.EncodeToString(file), mimeType),
+	), nil
+}
+
+// pprofStringTable interns the strings of a profile. The first string of the table has to be empty.
+type pprofStringTable struct {
+	strings []string
+	indexes map[string]int
+}
+
+func newPprofStringTable() *pprofStringTable {
+	return &pprofStringTable{strings: []string{""}, indexes: map[string]int{"": 0}}
+}
+
+func (t *pprofStringTable) index(value string) uint64 {
+	if index, ok := t.indexes[value]; ok {
+		return uint64(index)
+	}
+	t.indexes[value] = len(t.strings)
+	t.strings = append(t.strings, value)
+	return uint64(len(t.strings) - 1)
+}
+
+// exportPprofProfile converts the flame graph to a gzipped pprof profile.proto that can be opened with go tool pprof.
+// Every function gets a single location so samples are the stacks of function names of the flame graph.
+func exportPprofProfile(root *flameGraphNode, startTime int64, endTime int64) ([]byte, error) {
+	stringTable := newPprofStringTable()
+	functionIds := map[string]uint64{}
+	var functions, locations, samples []byte
+
+	walkFlameGraphStacks(root, func(stack []*flameGraphNode, self int64) {
+		locationIds := make([]byte, 0, len(stack))
+		// Locations of a sample start with the innermost call.
+		for i := len(stack) - 1; i >= 0; i-- {
+			id, ok := functionIds[stack[i].Name]
+			if !ok {
+				id = uint64(len(functionIds) + 1)
+				functionIds[stack[i].Name] = id
+
+				var function []byte
+				function = protowire.AppendTag(function, pprofFunctionId, protowire.VarintType)
+				function = protowire.AppendVarint(function, id)
+				function = protowire.AppendTag(function, pprofFunctionName, protowire.VarintType)
+				function = protowire.AppendVarint(function, stringTable.index(stack[i].Name))
+				function = protowire.AppendTag(function, pprofFunctionSystemName, protowire.VarintType)
+				function = protowire.AppendVarint(function, stringTable.index(stack[i].Name))
+				functions = protowire.AppendTag(functions, pprofProfileFunction, protowire.BytesType)
+				functions = protowire.AppendBytes(functions, function)
+
+				var line []byte
+				line = protowire.AppendTag(line, pprofLineFunctionId, protowire.VarintType)
+				line = protowire.AppendVarint(line, id)
+				var location []byte
+				location = protowire.AppendTag(location, pprofLocationId, protowire.VarintType)
+				location = protowire.AppendVarint(location, id)
+				location = protowire.AppendTag(location, pprofLocationLine, protowire.BytesType)
+				location = protowire.AppendBytes(location, line)
+				locations = protowire.AppendTag(locations, pprofProfileLocation, protowire.BytesType)
+				locations = protowire.AppendBytes(locations, location)
+			}
+			locationIds = protowire.AppendVarint(locationIds, id)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, pprofSampleLocationId, protowire.BytesType)
+		sample = protowire.AppendBytes(sample, locationIds)
+		sample = protowire.AppendTag(sample, pprofSampleValue, protowire.BytesType)
+		sample = protowire.AppendBytes(sample, protowire.AppendVarint(nil, uint64(self)))
+		samples = protowire.AppendTag(samples, pprofProfileSample, protowire.BytesType)
+		samples = protowire.AppendBytes(samples, sample)
+	})
+
+	valueType := func(sampleType string, unit string) []byte {
+		var message []byte
+		message = protowire.AppendTag(message, pprofValueTypeType, protowire.VarintType)
+		message = protowire.AppendVarint(message, stringTable.index(sampleType))
+		message = protowire.AppendTag(message, pprofValueTypeUnit, protowire.VarintType)
+		message = protowire.AppendVarint(message, stringTable.index(unit))
+		return message
+	}
+
+	var profile []byte
+	profile = protowire.AppendTag(profile, pprofProfileSampleType, protowire.BytesType)
+	profile = protowire.AppendBytes(profile, valueType(profileSampleType, profileSampleUnit))
+	profile = append(profile, samples...)
+	profile = append(profile, locations...)
+	profile = append(profile, functions...)
+	profile = protowire.AppendTag(profile, pprofProfileTimeNanos, protowire.VarintType)
+	profile = protowire.AppendVarint(profile, uint64(startTime*1e9))
+	profile = protowire.AppendTag(profile, pprofProfileDurationNanos, protowire.VarintType)
+	profile = protowire.AppendVarint(profile, uint64(max(endTime-startTime, 0)*1e9))
+	profile = protowire.AppendTag(profile, pprofProfilePeriodType, protowire.BytesType)
+	profile = protowire.AppendBytes(profile, valueType(profileSampleType, profileSampleUnit))
+	profile = protowire.AppendTag(profile, pprofProfilePeriod, protowire.VarintType)
+	profile = protowire.AppendVarint(profile, 1)
+	// The string table is written last since the messages above add to it.
+	for _, value := range stringTable.strings {
+		profile = protowire.AppendTag(profile, pprofProfileStringTable, protowire.BytesType)
+		profile = protowire.AppendString(profile, value)
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(profile); err != nil {
+		return nil, fmt.Errorf("error compressing pprof profile: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing pprof profile: %v", err)
+	}
+	return compressed.Bytes(), nil
+}
+
+type speedscopeFile struct {
+	Schema             string              `json:"$schema"`
+	Shared             speedscopeShared    `json:"shared"`
+	Profiles           []speedscopeProfile `json:"profiles"`
+	Name               string              `json:"name"`
+	ActiveProfileIndex int                 `json:"activeProfileIndex"`
+	Exporter           string              `json:"exporter"`
+}
+
+type speedscopeShared struct {
+	Frames []speedscopeFrame `json:"frames"`
+}
+
+type speedscopeFrame struct {
+	Name string `json:"name"`
+}
+
+type speedscopeProfile struct {
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	Unit       string  `json:"unit"`
+	StartValue int64   `json:"startValue"`
+	EndValue   int64   `json:"endValue"`
+	Samples    [][]int `json:"samples"`
+	Weights    []int64 `json:"weights"`
+}
+
+// exportSpeedscopeProfile converts the flame graph to a speedscope sampled profile, see
+// https://github.com/jlfwong/speedscope/wiki/Importing-from-custom-sources.
+func exportSpeedscopeProfile(root *flameGraphNode, name string) ([]byte, error) {
+	profile := speedscopeProfile{
+		Type:    "sampled",
+		Name:    name,
+		Unit:    profileSampleUnit,
+		Samples: [][]int{},
+		Weights: []int64{},
+	}
+	frames := []speedscopeFrame{}
+	frameIndexes := map[string]int{}
+
+	walkFlameGraphStacks(root, func(stack []*flameGraphNode, self int64) {
+		sample := make([]int, len(stack))
+		for i, node := range stack {
+			index, ok := frameIndexes[node.Name]
+			if !ok {
+				index = len(frames)
+				frameIndexes[node.Name] = index
+				frames = append(frames, speedscopeFrame{Name: node.Name})
+			}
+			sample[i] = index
+		}
+		profile.Samples = append(profile.Samples, sample)
+		profile.Weights = append(profile.Weights, self)
+		profile.EndValue += self
+	})
+
+	body, err := json.Marshal(speedscopeFile{
+		Schema:   "https://www.speedscope.app/file-format-schema.json",
+		Shared:   speedscopeShared{Frames: frames},
+		Profiles: []speedscopeProfile{profile},
+		Name:     name,
+		Exporter: "metoro-mcp-server",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling speedscope profile: %v", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodedPprofProfile holds the parts of a profile.proto the tests look at.
+type decodedPprofProfile struct {
+	strings []string
+	// functions maps function ids to their name index.
+	functions map[uint64]uint64
+	// samples are the function names of each sample from the innermost call followed by the value.
+	samples    [][]uint64
+	values     []uint64
+	timeNanos  uint64
+	sampleType []uint64
+}
+
+func decodePprofProfile(t *testing.T, compressed []byte) decodedPprofProfile {
+	t.Helper()
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("expected a gzipped profile: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to decompress profile: %v", err)
+	}
+
+	profile := decodedPprofProfile{functions: map[uint64]uint64{}}
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			t.Fatalf("invalid tag")
+		}
+		data = data[n:]
+
+		switch wireType {
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(data)
+			data = data[n:]
+			if number == pprofProfileTimeNanos {
+				profile.timeNanos = value
+			}
+		case protowire.BytesType:
+			message, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				t.Fatalf("invalid field %d", number)
+			}
+			data = data[n:]
+			if number == pprofProfileStringTable {
+				profile.strings = append(profile.strings, string(message))
+				continue
+			}
+			fields := decodePprofMessage(t, message)
+			switch number {
+			case pprofProfileFunction:
+				profile.functions[fields[pprofFunctionId][0]] = fields[pprofFunctionName][0]
+			case pprofProfileSample:
+				profile.samples = append(profile.samples, fields[pprofSampleLocationId])
+				profile.values = append(profile.values, fields[pprofSampleValue]...)
+			case pprofProfileSampleType:
+				profile.sampleType = []uint64{fields[pprofValueTypeType][0], fields[pprofValueTypeUnit][0]}
+			}
+		default:
+			t.Fatalf("unexpected wire type %d", wireType)
+		}
+	}
+	return profile
+}
+
+// decodePprofMessage decodes the varint and packed varint fields of a message. Other fields are skipped.
+func decodePprofMessage(t *testing.T, message []byte) map[protowire.Number][]uint64 {
+	t.Helper()
+
+	fields := map[protowire.Number][]uint64{}
+	for len(message) > 0 {
+		number, wireType, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return fields
+		}
+		message = message[n:]
+		switch wireType {
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(message)
+			message = message[n:]
+			fields[number] = append(fields[number], value)
+		case protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(message)
+			message = message[n:]
+			for len(packed) > 0 {
+				value, n := protowire.ConsumeVarint(packed)
+				if n < 0 {
+					break
+				}
+				packed = packed[n:]
+				fields[number] = append(fields[number], value)
+			}
+		default:
+			t.Fatalf("unexpected wire type %d", wireType)
+		}
+	}
+	return fields
+}
+
+func TestExportPprofProfile(t *testing.T) {
+	compressed, err := exportPprofProfile(newTestFlameGraph(), 1771495200, 1771495500)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	profile := decodePprofProfile(t, compressed)
+
+	if len(profile.strings) == 0 || profile.strings[0] != "" {
+		t.Fatalf("expected the string table to start with an empty string")
+	}
+	if profile.strings[profile.sampleType[0]] != "cpu" || profile.strings[profile.sampleType[1]] != "nanoseconds" {
+		t.Fatalf("unexpected sample type %v", profile.sampleType)
+	}
+	if profile.timeNanos != 1771495200*1e9 {
+		t.Fatalf("unexpected time %d", profile.timeNanos)
+	}
+	if len(profile.functions) != 5 {
+		t.Fatalf("expected one function per name, got %d", len(profile.functions))
+	}
+
+	var stacks []string
+	total := uint64(0)
+	for i, sample := range profile.samples {
+		var names []string
+		for _, id := range sample {
+			names = append(names, profile.strings[profile.functions[id]])
+		}
+		stacks = append(stacks, strings.Join(names, "<"))
+		total += profile.values[i]
+	}
+	if stacks[3] != "helper<parse<handle<main" || profile.values[3] != 5 {
+		t.Fatalf("expected samples to start with the innermost call, got %v", stacks)
+	}
+	if len(stacks) != 7 || total != 1000 {
+		t.Fatalf("expected all self time to be in samples, got %d samples with %d", len(stacks), total)
+	}
+}
+
+func TestExportSpeedscopeProfile(t *testing.T) {
+	body, err := exportSpeedscopeProfile(newTestFlameGraph(), "checkout cpu")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var file speedscopeFile
+	if err := json.Unmarshal(body, &file); err != nil {
+		t.Fatalf("failed to decode speedscope file: %v", err)
+	}
+	profile := file.Profiles[0]
+	if file.Schema != "https://www.speedscope.app/file-format-schema.json" || profile.Type != "sampled" || profile.EndValue != 1000 {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+	if len(file.Shared.Frames) != 5 || len(profile.Samples) != len(profile.Weights) {
+		t.Fatalf("unexpected frames %+v", file.Shared.Frames)
+	}
+
+	var names []string
+	for _, index := range profile.Samples[3] {
+		names = append(names, file.Shared.Frames[index].Name)
+	}
+	if strings.Join(names, ";") != "main;handle;parse;helper" || profile.Weights[3] != 5 {
+		t.Fatalf("expected samples to start with the outermost call, got %v", names)
+	}
+}
+
+func TestGetProfilesHandlerExportsEmbeddedResource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(newTestFlameGraph())
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	response, err := GetProfilesHandler(context.Background(), GetProfileHandlerArgs{
+		TimeConfig:   absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z"),
+		ServiceName:  "checkout",
+		ExportFormat: "pprof",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(response.Content) != 2 || !strings.Contains(response.Content[0].TextContent.Text, "go tool pprof") {
+		t.Fatalf("expected a description and the file")
+	}
+
+	blob := response.Content[1].EmbeddedResource.BlobResourceContents
+	if blob.Uri != "metoro://profiles/checkout/1771495200-1771495500.pb.gz" || *blob.MimeType != pprofMimeType {
+		t.Fatalf("unexpected resource %s %s", blob.Uri, *blob.MimeType)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(blob.Blob)
+	if err != nil {
+		t.Fatalf("expected base64 data: %v", err)
+	}
+	if profile := decodePprofProfile(t, compressed); len(profile.samples) != 7 {
+		t.Fatalf("expected the whole profile to be exported, got %d samples", len(profile.samples))
+	}
+
+	if _, err := GetProfilesHandler(context.Background(), GetProfileHandlerArgs{ServiceName: "checkout", ExportFormat: "svg"}); err == nil {
+		t.Fatalf("expected error for unknown export format")
+	}
+}
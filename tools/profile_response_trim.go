@@ -5,7 +5,8 @@ import (
 	"fmt"
 )
 
-const profileDurationPruneRatio = 0.01
+// defaultProfilePruneRatio is the share of the root duration below which nodes are pruned when no prune_ratio is set.
+const defaultProfilePruneRatio = 0.01
 
 type flameGraphNode struct {
 	Name     string            `json:"name"`
@@ -16,13 +17,13 @@ type flameGraphNode struct {
 	Data     map[string]string `json:"data,omitempty"`
 }
 
-func trimProfilesResponse(response []byte) ([]byte, error) {
+func trimProfilesResponse(response []byte, pruneRatio float64) ([]byte, error) {
 	var root flameGraphNode
 	if err := json.Unmarshal(response, &root); err != nil {
 		return nil, fmt.Errorf("error unmarshaling profiles response: %v", err)
 	}
 
-	changed := pruneFlameGraphNodesBelowDurationThreshold(&root)
+	changed := pruneFlameGraphNodesBelowDurationThreshold(&root, pruneRatio)
 	if !changed {
 		return response, nil
 	}
@@ -35,12 +36,12 @@ func trimProfilesResponse(response []byte) ([]byte, error) {
 	return trimmed, nil
 }
 
-func pruneFlameGraphNodesBelowDurationThreshold(root *flameGraphNode) bool {
-	if root == nil || root.Duration <= 0 {
+func pruneFlameGraphNodesBelowDurationThreshold(root *flameGraphNode, pruneRatio float64) bool {
+	if root == nil || root.Duration <= 0 || pruneRatio <= 0 {
 		return false
 	}
 
-	minDuration := float64(root.Duration) * profileDurationPruneRatio
+	minDuration := float64(root.Duration) * pruneRatio
 	return pruneFlameGraphChildren(root, minDuration)
 }
 
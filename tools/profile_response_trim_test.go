@@ -56,7 +56,7 @@ func TestTrimProfilesResponsePrunesNodesBelowOnePercent(t *testing.T) {
 		t.Fatalf("failed to marshal test payload: %v", err)
 	}
 
-	trimmed, err := trimProfilesResponse(raw)
+	trimmed, err := trimProfilesResponse(raw, defaultProfilePruneRatio)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -110,7 +110,7 @@ func TestTrimProfilesResponseLeavesZeroDurationRootUnchanged(t *testing.T) {
 		t.Fatalf("failed to marshal test payload: %v", err)
 	}
 
-	trimmed, err := trimProfilesResponse(raw)
+	trimmed, err := trimProfilesResponse(raw, defaultProfilePruneRatio)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -121,7 +121,7 @@ func TestTrimProfilesResponseLeavesZeroDurationRootUnchanged(t *testing.T) {
 }
 
 func TestTrimProfilesResponseReturnsErrorForInvalidJSON(t *testing.T) {
-	_, err := trimProfilesResponse([]byte(`{"name":`))
+	_, err := trimProfilesResponse([]byte(`{"name":`), defaultProfilePruneRatio)
 	if err == nil {
 		t.Fatalf("expected error for invalid JSON")
 	}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultProfileTopFunctions = 20
+	maxProfileTopFunctions     = 200
+)
+
+type profileTopFunctionsResponse struct {
+	TotalDuration int64 `json:"totalDuration"`
+	FunctionCount int   `json:"functionCount"`
+	// Functions are sorted by self time, the time spent in the function itself rather than in functions it calls.
+	Functions []profileFunction `json:"functions"`
+}
+
+type profileFunction struct {
+	Name string `json:"name"`
+	Self int64  `json:"self"`
+	// SelfPercentage and TotalPercentage are relative to the total duration of the profile.
+	SelfPercentage float64 `json:"selfPercentage"`
+	// Total is the time spent in the function including the functions it calls. Recursive calls are counted once.
+	Total           int64   `json:"total"`
+	TotalPercentage float64 `json:"totalPercentage"`
+}
+
+func parseFlameGraph(response []byte) (*flameGraphNode, error) {
+	var root flameGraphNode
+	if err := json.Unmarshal(response, &root); err != nil {
+		return nil, fmt.Errorf("error unmarshaling profiles response: %v", err)
+	}
+	return &root, nil
+}
+
+// flameGraphSelfDuration is the part of the node's duration not spent in its children.
+func flameGraphSelfDuration(node *flameGraphNode) int64 {
+	self := node.Duration
+	for _, child := range node.Children {
+		if child != nil {
+			self -= child.Duration
+		}
+	}
+	return max(self, 0)
+}
+
+// profileTopFunctions merges the nodes of the flame graph by function name, whatever they were called from. The root
+// of the flame graph is the whole profile rather than a function so it is left out.
+func profileTopFunctions(root *flameGraphNode, limit int) profileTopFunctionsResponse {
+	functions := map[string]*profileFunction{}
+	onStack := map[string]int{}
+
+	var visit func(node *flameGraphNode)
+	visit = func(node *flameGraphNode) {
+		function := functions[node.Name]
+		if function == nil {
+			function = &profileFunction{Name: node.Name}
+			functions[node.Name] = function
+		}
+		function.Self += flameGraphSelfDuration(node)
+		if onStack[node.Name] == 0 {
+			function.Total += node.Duration
+		}
+
+		onStack[node.Name]++
+		for _, child := range node.Children {
+			if child != nil {
+				visit(child)
+			}
+		}
+		onStack[node.Name]--
+	}
+	for _, child := range root.Children {
+		if child != nil {
+			visit(child)
+		}
+	}
+
+	response := profileTopFunctionsResponse{
+		TotalDuration: root.Duration,
+		FunctionCount: len(functions),
+		Functions:     []profileFunction{},
+	}
+	for _, function := range functions {
+		function.SelfPercentage = profilePercentage(function.Self, root.Duration)
+		function.TotalPercentage = profilePercentage(function.Total, root.Duration)
+		response.Functions = append(response.Functions, *function)
+	}
+	sort.Slice(response.Functions, func(i, j int) bool {
+		if response.Functions[i].Self != response.Functions[j].Self {
+			return response.Functions[i].Self > response.Functions[j].Self
+		}
+		if response.Functions[i].Total != response.Functions[j].Total {
+			return response.Functions[i].Total > response.Functions[j].Total
+		}
+		return response.Functions[i].Name < response.Functions[j].Name
+	})
+	if len(response.Functions) > limit {
+		response.Functions = response.Functions[:limit]
+	}
+	return response
+}
+
+// foldProfileStacks returns the flame graph in the folded stacks format used by flamegraph.pl, one line per stack
+// with the frames from the outermost call separated by semicolons followed by the self time of the last frame.
+func foldProfileStacks(root *flameGraphNode) string {
+	var lines []string
+	walkProfileStacks(root, func(stack string, self int64) {
+		lines = append(lines, fmt.Sprintf("%s %d", stack, self))
+	})
+	return strings.Join(lines, "\n")
+}
+
+// walkProfileStacks visits the folded stack of every node with self time, depth first.
+func walkProfileStacks(root *flameGraphNode, visit func(stack string, self int64)) {
+	walkFlameGraphStacks(root, func(stack []*flameGraphNode, self int64) {
+		frames := make([]string, len(stack))
+		for i, node := range stack {
+			frames[i] = strings.ReplaceAll(node.Name, ";", ",")
+		}
+		visit(strings.Join(frames, ";"), self)
+	})
+}
+
+// walkFlameGraphStacks visits every node with self time with the nodes from the outermost call to the node, depth
+// first. The root of the flame graph is left out of the stacks.
+func walkFlameGraphStacks(root *flameGraphNode, visit func(stack []*flameGraphNode, self int64)) {
+	var walk func(node *flameGraphNode, stack []*flameGraphNode)
+	walk = func(node *flameGraphNode, stack []*flameGraphNode) {
+		stack = append(stack, node)
+		if self := flameGraphSelfDuration(node); self > 0 {
+			visit(stack, self)
+		}
+		for _, child := range node.Children {
+			if child != nil {
+				walk(child, stack[:len(stack):len(stack)])
+			}
+		}
+	}
+	for _, child := range root.Children {
+		if child != nil {
+			walk(child, nil)
+		}
+	}
+}
+
+func profilePercentage(value int64, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(value)/float64(total)*10000) / 100
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestFlameGraph returns a profile where main calls handle and gc, handle calls parse directly and through a
+// recursive call to itself, and parse calls the tiny helper.
+func newTestFlameGraph() *flameGraphNode {
+	return &flameGraphNode{
+		Name:     "total",
+		Duration: 1000,
+		Children: []*flameGraphNode{
+			{
+				Name:     "main",
+				Duration: 1000,
+				Children: []*flameGraphNode{
+					{
+						Name:     "handle",
+						Duration: 800,
+						Children: []*flameGraphNode{
+							{Name: "parse", Duration: 300, Children: []*flameGraphNode{{Name: "helper", Duration: 5}}},
+							{Name: "handle", Duration: 400, Children: []*flameGraphNode{{Name: "parse", Duration: 350}}},
+						},
+					},
+					{Name: "gc", Duration: 150},
+				},
+			},
+		},
+	}
+}
+
+func TestProfileTopFunctions(t *testing.T) {
+	top := profileTopFunctions(newTestFlameGraph(), 3)
+
+	if top.TotalDuration != 1000 || top.FunctionCount != 5 || len(top.Functions) != 3 {
+		t.Fatalf("unexpected summary %+v", top)
+	}
+
+	parse := top.Functions[0]
+	if parse.Name != "parse" || parse.Self != 645 || parse.Total != 650 || parse.SelfPercentage != 64.5 {
+		t.Fatalf("expected parse merged across call sites first, got %+v", parse)
+	}
+	handle := top.Functions[1]
+	if handle.Name != "handle" || handle.Self != 150 || handle.Total != 800 {
+		t.Fatalf("expected recursive calls to be counted once in total, got %+v", handle)
+	}
+	if top.Functions[2].Name != "gc" {
+		t.Fatalf("expected gc third, got %+v", top.Functions[2])
+	}
+}
+
+func TestFoldProfileStacks(t *testing.T) {
+	root := newTestFlameGraph()
+	root.Children[0].Children[1].Name = "runtime;gc"
+
+	expected := strings.Join([]string{
+		"main 50",
+		"main;handle 100",
+		"main;handle;parse 295",
+		"main;handle;parse;helper 5",
+		"main;handle;handle 50",
+		"main;handle;handle;parse 350",
+		"main;runtime,gc 150",
+	}, "\n")
+	if folded := foldProfileStacks(root); folded != expected {
+		t.Fatalf("unexpected folded stacks\n%s", folded)
+	}
+}
+
+func TestGetProfilesHandlerModes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/profiles" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(newTestFlameGraph())
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	args := GetProfileHandlerArgs{
+		TimeConfig:  absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z"),
+		ServiceName: "checkout",
+		Mode:        "folded",
+	}
+	pruneRatio := 0.1
+	args.PruneRatio = &pruneRatio
+	response, err := GetProfilesHandler(context.Background(), args)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if folded := response.Content[0].TextContent.Text; strings.Contains(folded, "helper") || !strings.Contains(folded, "main;handle;parse 300") {
+		t.Fatalf("expected calls below 10%% to be folded into their caller, got\n%s", folded)
+	}
+
+	args.Mode = "top"
+	response, err = GetProfilesHandler(context.Background(), args)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var top profileTopFunctionsResponse
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &top); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if top.FunctionCount != 5 || top.Functions[0].Name != "parse" {
+		t.Fatalf("expected top mode to use the whole profile, got %+v", top)
+	}
+
+	args.Mode = ""
+	response, err = GetProfilesHandler(context.Background(), args)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var tree flameGraphNode
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &tree); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if main := findFlameGraphChild(&tree, "main"); main == nil || findFlameGraphChild(main, "gc") == nil || len(findFlameGraphChild(findFlameGraphChild(main, "handle"), "parse").Children) != 0 {
+		t.Fatalf("unexpected tree %+v", tree)
+	}
+
+	pruneRatio = 1
+	if _, err := GetProfilesHandler(context.Background(), args); err == nil {
+		t.Fatalf("expected error for prune_ratio of 1")
+	}
+}
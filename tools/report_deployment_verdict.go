@@ -56,7 +56,7 @@ func ReportDeploymentVerdictHandler(ctx context.Context, arguments ReportDeploym
 	}
 
 	// Make the API request
-	responseBody, err := utils.MakeMetoroAPIRequest("POST", "deploymentVerdict", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIWriteRequest(ctx, "POST", "deploymentVerdict", bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to report deployment verdict: %w", err)
 	}
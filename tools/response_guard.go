@@ -3,12 +3,10 @@ package tools
 import (
 	"encoding/json"
 	"fmt"
-	"math"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
-	"unicode/utf8"
 
 	mcpgolang "github.com/metoro-io/mcp-golang"
 )
@@ -26,12 +24,30 @@ type ToolResponseModifier func(toolName string, response *mcpgolang.ToolResponse
 type ToolResponseGuardOptions struct {
 	MaxTokens            int
 	TooLargeErrorMessage string
+	TokenEstimator       TokenEstimator
 }
 
 var DefaultToolResponseGuard = NewToolResponseGuard(nil, ToolResponseGuardOptions{})
 
 func (tool MetoroTools) WrappedHandler() any {
-	return wrapToolHandlerWithResponseGuard(tool.Name, tool.Handler, tool.ResponseGuard)
+	return wrapToolHandlerWithResponseGuard(tool)
+}
+
+// responseGuard returns the guard the responses of the tool are checked with. Tools without their own guard get one
+// that runs their modifier on the JSON, formats the result and checks it against their budget. Tools with their own
+// guard set the budget in its options and their responses are formatted once the guard accepted them.
+func (tool MetoroTools) responseGuard(outputFormat string) ToolResponseGuard {
+	if tool.ResponseGuard != nil {
+		return withOutputFormat(tool.ResponseGuard, outputFormat)
+	}
+	modifier := outputFormatModifier(tool.ResponseModifier, outputFormat)
+	if modifier == nil && tool.MaxResponseTokens <= 0 && tool.TokenEstimator == nil {
+		return DefaultToolResponseGuard
+	}
+	return NewToolResponseGuard(modifier, ToolResponseGuardOptions{
+		MaxTokens:      tool.MaxResponseTokens,
+		TokenEstimator: tool.TokenEstimator,
+	})
 }
 
 func NewToolResponseGuard(modifier ToolResponseModifier, options ToolResponseGuardOptions) ToolResponseGuard {
@@ -53,16 +69,20 @@ func NewToolResponseGuard(modifier ToolResponseModifier, options ToolResponseGua
 		}
 
 		maxTokens := options.MaxTokens
+		estimator := options.TokenEstimator
 		if maxTokens <= 0 {
 			maxTokens = getGlobalToolResponseMaxTokens()
 		}
+		if estimator == nil {
+			estimator = getGlobalTokenEstimator()
+		}
 
 		tooLargeMessage := options.TooLargeErrorMessage
 		if tooLargeMessage == "" {
 			tooLargeMessage = toolResponseTooLargeErrorMessage
 		}
 
-		tokenCount, err := estimateToolResponseTokens(guardedResponse)
+		tokenCount, err := estimateToolResponseTokens(guardedResponse, estimator)
 		if err != nil {
 			return nil, fmt.Errorf("failed to estimate response token size for tool %q: %w", toolName, err)
 		}
@@ -89,29 +109,45 @@ func getGlobalToolResponseMaxTokens() int {
 	return parsed
 }
 
-func estimateToolResponseTokens(response *mcpgolang.ToolResponse) (int, error) {
-	body, err := json.Marshal(response)
-	if err != nil {
-		return 0, err
+// estimateToolResponseTokens leaves out the data of embedded resource blobs, such as exported profiles, since clients
+// save them rather than passing them to the model.
+func estimateToolResponseTokens(response *mcpgolang.ToolResponse, estimator TokenEstimator) (int, error) {
+	counted := response
+	for i, content := range response.Content {
+		if content == nil || content.EmbeddedResource == nil || content.EmbeddedResource.BlobResourceContents == nil {
+			continue
+		}
+		if counted == response {
+			counted = &mcpgolang.ToolResponse{Content: append([]*mcpgolang.Content{}, response.Content...)}
+		}
+		blob := *content.EmbeddedResource.BlobResourceContents
+		blob.Blob = ""
+		resource := *content.EmbeddedResource
+		resource.BlobResourceContents = &blob
+		withoutBlob := *content
+		withoutBlob.EmbeddedResource = &resource
+		counted.Content[i] = &withoutBlob
 	}
 
-	return estimateTokenCount(string(body)), nil
-}
-
-func estimateTokenCount(value string) int {
-	if value == "" {
-		return 0
+	body, err := json.Marshal(counted)
+	if err != nil {
+		return 0, err
 	}
 
-	runeCount := utf8.RuneCountInString(value)
-	return int(math.Ceil(float64(runeCount) / 4.0))
+	return estimator.EstimateTokens(string(body)), nil
 }
 
-func wrapToolHandlerWithResponseGuard(toolName string, handler any, overrideGuard ToolResponseGuard) any {
-	handlerValue := reflect.ValueOf(handler)
+func wrapToolHandlerWithResponseGuard(tool MetoroTools) any {
+	toolName := tool.Name
+	handlerValue := reflect.ValueOf(tool.Handler)
 	handlerType := handlerValue.Type()
 
 	wrapped := reflect.MakeFunc(handlerType, func(inputs []reflect.Value) []reflect.Value {
+		outputFormat, formatErr := normalizeOutputFormat(outputFormatFromInputs(inputs))
+		if formatErr != nil && handlerType.NumOut() == 2 {
+			return []reflect.Value{reflect.Zero(handlerType.Out(0)), reflect.ValueOf(formatErr)}
+		}
+
 		outputs := handlerValue.Call(inputs)
 		if len(outputs) != 2 {
 			return outputs
@@ -128,12 +164,15 @@ func wrapToolHandlerWithResponseGuard(toolName string, handler any, overrideGuar
 			return outputs
 		}
 
-		guard := DefaultToolResponseGuard
-		if overrideGuard != nil {
-			guard = overrideGuard
-		}
+		guard := tool.responseGuard(outputFormat)
 
-		guardedResponse, err := guard(toolName, response)
+		var guardedResponse *mcpgolang.ToolResponse
+		var err error
+		if tool.TruncateOversizedResponse {
+			guardedResponse, err = truncateOversizedToolResponse(toolName, response, continuationCursorFromInputs(inputs), guard)
+		} else {
+			guardedResponse, err = guard(toolName, response)
+		}
 		if err != nil {
 			outputs[0] = reflect.Zero(handlerType.Out(0))
 			outputs[1] = reflect.ValueOf(err)
@@ -151,3 +190,27 @@ func wrapToolHandlerWithResponseGuard(toolName string, handler any, overrideGuar
 
 	return wrapped.Interface()
 }
+
+func continuationCursorFromInputs(inputs []reflect.Value) string {
+	for _, input := range inputs {
+		if !input.IsValid() || !input.CanInterface() {
+			continue
+		}
+		if args, ok := input.Interface().(continuationCursorArgs); ok {
+			return args.continuationCursor()
+		}
+	}
+	return ""
+}
+
+func outputFormatFromInputs(inputs []reflect.Value) string {
+	for _, input := range inputs {
+		if !input.IsValid() || !input.CanInterface() {
+			continue
+		}
+		if args, ok := input.Interface().(outputFormatArgs); ok {
+			return args.outputFormat()
+		}
+	}
+	return ""
+}
@@ -68,3 +68,29 @@ func TestNewToolResponseGuardAppliesModifierBeforeTokenCheck(t *testing.T) {
 		t.Fatalf("expected modifier to rewrite response text")
 	}
 }
+
+func TestNewToolResponseGuardLeavesOutEmbeddedBlobs(t *testing.T) {
+	guard := NewToolResponseGuard(nil, ToolResponseGuardOptions{
+		MaxTokens:      100,
+		TokenEstimator: RuneTokenEstimator{},
+	})
+
+	blob := strings.Repeat("QUJD", 1000)
+	response := mcpgolang.NewToolResponse(
+		mcpgolang.NewTextContent("exported"),
+		mcpgolang.NewBlobResourceContent("metoro://profiles/test.pb.gz", blob, "application/octet-stream"),
+	)
+
+	guarded, err := guard("test_tool", response)
+	if err != nil {
+		t.Fatalf("expected blob data not to count towards the limit, got %v", err)
+	}
+	if guarded.Content[1].EmbeddedResource.BlobResourceContents.Blob != blob {
+		t.Fatalf("expected the blob to be returned unchanged")
+	}
+
+	response.Content[0].TextContent.Text = strings.Repeat("x", 4000)
+	if _, err := guard("test_tool", response); err == nil {
+		t.Fatalf("expected text content to still count towards the limit")
+	}
+}
@@ -0,0 +1,344 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+// ResponseContinuationArgs is embedded in the arguments of tools whose oversized responses are truncated
+// instead of rejected, so a follow-up call can ask for the items that did not fit.
+type ResponseContinuationArgs struct {
+	ContinuationCursor string `json:"continuation_cursor,omitempty" jsonschema:"description=If the response is too large some items are left out and a truncation note with a continuationCursor is returned. Only set this to the continuationCursor from the truncation note of a previous call of this tool to get the items that did not fit in that response. All other arguments must be exactly the same as in the previous call."`
+}
+
+func (a ResponseContinuationArgs) continuationCursor() string {
+	return a.ContinuationCursor
+}
+
+type continuationCursorArgs interface {
+	continuationCursor() string
+}
+
+// responseContinuationCursor points at the first item of a JSON array that was left out of a truncated response.
+type responseContinuationCursor struct {
+	Tool string `json:"tool"`
+	// Path is a JSON pointer (RFC 6901) to the truncated array in the tool's text response.
+	Path   string `json:"path"`
+	Offset int    `json:"offset"`
+}
+
+type responseTruncationNote struct {
+	Truncated          bool   `json:"truncated"`
+	Path               string `json:"path"`
+	FirstItem          int    `json:"firstItem"`
+	ReturnedItems      int    `json:"returnedItems"`
+	RemainingItems     int    `json:"remainingItems"`
+	ContinuationCursor string `json:"continuationCursor,omitempty"`
+	Message            string `json:"message"`
+}
+
+type truncatableArray struct {
+	path  []string
+	size  int
+	items []any
+}
+
+// truncateOversizedToolResponse runs the response guard and, when the response is too large, drops items from the
+// end of the largest JSON array in its text content until the guard accepts it. A note is appended with how many
+// items were dropped and a cursor the agent can pass back through ResponseContinuationArgs to get them. If no
+// truncation makes the response fit, the guard's error is returned unchanged.
+func truncateOversizedToolResponse(toolName string, response *mcpgolang.ToolResponse, rawCursor string, guard ToolResponseGuard) (*mcpgolang.ToolResponse, error) {
+	var cursor *responseContinuationCursor
+	if rawCursor != "" {
+		decoded, err := decodeContinuationCursor(rawCursor)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.Tool != toolName {
+			return nil, fmt.Errorf("continuation_cursor was issued by %s, it can only be used with that tool", decoded.Tool)
+		}
+		cursor = decoded
+	}
+
+	contentIndex, document := findJSONTextContent(response)
+	if document == nil {
+		if cursor != nil {
+			return nil, fmt.Errorf("continuation_cursor can't be applied, the response has no JSON content")
+		}
+		return guard(toolName, response)
+	}
+
+	offset := 0
+	var candidates []truncatableArray
+	if cursor != nil {
+		path := parseJSONPointer(cursor.Path)
+		items, ok := getJSONValue(document, path).([]any)
+		if !ok {
+			return nil, fmt.Errorf("continuation_cursor doesn't match the response, call the tool again without it")
+		}
+		offset = min(cursor.Offset, len(items))
+		items = items[offset:]
+		document = setJSONValue(document, path, items)
+		candidates = []truncatableArray{{path: path, items: items}}
+	}
+
+	untruncated, err := buildTruncatedResponse(response, contentIndex, document, nil)
+	if err != nil {
+		return nil, err
+	}
+	guardedResponse, guardErr := guard(toolName, untruncated)
+	if guardErr == nil {
+		return guardedResponse, nil
+	}
+
+	if cursor == nil {
+		candidates = findTruncatableArrays(document)
+	}
+	for _, candidate := range candidates {
+		truncated, err := truncateArrayToFit(toolName, response, contentIndex, document, candidate, offset, guard)
+		if err != nil {
+			return nil, err
+		}
+		if truncated != nil {
+			return truncated, nil
+		}
+	}
+
+	return nil, guardErr
+}
+
+// truncateArrayToFit binary searches for the largest prefix of the candidate array the guard accepts. It returns
+// nil if not even a single item fits.
+func truncateArrayToFit(toolName string, response *mcpgolang.ToolResponse, contentIndex int, document any, candidate truncatableArray, offset int, guard ToolResponseGuard) (*mcpgolang.ToolResponse, error) {
+	var best *mcpgolang.ToolResponse
+	low, high := 1, len(candidate.items)-1
+	for low <= high {
+		keep := (low + high) / 2
+		truncatedDocument := setJSONValue(document, candidate.path, candidate.items[:keep])
+		note := newResponseTruncationNote(toolName, candidate.path, offset, keep, len(candidate.items)-keep)
+		truncated, err := buildTruncatedResponse(response, contentIndex, truncatedDocument, note)
+		if err != nil {
+			return nil, err
+		}
+
+		guardedResponse, err := guard(toolName, truncated)
+		if err == nil && guardedResponse != nil {
+			best = guardedResponse
+			low = keep + 1
+		} else {
+			high = keep - 1
+		}
+	}
+
+	return best, nil
+}
+
+func newResponseTruncationNote(toolName string, path []string, offset int, returned int, remaining int) *responseTruncationNote {
+	pointer := formatJSONPointer(path)
+	note := &responseTruncationNote{
+		Truncated:      true,
+		Path:           pointer,
+		FirstItem:      offset,
+		ReturnedItems:  returned,
+		RemainingItems: remaining,
+		ContinuationCursor: encodeContinuationCursor(responseContinuationCursor{
+			Tool:   toolName,
+			Path:   pointer,
+			Offset: offset + returned,
+		}),
+	}
+	note.Message = fmt.Sprintf("The response was too large so only items %d to %d of the array at %s were returned and %d items were left out. To get the next items call %s again with exactly the same arguments (use an absolute time range so the data doesn't shift) and continuation_cursor set to continuationCursor. Alternatively narrow down the query with more filters or a smaller time window.",
+		offset, offset+returned-1, pointer, remaining, toolName)
+	return note
+}
+
+func buildTruncatedResponse(response *mcpgolang.ToolResponse, contentIndex int, document any, note *responseTruncationNote) (*mcpgolang.ToolResponse, error) {
+	if fields, ok := document.(map[string]any); ok && note != nil {
+		// The next page starts after the last item of the whole page, so it is only handed out with the last items.
+		if _, ok := fields[nextPageCursorField]; ok {
+			document = removeJSONValue(document, []string{nextPageCursorField})
+		}
+	}
+	text, err := marshalJSONWithoutHTMLEscape(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal truncated response: %w", err)
+	}
+
+	content := make([]*mcpgolang.Content, 0, len(response.Content)+1)
+	content = append(content, response.Content...)
+	content[contentIndex] = mcpgolang.NewTextContent(text)
+
+	if note != nil {
+		noteText, err := marshalJSONWithoutHTMLEscape(note)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal truncation note: %w", err)
+		}
+		content = append(content, mcpgolang.NewTextContent(noteText))
+	}
+
+	return mcpgolang.NewToolResponse(content...), nil
+}
+
+// findJSONTextContent returns the first text content that holds a JSON object or array, decoded with numbers
+// kept as json.Number so they survive re-encoding unchanged.
+func findJSONTextContent(response *mcpgolang.ToolResponse) (int, any) {
+	for i, content := range response.Content {
+		if content == nil || content.Type != mcpgolang.ContentTypeText || content.TextContent == nil {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(content.TextContent.Text)
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(trimmed))
+		decoder.UseNumber()
+		var document any
+		if err := decoder.Decode(&document); err != nil {
+			continue
+		}
+		return i, document
+	}
+
+	return -1, nil
+}
+
+// findTruncatableArrays lists the arrays with more than one item, largest serialized size first.
+func findTruncatableArrays(document any) []truncatableArray {
+	var candidates []truncatableArray
+	var walk func(value any, path []string)
+	walk = func(value any, path []string) {
+		switch typed := value.(type) {
+		case map[string]any:
+			for key, child := range typed {
+				walk(child, append(append([]string{}, path...), key))
+			}
+		case []any:
+			if len(typed) > 1 {
+				serialized, err := json.Marshal(typed)
+				if err == nil {
+					candidates = append(candidates, truncatableArray{path: path, size: len(serialized), items: typed})
+				}
+			}
+			for i, child := range typed {
+				walk(child, append(append([]string{}, path...), strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(document, nil)
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].size != candidates[j].size {
+			return candidates[i].size > candidates[j].size
+		}
+		if len(candidates[i].path) != len(candidates[j].path) {
+			return len(candidates[i].path) < len(candidates[j].path)
+		}
+		return formatJSONPointer(candidates[i].path) < formatJSONPointer(candidates[j].path)
+	})
+	return candidates
+}
+
+func getJSONValue(document any, path []string) any {
+	current := document
+	for _, token := range path {
+		switch typed := current.(type) {
+		case map[string]any:
+			current = typed[token]
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(typed) {
+				return nil
+			}
+			current = typed[index]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// setJSONValue returns a copy of document with the value at path replaced. Containers along the path are copied
+// so the original document can be reused for further attempts.
+func setJSONValue(document any, path []string, value any) any {
+	if len(path) == 0 {
+		return value
+	}
+
+	switch typed := document.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(typed))
+		for key, child := range typed {
+			copied[key] = child
+		}
+		copied[path[0]] = setJSONValue(typed[path[0]], path[1:], value)
+		return copied
+	case []any:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(typed) {
+			return document
+		}
+		copied := append([]any{}, typed...)
+		copied[index] = setJSONValue(typed[index], path[1:], value)
+		return copied
+	default:
+		return document
+	}
+}
+
+func formatJSONPointer(path []string) string {
+	var builder strings.Builder
+	for _, token := range path {
+		builder.WriteString("/")
+		builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return builder.String()
+}
+
+func parseJSONPointer(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+func encodeContinuationCursor(cursor responseContinuationCursor) string {
+	serialized, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(serialized)
+}
+
+func decodeContinuationCursor(raw string) (*responseContinuationCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid continuation_cursor, pass the continuationCursor value from the truncation note unchanged")
+	}
+
+	var cursor responseContinuationCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil || cursor.Tool == "" || cursor.Offset < 0 {
+		return nil, fmt.Errorf("invalid continuation_cursor, pass the continuationCursor value from the truncation note unchanged")
+	}
+	return &cursor, nil
+}
+
+func marshalJSONWithoutHTMLEscape(value any) (string, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+type truncationTestArgs struct {
+	Query string `json:"query"`
+	ResponseContinuationArgs
+}
+
+func newTruncationTestTool(itemCount int, maxTokens int) func(context.Context, truncationTestArgs) (*mcpgolang.ToolResponse, error) {
+	handler := func(ctx context.Context, arguments truncationTestArgs) (*mcpgolang.ToolResponse, error) {
+		items := make([]map[string]any, itemCount)
+		for i := range items {
+			items[i] = map[string]any{"index": i, "message": strings.Repeat("x", 200)}
+		}
+		body, err := json.Marshal(map[string]any{"logs": items, "labels": []string{"a", "b"}})
+		if err != nil {
+			return nil, err
+		}
+		return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+	}
+
+	tool := MetoroTools{
+		Name:                      "test_tool",
+		Handler:                   handler,
+		ResponseGuard:             NewToolResponseGuard(nil, ToolResponseGuardOptions{MaxTokens: maxTokens}),
+		TruncateOversizedResponse: true,
+	}
+	return tool.WrappedHandler().(func(context.Context, truncationTestArgs) (*mcpgolang.ToolResponse, error))
+}
+
+func decodeTruncatedResponse(t *testing.T, response *mcpgolang.ToolResponse) ([]int, *responseTruncationNote) {
+	t.Helper()
+
+	var body struct {
+		Logs []struct {
+			Index int `json:"index"`
+		} `json:"logs"`
+	}
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &body); err != nil {
+		t.Fatalf("failed to decode truncated body: %v", err)
+	}
+	indexes := make([]int, len(body.Logs))
+	for i, log := range body.Logs {
+		indexes[i] = log.Index
+	}
+
+	if len(response.Content) < 2 {
+		return indexes, nil
+	}
+	var note responseTruncationNote
+	if err := json.Unmarshal([]byte(response.Content[1].TextContent.Text), &note); err != nil {
+		t.Fatalf("failed to decode truncation note: %v", err)
+	}
+	return indexes, &note
+}
+
+func TestTruncateOversizedResponseReturnsNoteAndCursor(t *testing.T) {
+	wrapped := newTruncationTestTool(40, 1000)
+
+	response, err := wrapped(context.Background(), truncationTestArgs{})
+	if err != nil {
+		t.Fatalf("expected truncated response, got error %v", err)
+	}
+
+	indexes, note := decodeTruncatedResponse(t, response)
+	if note == nil || !note.Truncated {
+		t.Fatalf("expected truncation note, got %+v", response.Content)
+	}
+	if len(indexes) == 0 || len(indexes) >= 40 {
+		t.Fatalf("expected some but not all items, got %d", len(indexes))
+	}
+	if note.Path != "/logs" || note.ReturnedItems != len(indexes) || note.RemainingItems != 40-len(indexes) {
+		t.Fatalf("unexpected note %+v", note)
+	}
+
+	seen := len(indexes)
+	cursor := note.ContinuationCursor
+	for cursor != "" {
+		response, err = wrapped(context.Background(), truncationTestArgs{ResponseContinuationArgs: ResponseContinuationArgs{ContinuationCursor: cursor}})
+		if err != nil {
+			t.Fatalf("expected continuation response, got error %v", err)
+		}
+		indexes, note = decodeTruncatedResponse(t, response)
+		if len(indexes) == 0 || indexes[0] != seen {
+			t.Fatalf("expected continuation to start at %d, got %v", seen, indexes)
+		}
+		seen += len(indexes)
+		cursor = ""
+		if note != nil {
+			cursor = note.ContinuationCursor
+		}
+	}
+	if seen != 40 {
+		t.Fatalf("expected to page through all 40 items, got %d", seen)
+	}
+}
+
+func TestTruncateOversizedResponseLeavesSmallResponsesUntouched(t *testing.T) {
+	wrapped := newTruncationTestTool(2, 1000)
+
+	response, err := wrapped(context.Background(), truncationTestArgs{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	indexes, note := decodeTruncatedResponse(t, response)
+	if note != nil || len(indexes) != 2 {
+		t.Fatalf("expected untouched response, got %d items and note %+v", len(indexes), note)
+	}
+}
+
+func TestTruncateOversizedResponseFallsBackToGuardError(t *testing.T) {
+	wrapped := newTruncationTestTool(40, 20)
+
+	response, err := wrapped(context.Background(), truncationTestArgs{})
+	if err == nil || err.Error() != toolResponseTooLargeErrorMessage {
+		t.Fatalf("expected too large error, got %v", err)
+	}
+	if response != nil {
+		t.Fatalf("expected nil response when nothing fits")
+	}
+}
+
+func TestTruncateOversizedResponseRejectsForeignCursor(t *testing.T) {
+	wrapped := newTruncationTestTool(40, 1000)
+	cursor := encodeContinuationCursor(responseContinuationCursor{Tool: "other_tool", Path: "/logs", Offset: 3})
+
+	_, err := wrapped(context.Background(), truncationTestArgs{ResponseContinuationArgs: ResponseContinuationArgs{ContinuationCursor: cursor}})
+	if err == nil || !strings.Contains(err.Error(), "other_tool") {
+		t.Fatalf("expected cursor tool mismatch error, got %v", err)
+	}
+
+	_, err = wrapped(context.Background(), truncationTestArgs{ResponseContinuationArgs: ResponseContinuationArgs{ContinuationCursor: "not a cursor"}})
+	if err == nil {
+		t.Fatalf("expected invalid cursor error")
+	}
+}
+
+func TestFindTruncatableArraysPrefersLargestArray(t *testing.T) {
+	var document any
+	raw := fmt.Sprintf(`{"small":[1,2],"nested":{"items":[%q,%q,%q]}}`, strings.Repeat("a", 50), "b", "c")
+	if err := json.Unmarshal([]byte(raw), &document); err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+
+	candidates := findTruncatableArrays(document)
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(candidates))
+	}
+	if formatJSONPointer(candidates[0].path) != "/nested/items" {
+		t.Fatalf("expected /nested/items first, got %s", formatJSONPointer(candidates[0].path))
+	}
+}
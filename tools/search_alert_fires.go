@@ -0,0 +1,345 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/utils"
+)
+
+const (
+	defaultAlertFiresTimelineLimit = 100
+	maxAlertFiresTimelineLimit     = 1000
+	// maxConcurrentAlertFireRequests bounds how many alerts the fires are fetched for at the same time.
+	maxConcurrentAlertFireRequests = 8
+)
+
+type SearchAlertFiresHandlerArgs struct {
+	TimeConfig  utils.TimeConfig `json:"time_config" jsonschema:"required,description=The time period to get alert fires for. e.g. if you want to get the alert fires of the last 12 hours you would set time_period=12 and time_window=Hours. You can also set an absoulute time range by setting start_time and end_time"`
+	Environment string           `json:"environment,omitempty" jsonschema:"description=Optional environment to only get the fires of the alerts of e.g. prod. Alerts whose query filters on other environments are left out and alerts whose query doesn't filter on the environment are kept. Use get_environments to get the available environments."`
+	Service     string           `json:"service,omitempty" jsonschema:"description=Optional service name to only get the fires of the alerts of. Alerts whose query filters on other services are left out and alerts whose query doesn't filter on the service are kept. Use get_services to get the available services."`
+	AlertName   string           `json:"alert_name,omitempty" jsonschema:"description=Optional case insensitive pattern of the names of the alerts to get the fires of. * matches any characters e.g. checkout*latency. Without * every alert whose name contains the pattern matches."`
+	Limit       int              `json:"limit,omitempty" jsonschema:"description=Optional maximum number of fires in the timeline. The most recent fires are kept. Defaults to 100 and can be at most 1000. The counts of the alerts always cover all fires."`
+}
+
+// alertFiresResponse is the response of alertFires.
+type alertFiresResponse struct {
+	AlertFires []alertFire `json:"alertFires"`
+}
+
+type alertFire struct {
+	Uuid      string `json:"uuid"`
+	AlertId   string `json:"alertId"`
+	AlertName string `json:"alertName"`
+	// StartTime is when the alert fired in milliseconds since epoch
+	StartTime int64 `json:"startTime"`
+	// EndTime is when the alert recovered in milliseconds since epoch. It is not set while the alert is still firing
+	EndTime *int64 `json:"endTime,omitempty"`
+	// Environment and ServiceName are only shown in the timeline, they are not set for every fire
+	Environment string `json:"environment,omitempty"`
+	ServiceName string `json:"serviceName,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+type searchAlertFiresResponse struct {
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	AlertsMatched int    `json:"alertsMatched"`
+	AlertsFired   int    `json:"alertsFired"`
+	TotalFires    int    `json:"totalFires"`
+	// Alerts are ranked from the noisiest alert, the one that fired most often, to the quietest.
+	Alerts            []alertFireStats `json:"alerts"`
+	Timeline          []alertFireEvent `json:"timeline"`
+	TimelineTruncated bool             `json:"timelineTruncated,omitempty"`
+	// Errors lists the alerts whose fires couldn't be fetched. The rest of the response covers the other alerts.
+	Errors []alertFiresError `json:"errors,omitempty"`
+}
+
+type alertFireStats struct {
+	Rank                 int    `json:"rank"`
+	AlertId              string `json:"alertId"`
+	AlertName            string `json:"alertName"`
+	Fires                int    `json:"fires"`
+	Ongoing              int    `json:"ongoing,omitempty"`
+	TotalFireTimeSeconds int64  `json:"totalFireTimeSeconds"`
+	// MeanTimeToResolveSeconds only covers the resolved fires and is not set when none resolved.
+	MeanTimeToResolveSeconds *int64 `json:"meanTimeToResolveSeconds,omitempty"`
+}
+
+// alertFireEvent is a fire of the timeline. Ongoing fires end at the end of the time period.
+type alertFireEvent struct {
+	AlertId     string `json:"alertId"`
+	AlertName   string `json:"alertName"`
+	Environment string `json:"environment,omitempty"`
+	ServiceName string `json:"serviceName,omitempty"`
+	Message     string `json:"message,omitempty"`
+	alertFireInterval
+}
+
+type alertFiresError struct {
+	AlertId   string `json:"alertId"`
+	AlertName string `json:"alertName"`
+	Error     string `json:"error"`
+}
+
+func SearchAlertFiresHandler(ctx context.Context, arguments SearchAlertFiresHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	startTime, endTime, err := utils.CalculateTimeRange(arguments.TimeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating time range: %v", err)
+	}
+	limit := arguments.Limit
+	if limit == 0 {
+		limit = defaultAlertFiresTimelineLimit
+	}
+	if limit < 0 || limit > maxAlertFiresTimelineLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxAlertFiresTimelineLimit)
+	}
+	namePattern, err := alertNamePattern(arguments.AlertName)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := searchAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []searchedAlert
+	for _, alert := range alerts {
+		if namePattern != nil && !namePattern.MatchString(alert.Metadata.Name) {
+			continue
+		}
+		if !alertQueryMatches(alert, environmentAttributeKey, arguments.Environment) || !alertQueryMatches(alert, serviceAttributeKey, arguments.Service) {
+			continue
+		}
+		matched = append(matched, alert)
+	}
+
+	fires, errors := getAlertsFires(ctx, matched, startTime, endTime)
+	response := aggregateAlertFires(matched, fires, endTime*1000, limit)
+	response.Start = time.Unix(startTime, 0).UTC().Format(time.RFC3339)
+	response.End = time.Unix(endTime, 0).UTC().Format(time.RFC3339)
+	response.Errors = errors
+	if len(matched) > 0 && len(errors) == len(matched) {
+		return nil, fmt.Errorf("error getting alert fires: %s", errors[0].Error)
+	}
+
+	result, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling alert fires: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(result))), nil
+}
+
+// alertNamePattern compiles the alert_name argument into a case insensitive regular expression. Patterns without *
+// match names containing them.
+func alertNamePattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, nil
+	}
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expression := strings.Join(parts, ".*")
+	if strings.Contains(pattern, "*") {
+		expression = "^" + expression + "$"
+	}
+	compiled, err := regexp.Compile("(?i)" + expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alert_name pattern: %v", err)
+	}
+	return compiled, nil
+}
+
+// getAlertsFires fetches the fires of every alert concurrently. The alerts whose fires couldn't be fetched are returned
+// as errors in the order of the alerts.
+func getAlertsFires(ctx context.Context, alerts []searchedAlert, startTime int64, endTime int64) ([]alertFire, []alertFiresError) {
+	results := make([][]alertFire, len(alerts))
+	failures := make([]error, len(alerts))
+	semaphore := make(chan struct{}, maxConcurrentAlertFireRequests)
+	var wg sync.WaitGroup
+	for i, alert := range alerts {
+		wg.Add(1)
+		go func(i int, alertId string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			body, err := getAlertFiresMetoroCall(ctx, alertId, startTime, endTime)
+			if err != nil {
+				failures[i] = err
+				return
+			}
+			var response alertFiresResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				failures[i] = fmt.Errorf("error unmarshaling alert fires: %v", err)
+				return
+			}
+			results[i] = response.AlertFires
+		}(i, alert.Metadata.Id)
+	}
+	wg.Wait()
+
+	var fires []alertFire
+	var errors []alertFiresError
+	for i, alert := range alerts {
+		if failures[i] != nil {
+			errors = append(errors, alertFiresError{AlertId: alert.Metadata.Id, AlertName: alert.Metadata.Name, Error: failures[i].Error()})
+			continue
+		}
+		for _, fire := range results[i] {
+			// The fires are attributed to the alert they were fetched for.
+			fire.AlertId = alert.Metadata.Id
+			if fire.AlertName == "" {
+				fire.AlertName = alert.Metadata.Name
+			}
+			fires = append(fires, fire)
+		}
+	}
+	return fires, errors
+}
+
+var (
+	alertQuerySelector = regexp.MustCompile(`\{[^{}]*\}`)
+	alertQueryMatcher  = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_.]*)\s*(=~|!~|!=|==|=)\s*"((?:[^"\\]|\\.)*)"`)
+)
+
+func environmentAttributeKey(key string) bool {
+	return key == "environment"
+}
+
+func serviceAttributeKey(key string) bool {
+	return key == "service.name" || key == "service_name" || strings.HasSuffix(key, ".service.name")
+}
+
+// alertQueryMatches reports whether the MetoroQL query of the alert can match the value of the attribute. The alert
+// matches when any selector of the query either doesn't filter on the attribute or its filters on the attribute all
+// accept the value, so alerts that cover every environment or service are kept. Empty values match every alert.
+func alertQueryMatches(alert searchedAlert, isKey func(string) bool, value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return true
+	}
+	selectors := alertQuerySelector.FindAllString(alert.query(), -1)
+	if len(selectors) == 0 {
+		return true
+	}
+	for _, selector := range selectors {
+		accepted := true
+		for _, matcher := range alertQueryMatcher.FindAllStringSubmatch(selector, -1) {
+			if isKey(matcher[1]) && !alertQueryMatcherAccepts(matcher[2], matcher[3], value) {
+				accepted = false
+				break
+			}
+		}
+		if accepted {
+			return true
+		}
+	}
+	return false
+}
+
+func alertQueryMatcherAccepts(operator string, expected string, value string) bool {
+	switch operator {
+	case "=", "==":
+		return strings.EqualFold(expected, value)
+	case "!=":
+		return !strings.EqualFold(expected, value)
+	}
+	pattern, err := regexp.Compile("(?i)^(?:" + expected + ")$")
+	if err != nil {
+		// Keep alerts whose filter can't be understood rather than dropping their fires.
+		return true
+	}
+	return pattern.MatchString(value) == (operator == "=~")
+}
+
+// aggregateAlertFires merges the fires of all alerts into a timeline ordered by when they fired and counts them per
+// alert. Fires that haven't resolved are ongoing until the end time, which is in milliseconds. Only the most recent
+// limit fires are kept in the timeline.
+func aggregateAlertFires(alerts []searchedAlert, fires []alertFire, endTime int64, limit int) searchAlertFiresResponse {
+	response := searchAlertFiresResponse{
+		AlertsMatched: len(alerts),
+		TotalFires:    len(fires),
+		Alerts:        []alertFireStats{},
+		Timeline:      []alertFireEvent{},
+	}
+
+	statsByAlert := map[string]*alertFireStats{}
+	resolveTime := map[string]int64{}
+	var order []string
+	for _, fire := range fires {
+		start := timeseriesMillis(fire.StartTime)
+		end, ongoing := endTime, fire.EndTime == nil
+		if !ongoing {
+			end = timeseriesMillis(*fire.EndTime)
+		}
+		end = max(end, start)
+		interval := alertFireInterval{
+			Start:           formatTimeseriesTime(start),
+			End:             formatTimeseriesTime(end),
+			DurationSeconds: (end - start) / 1000,
+			Ongoing:         ongoing,
+			start:           start,
+			end:             end,
+		}
+		response.Timeline = append(response.Timeline, alertFireEvent{
+			AlertId:           fire.AlertId,
+			AlertName:         fire.AlertName,
+			Environment:       fire.Environment,
+			ServiceName:       fire.ServiceName,
+			Message:           fire.Message,
+			alertFireInterval: interval,
+		})
+
+		stats, ok := statsByAlert[fire.AlertId]
+		if !ok {
+			stats = &alertFireStats{AlertId: fire.AlertId, AlertName: fire.AlertName}
+			statsByAlert[fire.AlertId] = stats
+			order = append(order, fire.AlertId)
+		}
+		stats.Fires++
+		stats.TotalFireTimeSeconds += interval.DurationSeconds
+		if ongoing {
+			stats.Ongoing++
+		} else {
+			resolveTime[fire.AlertId] += interval.DurationSeconds
+		}
+	}
+
+	for _, alertId := range order {
+		stats := statsByAlert[alertId]
+		if resolved := stats.Fires - stats.Ongoing; resolved > 0 {
+			mean := resolveTime[alertId] / int64(resolved)
+			stats.MeanTimeToResolveSeconds = &mean
+		}
+		response.Alerts = append(response.Alerts, *stats)
+	}
+	sort.SliceStable(response.Alerts, func(i, j int) bool {
+		if response.Alerts[i].Fires != response.Alerts[j].Fires {
+			return response.Alerts[i].Fires > response.Alerts[j].Fires
+		}
+		return response.Alerts[i].TotalFireTimeSeconds > response.Alerts[j].TotalFireTimeSeconds
+	})
+	for i := range response.Alerts {
+		response.Alerts[i].Rank = i + 1
+	}
+	response.AlertsFired = len(response.Alerts)
+
+	sort.SliceStable(response.Timeline, func(i, j int) bool {
+		return response.Timeline[i].start < response.Timeline[j].start
+	})
+	if len(response.Timeline) > limit {
+		response.Timeline = response.Timeline[len(response.Timeline)-limit:]
+		response.TimelineTruncated = true
+	}
+	return response
+}
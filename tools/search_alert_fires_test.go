@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAlertNamePattern(t *testing.T) {
+	matches := map[string][]string{
+		"latency":          {"Checkout Latency", "latency p99"},
+		"checkout*latency": {"Checkout p99 Latency", "checkoutlatency"},
+		"*errors":          {"Payment errors"},
+		"a.b":              {"a.b alert"},
+	}
+	for pattern, names := range matches {
+		compiled, err := alertNamePattern(pattern)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		for _, name := range names {
+			if !compiled.MatchString(name) {
+				t.Fatalf("expected %q to match %q", pattern, name)
+			}
+		}
+	}
+	compiled, _ := alertNamePattern("checkout*latency")
+	if compiled.MatchString("checkout latency alert") {
+		t.Fatalf("expected patterns with * to match the whole name")
+	}
+	compiled, _ = alertNamePattern("a.b")
+	if compiled.MatchString("axb") {
+		t.Fatalf("expected the pattern to be matched literally")
+	}
+	if compiled, _ := alertNamePattern(" "); compiled != nil {
+		t.Fatalf("expected no pattern")
+	}
+}
+
+func TestAlertQueryMatches(t *testing.T) {
+	alert := func(query string) searchedAlert {
+		var alert searchedAlert
+		alert.Timeseries.Expression.MetoroQLTimeseries = &struct {
+			Query string `json:"query"`
+		}{Query: query}
+		return alert
+	}
+	cases := []struct {
+		query    string
+		value    string
+		expected bool
+	}{
+		{`sum(errors{service.name="checkout", environment="prod"})`, "checkout", true},
+		{`sum(errors{server.service.name="payment"})`, "checkout", false},
+		{`sum(errors{service_name!="checkout"})`, "checkout", false},
+		{`sum(errors{service.name=~"check.*"})`, "Checkout", true},
+		{`sum(errors{service.name!~"check.*"})`, "checkout", false},
+		{`sum(errors{service.name="payment"}) / sum(requests{service.name="checkout"})`, "checkout", true},
+		{`sum(errors{environment="prod"})`, "checkout", true},
+		{`sum(errors{service.name="payment"})`, "", true},
+	}
+	for _, c := range cases {
+		if matches := alertQueryMatches(alert(c.query), serviceAttributeKey, c.value); matches != c.expected {
+			t.Fatalf("expected %v for %q in %s, got %v", c.expected, c.value, c.query, matches)
+		}
+	}
+	if !alertQueryMatches(searchedAlert{}, environmentAttributeKey, "prod") {
+		t.Fatalf("expected alerts without a query to match")
+	}
+}
+
+func TestAggregateAlertFires(t *testing.T) {
+	start := anomalyTestWindowStart * 1000
+	minutes := func(minutes int64) int64 { return start + minutes*60*1000 }
+	end := func(minutes int64) *int64 {
+		value := start + minutes*60*1000
+		return &value
+	}
+	fires := []alertFire{
+		{AlertId: "cpu", AlertName: "CPU", StartTime: minutes(30), EndTime: end(40)},
+		{AlertId: "latency", AlertName: "Latency", StartTime: minutes(0), EndTime: end(20)},
+		{AlertId: "cpu", AlertName: "CPU", StartTime: minutes(10), EndTime: end(30)},
+		{AlertId: "cpu", AlertName: "CPU", StartTime: minutes(50)},
+		{AlertId: "latency", AlertName: "Latency", StartTime: minutes(45), EndTime: end(55)},
+	}
+	response := aggregateAlertFires([]searchedAlert{{}, {}, {}}, fires, minutes(60), 3)
+
+	if response.AlertsMatched != 3 || response.AlertsFired != 2 || response.TotalFires != 5 {
+		t.Fatalf("unexpected counts %+v", response)
+	}
+	cpu, latency := response.Alerts[0], response.Alerts[1]
+	if cpu.Rank != 1 || cpu.AlertId != "cpu" || cpu.Fires != 3 || cpu.Ongoing != 1 || cpu.TotalFireTimeSeconds != 2400 || *cpu.MeanTimeToResolveSeconds != 900 {
+		t.Fatalf("unexpected cpu stats %+v", cpu)
+	}
+	if latency.Rank != 2 || latency.Fires != 2 || *latency.MeanTimeToResolveSeconds != 900 {
+		t.Fatalf("unexpected latency stats %+v", latency)
+	}
+
+	if !response.TimelineTruncated || len(response.Timeline) != 3 {
+		t.Fatalf("expected the 3 most recent fires, got %+v", response.Timeline)
+	}
+	if response.Timeline[0].Start != "2026-02-19T10:30:00Z" || response.Timeline[1].AlertId != "latency" || response.Timeline[2].AlertId != "cpu" {
+		t.Fatalf("unexpected timeline %+v", response.Timeline)
+	}
+	if ongoing := response.Timeline[2]; !ongoing.Ongoing || ongoing.End != "2026-02-19T11:00:00Z" || ongoing.DurationSeconds != 600 {
+		t.Fatalf("expected the ongoing fire to last until the end, got %+v", ongoing)
+	}
+}
+
+func TestSearchAlertFiresHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/searchAlerts":
+			_, _ = w.Write([]byte(`{"alerts":[
+				{"metadata":{"id":"a1","name":"Checkout latency"},"timeseries":{"expression":{"metoroQLTimeseries":{"query":"max(latency{environment=\"prod\"})","bucketSize":60}},"evaluationRules":[]}},
+				{"metadata":{"id":"a2","name":"Checkout errors"},"timeseries":{"expression":{},"evaluationRules":[]}},
+				{"metadata":{"id":"a3","name":"Checkout saturation"},"timeseries":{"expression":{"metoroQLTimeseries":{"query":"max(cpu{environment=~\"prod|staging\"})","bucketSize":60}},"evaluationRules":[]}},
+				{"metadata":{"id":"a4","name":"Payment errors"},"timeseries":{"expression":{},"evaluationRules":[]}},
+				{"metadata":{"id":"a5","name":"Checkout staging latency"},"timeseries":{"expression":{"metoroQLTimeseries":{"query":"max(latency{environment=\"staging\"})","bucketSize":60}},"evaluationRules":[]}}]}`))
+		case "/api/v1/alertFires":
+			if r.URL.Query().Get("startTime") != "1771495200" || r.URL.Query().Get("endTime") != "1771538400" {
+				t.Fatalf("unexpected time range %s", r.URL.RawQuery)
+			}
+			switch r.URL.Query().Get("alertId") {
+			case "a1":
+				_, _ = w.Write([]byte(`{"alertFires":[
+					{"uuid":"f1","startTime":1771495200000,"endTime":1771495800000,"environment":"prod","serviceName":"checkout"},
+					{"uuid":"f2","startTime":1771499000000,"endTime":1771499600000,"environment":"staging","serviceName":"checkout"}]}`))
+			case "a2":
+				_, _ = w.Write([]byte(`{"alertFires":[{"uuid":"f3","startTime":1771496000000,"environment":"Prod","serviceName":"checkout"}]}`))
+			case "a3":
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				t.Fatalf("unexpected alert %s", r.URL.Query().Get("alertId"))
+			}
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	arguments := SearchAlertFiresHandlerArgs{
+		TimeConfig:  absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T22:00:00Z"),
+		Environment: "prod",
+		AlertName:   "checkout",
+	}
+	response, err := SearchAlertFiresHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var result searchAlertFiresResponse
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if result.AlertsMatched != 3 || result.TotalFires != 3 || len(result.Errors) != 1 || result.Errors[0].AlertId != "a3" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.Timeline[0].AlertName != "Checkout latency" || result.Timeline[1].AlertId != "a2" || !result.Timeline[1].Ongoing || result.Timeline[1].End != "2026-02-19T22:00:00Z" {
+		t.Fatalf("unexpected timeline %+v", result.Timeline)
+	}
+
+	arguments.Limit = 1001
+	if _, err := SearchAlertFiresHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error for too large limit")
+	}
+}
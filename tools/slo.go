@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+const (
+	defaultSLOWindowDays = 30
+	maxSLOWindowDays     = 30
+
+	sloSeverityPage   = "page"
+	sloSeverityTicket = "ticket"
+
+	// The SLO window is fetched in hourly buckets for the attainment and the last 3 days in 5 minute buckets for the
+	// burn rate windows.
+	sloWindowBucketSize = int64(3600)
+	sloBurnBucketSize   = int64(300)
+	sloBurnLookback     = 3 * 24 * time.Hour
+)
+
+// SLODefinition defines an SLO as the share of good events of all events, e.g. the requests that succeeded within
+// 500ms of all requests.
+type SLODefinition struct {
+	Name       string                        `json:"name" jsonschema:"required,description=The name of the SLO e.g. Checkout availability"`
+	Good       model.SingleTimeseriesRequest `json:"good" jsonschema:"required,description=The timeseries counting the good events e.g. the count of traces of the checkout service without errors. Same as a timeseries of get_timeseries_data but splits are not supported and the bucket size is ignored."`
+	Total      model.SingleTimeseriesRequest `json:"total" jsonschema:"required,description=The timeseries counting all events e.g. the count of all traces of the checkout service. Same as a timeseries of get_timeseries_data but splits are not supported and the bucket size is ignored."`
+	Target     float64                       `json:"target" jsonschema:"required,description=The percentage of events that have to be good e.g. 99.9"`
+	WindowDays int                           `json:"window_days,omitempty" jsonschema:"description=Optional number of days of the rolling SLO window. Defaults to 30 and can be at most 30."`
+}
+
+// sloBurnRateAlert is a multi-window multi-burn-rate alert as recommended by the Google SRE workbook. It fires when
+// both the long and the short window burn the error budget faster than the burn rate. The long window makes sure
+// enough of the budget was spent and the short window that it is still being spent.
+type sloBurnRateAlert struct {
+	Severity    string
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	// BudgetShare is the share of the error budget spent in the long window at the burn rate.
+	BudgetShare float64
+}
+
+var sloBurnRateAlerts = []sloBurnRateAlert{
+	{Severity: sloSeverityPage, LongWindow: time.Hour, ShortWindow: 5 * time.Minute, BudgetShare: 0.02},
+	{Severity: sloSeverityPage, LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, BudgetShare: 0.05},
+	{Severity: sloSeverityTicket, LongWindow: 24 * time.Hour, ShortWindow: 2 * time.Hour, BudgetShare: 0.1},
+	{Severity: sloSeverityTicket, LongWindow: 3 * 24 * time.Hour, ShortWindow: 6 * time.Hour, BudgetShare: 0.1},
+}
+
+// burnRate returns the burn rate of the alert for the SLO window, which is 14.4, 6, 3 and 1 for a 30 day window.
+func (alert sloBurnRateAlert) burnRate(windowDays int) float64 {
+	return alert.BudgetShare * float64(windowDays) * 24 * float64(time.Hour) / float64(alert.LongWindow)
+}
+
+type sloBucket struct {
+	// Time is the start of the bucket in milliseconds since the epoch.
+	Time  int64
+	Good  float64
+	Total float64
+}
+
+// normalizeSLODefinition validates the SLO and fills in the default window.
+func normalizeSLODefinition(slo SLODefinition) (SLODefinition, error) {
+	if slo.Name == "" {
+		return slo, fmt.Errorf("name is required")
+	}
+	if slo.Target <= 0 || slo.Target >= 100 {
+		return slo, fmt.Errorf("target must be a percentage between 0 and 100 e.g. 99.9")
+	}
+	if slo.WindowDays == 0 {
+		slo.WindowDays = defaultSLOWindowDays
+	}
+	if slo.WindowDays < 0 || slo.WindowDays > maxSLOWindowDays {
+		return slo, fmt.Errorf("window_days must be between 1 and %d", maxSLOWindowDays)
+	}
+	if len(slo.Good.Splits) > 0 || len(slo.Total.Splits) > 0 {
+		return slo, fmt.Errorf("splits are not supported for SLOs, use filters to select the events instead")
+	}
+	return slo, nil
+}
+
+// sloTimeseries returns the good and total timeseries of the SLO as timeseries a and b with the bucket size.
+func sloTimeseries(slo SLODefinition, bucketSize int64) []model.SingleTimeseriesRequest {
+	good, total := slo.Good, slo.Total
+	good.FormulaIdentifier, total.FormulaIdentifier = "a", "b"
+	good.ShouldNotReturn, total.ShouldNotReturn = false, false
+	good.BucketSize, total.BucketSize = bucketSize, bucketSize
+	return []model.SingleTimeseriesRequest{good, total}
+}
+
+// getSLOBuckets returns the good and total events of every bucket of the time range.
+func getSLOBuckets(ctx context.Context, slo SLODefinition, startTime int64, endTime int64, bucketSize int64) ([]sloBucket, error) {
+	body, err := getMultiMetricMetoroCall(ctx, model.GetMultiMetricRequest{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Metrics:   convertTimeseriesToAPITimeseries(sloTimeseries(slo, bucketSize), startTime, endTime),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting metric: %v", err)
+	}
+	series, err := parseMetricsResponseSeries(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) != 2 {
+		return nil, fmt.Errorf("error getting SLO events: expected the good and total timeseries, got %d timeseries", len(series))
+	}
+	return mergeSLOBuckets(series[0], series[1]), nil
+}
+
+// mergeSLOBuckets adds up the series of the good and the total timeseries per bucket.
+func mergeSLOBuckets(good []metricSeries, total []metricSeries) []sloBucket {
+	buckets := map[int64]*sloBucket{}
+	bucket := func(time int64) *sloBucket {
+		if buckets[time] == nil {
+			buckets[time] = &sloBucket{Time: time}
+		}
+		return buckets[time]
+	}
+	for _, series := range good {
+		for _, point := range metricSeriesPoints(series) {
+			bucket(point.Time).Good += point.Value
+		}
+	}
+	for _, series := range total {
+		for _, point := range metricSeriesPoints(series) {
+			bucket(point.Time).Total += point.Value
+		}
+	}
+
+	merged := make([]sloBucket, 0, len(buckets))
+	for _, value := range buckets {
+		merged = append(merged, *value)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Time < merged[j].Time
+	})
+	return merged
+}
+
+// sloBurnRate returns how many times faster than allowed the error budget was spent in the buckets since the time.
+// It is nil when there were no events.
+func sloBurnRate(buckets []sloBucket, since int64, target float64) *float64 {
+	var good, total float64
+	for _, bucket := range buckets {
+		if bucket.Time >= since {
+			good += bucket.Good
+			total += bucket.Total
+		}
+	}
+	if total <= 0 {
+		return nil
+	}
+	burnRate := roundTimeseriesValue(math.Max(0, total-good) / total / (1 - target/100))
+	return &burnRate
+}
+
+type sloStatusResponse struct {
+	Name       string  `json:"name"`
+	Target     float64 `json:"target"`
+	WindowDays int     `json:"windowDays"`
+	Start      string  `json:"start"`
+	End        string  `json:"end"`
+	GoodEvents float64 `json:"goodEvents"`
+	// TotalEvents is 0 when there were no events in the window, in which case the attainment is not set.
+	TotalEvents float64  `json:"totalEvents"`
+	Attainment  *float64 `json:"attainment,omitempty"`
+	// AllowedBadEvents is the error budget, the number of bad events the SLO allows for the events of the window.
+	AllowedBadEvents float64 `json:"allowedBadEvents"`
+	BadEvents        float64 `json:"badEvents"`
+	// ErrorBudgetRemaining is the percentage of the error budget that is left. It is negative when the SLO is breached.
+	ErrorBudgetRemaining *float64              `json:"errorBudgetRemaining,omitempty"`
+	BurnRates            []sloWindowBurnRate   `json:"burnRates"`
+	Alerts               []sloBurnRateAlertRun `json:"alerts"`
+}
+
+type sloWindowBurnRate struct {
+	Window   string   `json:"window"`
+	BurnRate *float64 `json:"burnRate"`
+}
+
+type sloBurnRateAlertRun struct {
+	Severity          string   `json:"severity"`
+	LongWindow        string   `json:"longWindow"`
+	ShortWindow       string   `json:"shortWindow"`
+	BurnRateThreshold float64  `json:"burnRateThreshold"`
+	LongBurnRate      *float64 `json:"longBurnRate"`
+	ShortBurnRate     *float64 `json:"shortBurnRate"`
+	Firing            bool     `json:"firing"`
+}
+
+// computeSLOStatus computes the attainment of the SLO from the buckets of the whole window and the burn rates from the
+// recent buckets. The end time is in seconds.
+func computeSLOStatus(slo SLODefinition, windowBuckets []sloBucket, burnBuckets []sloBucket, startTime int64, endTime int64) sloStatusResponse {
+	status := sloStatusResponse{
+		Name:       slo.Name,
+		Target:     slo.Target,
+		WindowDays: slo.WindowDays,
+		Start:      time.Unix(startTime, 0).UTC().Format(time.RFC3339),
+		End:        time.Unix(endTime, 0).UTC().Format(time.RFC3339),
+		BurnRates:  []sloWindowBurnRate{},
+		Alerts:     []sloBurnRateAlertRun{},
+	}
+	for _, bucket := range windowBuckets {
+		status.GoodEvents += bucket.Good
+		status.TotalEvents += bucket.Total
+	}
+	status.BadEvents = math.Max(0, status.TotalEvents-status.GoodEvents)
+	status.AllowedBadEvents = roundTimeseriesValue(status.TotalEvents * (1 - slo.Target/100))
+	if status.TotalEvents > 0 {
+		attainment := roundTimeseriesValue(status.GoodEvents / status.TotalEvents * 100)
+		status.Attainment = &attainment
+		remaining := math.Round((1-status.BadEvents/(status.TotalEvents*(1-slo.Target/100)))*10000) / 100
+		status.ErrorBudgetRemaining = &remaining
+	}
+
+	burnRateSince := func(window time.Duration) *float64 {
+		return sloBurnRate(burnBuckets, (endTime*1000)-window.Milliseconds(), slo.Target)
+	}
+	var windows []time.Duration
+	for _, alert := range sloBurnRateAlerts {
+		windows = append(windows, alert.ShortWindow, alert.LongWindow)
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i] < windows[j]
+	})
+	for i, window := range windows {
+		if i > 0 && windows[i-1] == window {
+			continue
+		}
+		status.BurnRates = append(status.BurnRates, sloWindowBurnRate{Window: formatSLOWindow(window), BurnRate: burnRateSince(window)})
+	}
+
+	for _, alert := range sloBurnRateAlerts {
+		run := sloBurnRateAlertRun{
+			Severity:          alert.Severity,
+			LongWindow:        formatSLOWindow(alert.LongWindow),
+			ShortWindow:       formatSLOWindow(alert.ShortWindow),
+			BurnRateThreshold: roundTimeseriesValue(alert.burnRate(slo.WindowDays)),
+			LongBurnRate:      burnRateSince(alert.LongWindow),
+			ShortBurnRate:     burnRateSince(alert.ShortWindow),
+		}
+		run.Firing = run.LongBurnRate != nil && run.ShortBurnRate != nil &&
+			*run.LongBurnRate > run.BurnRateThreshold && *run.ShortBurnRate > run.BurnRateThreshold
+		status.Alerts = append(status.Alerts, run)
+	}
+	return status
+}
+
+// formatSLOWindow formats windows as 5m, 6h or 3d.
+func formatSLOWindow(window time.Duration) string {
+	switch {
+	case window%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	}
+}
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+func TestSLOBurnRateAlertBurnRates(t *testing.T) {
+	expected := []float64{14.4, 6, 3, 1}
+	for i, alert := range sloBurnRateAlerts {
+		if burnRate := roundTimeseriesValue(alert.burnRate(30)); burnRate != expected[i] {
+			t.Fatalf("expected burn rate %v for %s, got %v", expected[i], formatSLOWindow(alert.LongWindow), burnRate)
+		}
+	}
+	if burnRate := roundTimeseriesValue(sloBurnRateAlerts[0].burnRate(7)); burnRate != 3.36 {
+		t.Fatalf("expected the burn rate to scale with the window, got %v", burnRate)
+	}
+}
+
+func TestComputeSLOStatus(t *testing.T) {
+	slo := SLODefinition{Name: "Checkout availability", Target: 99.9, WindowDays: 30}
+	endTime := int64(1771495200)
+	windowBuckets := []sloBucket{
+		{Time: (endTime - 7200) * 1000, Good: 999, Total: 1000},
+		{Time: (endTime - 3600) * 1000, Good: 998, Total: 1000},
+	}
+	// 5 minute buckets of the last hour with 10 bad events in the last bucket.
+	var burnBuckets []sloBucket
+	for i := 12; i > 0; i-- {
+		bucket := sloBucket{Time: (endTime - int64(i)*300) * 1000, Good: 100, Total: 100}
+		if i == 1 {
+			bucket.Good = 90
+		}
+		burnBuckets = append(burnBuckets, bucket)
+	}
+
+	status := computeSLOStatus(slo, windowBuckets, burnBuckets, endTime-30*24*3600, endTime)
+	if status.TotalEvents != 2000 || status.BadEvents != 3 || status.AllowedBadEvents != 2 || *status.Attainment != 99.85 {
+		t.Fatalf("unexpected events %+v", status)
+	}
+	if *status.ErrorBudgetRemaining != -50 {
+		t.Fatalf("expected the budget to be overspent by half, got %v", *status.ErrorBudgetRemaining)
+	}
+	if status.BurnRates[0].Window != "5m" || *status.BurnRates[0].BurnRate != 100 || len(status.BurnRates) != 7 {
+		t.Fatalf("unexpected burn rates %+v", status.BurnRates)
+	}
+
+	page, slowPage := status.Alerts[0], status.Alerts[1]
+	if *page.LongBurnRate != 8.33333 || page.Firing {
+		t.Fatalf("expected the 1h alert not to fire, got %+v", page)
+	}
+	if *slowPage.ShortBurnRate != 16.6667 || !slowPage.Firing {
+		t.Fatalf("expected the 6h alert to fire, got %+v", slowPage)
+	}
+
+	empty := computeSLOStatus(slo, nil, nil, endTime-30*24*3600, endTime)
+	if empty.Attainment != nil || empty.ErrorBudgetRemaining != nil || empty.Alerts[0].LongBurnRate != nil || empty.Alerts[0].Firing {
+		t.Fatalf("expected no attainment without events, got %+v", empty)
+	}
+}
+
+func TestMergeSLOBuckets(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	good := []metricSeries{{Data: []metricDataPoint{{Time: 1771495200000, Value: value(9)}, {Time: 1771495500000, Value: nil}}}}
+	total := []metricSeries{
+		{Data: []metricDataPoint{{Time: 1771495200000, Value: value(6)}, {Time: 1771495500000, Value: value(5)}}},
+		{Data: []metricDataPoint{{Time: 1771495200000, Value: value(4)}}},
+	}
+
+	buckets := mergeSLOBuckets(good, total)
+	if len(buckets) != 2 || buckets[0] != (sloBucket{Time: 1771495200000, Good: 9, Total: 10}) || buckets[1] != (sloBucket{Time: 1771495500000, Total: 5}) {
+		t.Fatalf("unexpected buckets %+v", buckets)
+	}
+}
+
+func TestSLOBurnRateConditions(t *testing.T) {
+	slo := SLODefinition{Name: "Checkout availability", Target: 99.9, WindowDays: 30}
+	conditions := sloBurnRateConditions(slo, sloBurnRateAlerts[0])
+	if len(conditions) != 2 {
+		t.Fatalf("expected a sustained and a fast rule, got %+v", conditions)
+	}
+	sustained, fast := conditions[0].Static, conditions[1].Static
+	if sustained.Operators[0].Threshold != 0.0144 || sustained.PersistenceSettings.DatapointsToAlarm != 12 || sustained.PersistenceSettings.DatapointsInEvaluationWindow != 12 {
+		t.Fatalf("unexpected sustained rule %+v", sustained)
+	}
+	if fast.Operators[0].Threshold != 0.1728 || fast.PersistenceSettings.DatapointsToAlarm != 1 || fast.PersistenceSettings.DatapointsInEvaluationWindow != 12 {
+		t.Fatalf("unexpected fast rule %+v", fast)
+	}
+
+	// A loose SLO needs several buckets to spend the budget share of the long window.
+	slo.Target = 50
+	conditions = sloBurnRateConditions(slo, sloBurnRateAlerts[3])
+	if len(conditions) != 2 || conditions[1].Static.PersistenceSettings.DatapointsToAlarm != 7 || conditions[1].Static.Operators[0].Threshold != roundTimeseriesValue(6.0/7) {
+		t.Fatalf("unexpected rules %+v", conditions)
+	}
+	// The error ratio can't reach the fast threshold so only the sustained rule is kept.
+	slo.Target = 90
+	if conditions := sloBurnRateConditions(slo, sloBurnRateAlerts[0]); len(conditions) != 1 {
+		t.Fatalf("expected a single rule, got %+v", conditions)
+	}
+}
+
+func TestCreateSLOAlertsHandler(t *testing.T) {
+	var conversions []model.MetricSpecifiersRequest
+	var alerts []model.Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		requestBody, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":["service.name"]}`))
+		case "/api/v1/metoroql/convert/metricSpecifierToMetoroql":
+			var request model.MetricSpecifiersRequest
+			if err := json.Unmarshal(requestBody, &request); err != nil {
+				t.Fatalf("failed to decode conversion request: %v", err)
+			}
+			conversions = append(conversions, request)
+			_, _ = w.Write([]byte(`{"queries":["good","","total","error ratio"]}`))
+		case "/api/v1/searchAlerts":
+			_, _ = w.Write([]byte(`{"alerts":[{"metadata":{"id":"existing","name":"Payments"},"timeseries":{"evaluationRules":[{"actions":[{"type":"pagerDuty","pagerDutyDestination":{"serviceName":"payments"}}]}]}}]}`))
+		case "/api/v1/alerts/update":
+			var request model.CreateUpdateAlertRequest
+			if err := json.Unmarshal(requestBody, &request); err != nil {
+				t.Fatalf("failed to decode alert request: %v", err)
+			}
+			alerts = append(alerts, request.Alert)
+			_, _ = w.Write([]byte(`{"id":"alert"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	arguments := CreateSLOAlertsHandlerArgs{
+		SLODefinition: SLODefinition{
+			Name:   "Checkout availability",
+			Good:   model.SingleTimeseriesRequest{Type: model.Trace, Aggregation: model.AggregationCount},
+			Total:  model.SingleTimeseriesRequest{Type: model.Trace, Aggregation: model.AggregationCount},
+			Target: 99.9,
+		},
+		PageDestinations: []AlertDestination{{Type: "pagerduty", ServiceName: "checkout"}},
+	}
+	response, err := CreateSLOAlertsHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(alerts) != len(sloBurnRateAlerts) {
+		t.Fatalf("expected an alert per burn rate, got %d", len(alerts))
+	}
+	if conversions[0].Formulas[0].Formula != sloErrorRatioFormula || conversions[0].MetricSpecifiers[1].FormulaIdentifier != "b" || !conversions[0].MetricSpecifiers[0].ShouldNotReturn {
+		t.Fatalf("unexpected conversion request %+v", conversions[0])
+	}
+	if actions := alerts[0].Timeseries.EvaluationRules[0].Actions; len(actions) != 1 || *actions[0].PagerDutyDestination.ServiceName != "checkout" || actions[0].Type != "pagerDuty" {
+		t.Fatalf("expected the page alerts to notify the pagerduty service, got %+v", actions)
+	}
+	for _, alert := range alerts {
+		if query := alert.Timeseries.Expression.MetoroQLTimeseries.Query; query != "error ratio" {
+			t.Fatalf("expected the alerts to evaluate the query of the formula, got %q", query)
+		}
+	}
+	last := alerts[3]
+	if len(last.Timeseries.EvaluationRules[0].Actions) != 0 {
+		t.Fatalf("expected the ticket alerts not to notify, got %+v", last.Timeseries.EvaluationRules[0].Actions)
+	}
+	if last.Metadata.Name != "Checkout availability SLO burn rate 3d/6h" || last.Timeseries.Expression.MetoroQLTimeseries.BucketSize != 6*3600 || len(last.Timeseries.EvaluationRules) != 2 {
+		t.Fatalf("unexpected alert %+v", last)
+	}
+
+	var created []createdSLOAlert
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created[0].Severity != sloSeverityPage || created[3].Severity != sloSeverityTicket || string(created[0].Response) != `{"id":"alert"}` {
+		t.Fatalf("unexpected response %+v", created)
+	}
+
+	arguments.Target = 100
+	if _, err := CreateSLOAlertsHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error for a target of 100")
+	}
+}
+
+func TestCreateSLOAlertsHandlerReportsCreatedAlertsOnFailure(t *testing.T) {
+	var conversions, updates int
+	failConversion := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":["service.name"]}`))
+		case "/api/v1/metoroql/convert/metricSpecifierToMetoroql":
+			conversions++
+			if failConversion && conversions == 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"queries":["error ratio"]}`))
+		case "/api/v1/alerts/update":
+			updates++
+			if updates == 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	arguments := CreateSLOAlertsHandlerArgs{SLODefinition: SLODefinition{
+		Name:   "Checkout availability",
+		Good:   model.SingleTimeseriesRequest{Type: model.Trace, Aggregation: model.AggregationCount},
+		Total:  model.SingleTimeseriesRequest{Type: model.Trace, Aggregation: model.AggregationCount},
+		Target: 99.9,
+	}}
+	_, err := CreateSLOAlertsHandler(context.Background(), arguments)
+	if err == nil || !strings.Contains(err.Error(), "already created: Checkout availability SLO burn rate 1h/5m, Checkout availability SLO burn rate 6h/30m") {
+		t.Fatalf("expected the created alerts in the error, got %v", err)
+	}
+
+	conversions, updates = 0, 0
+	failConversion = true
+	if _, err := CreateSLOAlertsHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error when an alert can't be built")
+	}
+	if updates != 0 {
+		t.Fatalf("expected no alert to be created when one can't be built, got %d", updates)
+	}
+}
@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	compareToPreviousPeriod = "previous_period"
+	compareToOneDayAgo      = "1d"
+	compareToSevenDaysAgo   = "7d"
+)
+
+type timeseriesComparisonResponse struct {
+	CompareTo       string                     `json:"compareTo"`
+	Start           string                     `json:"start"`
+	End             string                     `json:"end"`
+	ComparisonStart string                     `json:"comparisonStart"`
+	ComparisonEnd   string                     `json:"comparisonEnd"`
+	Metrics         []timeseriesComparisonItem `json:"metrics"`
+}
+
+type timeseriesComparisonItem struct {
+	Name   string                 `json:"name"`
+	Series []timeseriesComparison `json:"series"`
+}
+
+// timeseriesComparison compares a split of the time period with the same split of the comparison period. Splits
+// that only exist in one of the periods are compared with nothing.
+type timeseriesComparison struct {
+	Attributes      map[string]string `json:"attributes,omitempty"`
+	Avg             *float64          `json:"avg"`
+	PreviousAvg     *float64          `json:"previousAvg"`
+	AvgDelta        *float64          `json:"avgDelta,omitempty"`
+	AvgPercentDelta *float64          `json:"avgPercentDelta,omitempty"`
+	// Summary and PreviousSummary are only set in summary mode, Data only in raw mode.
+	Summary         *timeseriesSummary          `json:"summary,omitempty"`
+	PreviousSummary *timeseriesSummary          `json:"previousSummary,omitempty"`
+	Data            []timeseriesComparisonPoint `json:"data,omitempty"`
+}
+
+// timeseriesComparisonPoint holds a bucket of the time period and the bucket of the comparison period that is the
+// offset earlier.
+type timeseriesComparisonPoint struct {
+	Time          int64    `json:"time"`
+	Value         *float64 `json:"value"`
+	PreviousValue *float64 `json:"previousValue"`
+	Delta         *float64 `json:"delta,omitempty"`
+	PercentDelta  *float64 `json:"percentDelta,omitempty"`
+}
+
+// parseCompareToOffset returns how far the comparison period is before the time period. Besides the named periods it
+// accepts offsets like 90m, 6h, 2d or 1w.
+func parseCompareToOffset(compareTo string, startTime int64, endTime int64) (time.Duration, error) {
+	switch compareTo {
+	case compareToPreviousPeriod:
+		return time.Duration(endTime-startTime) * time.Second, nil
+	case compareToOneDayAgo:
+		return 24 * time.Hour, nil
+	case compareToSevenDaysAgo:
+		return 7 * 24 * time.Hour, nil
+	}
+
+	offset, err := parseDayDuration(compareTo)
+	if err != nil || offset <= 0 {
+		return 0, fmt.Errorf("compare_to must be %s, %s, %s or a positive offset like 6h or 2d", compareToPreviousPeriod, compareToOneDayAgo, compareToSevenDaysAgo)
+	}
+	return offset, nil
+}
+
+// parseDayDuration parses Go durations like 90m or 6h and whole days and weeks like 2d or 1w.
+func parseDayDuration(value string) (time.Duration, error) {
+	if !strings.HasSuffix(value, "d") && !strings.HasSuffix(value, "w") {
+		return time.ParseDuration(value)
+	}
+	count, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	duration := time.Duration(count) * 24 * time.Hour
+	if strings.HasSuffix(value, "w") {
+		duration *= 7
+	}
+	return duration, nil
+}
+
+// compareMetricsResponses pairs the timeseries and formulas of both metrics responses by their order and their splits
+// by their attributes. In raw mode the buckets of the comparison period are aligned with the buckets of the time period
+// by shifting them by the offset.
+func compareMetricsResponses(current []byte, previous []byte, names []string, offset time.Duration, mode string, points int) ([]timeseriesComparisonItem, error) {
+	currentSeries, err := parseMetricsResponseSeries(current)
+	if err != nil {
+		return nil, err
+	}
+	previousSeries, err := parseMetricsResponseSeries(previous)
+	if err != nil {
+		return nil, err
+	}
+	if len(currentSeries) != len(previousSeries) {
+		return nil, fmt.Errorf("error comparing timeseries: got %d timeseries for the time period and %d for the comparison period", len(currentSeries), len(previousSeries))
+	}
+
+	items := make([]timeseriesComparisonItem, 0, len(currentSeries))
+	for i := range currentSeries {
+		item := timeseriesComparisonItem{Name: names[i], Series: []timeseriesComparison{}}
+		var previousKeys []string
+		previousByKey := map[string]metricSeries{}
+		for _, series := range previousSeries[i] {
+			key := metricSeriesKey(series.Attributes)
+			previousKeys = append(previousKeys, key)
+			previousByKey[key] = series
+		}
+
+		seen := map[string]bool{}
+		for _, series := range currentSeries[i] {
+			key := metricSeriesKey(series.Attributes)
+			seen[key] = true
+			var matched *metricSeries
+			if previous, ok := previousByKey[key]; ok {
+				matched = &previous
+			}
+			item.Series = append(item.Series, compareTimeseries(&series, matched, offset, mode, points))
+		}
+		for _, key := range previousKeys {
+			if !seen[key] {
+				previous := previousByKey[key]
+				item.Series = append(item.Series, compareTimeseries(nil, &previous, offset, mode, points))
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func parseMetricsResponseSeries(body []byte) ([][]metricSeries, error) {
+	var response metricsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling metrics response: %v", err)
+	}
+	result := make([][]metricSeries, len(response.Metrics))
+	for i, item := range response.Metrics {
+		rawSeries, ok := item["metric"]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(rawSeries, &result[i]); err != nil {
+			return nil, fmt.Errorf("error unmarshaling metric series: %v", err)
+		}
+	}
+	return result, nil
+}
+
+func compareTimeseries(current *metricSeries, previous *metricSeries, offset time.Duration, mode string, points int) timeseriesComparison {
+	comparison := timeseriesComparison{}
+	var currentPoints, previousPoints []timeseriesSummaryPoint
+	if previous != nil {
+		comparison.Attributes = previous.Attributes
+		previousPoints = metricSeriesPoints(*previous)
+		comparison.PreviousAvg = averageTimeseriesPoints(previousPoints)
+	}
+	if current != nil {
+		comparison.Attributes = current.Attributes
+		currentPoints = metricSeriesPoints(*current)
+		comparison.Avg = averageTimeseriesPoints(currentPoints)
+	}
+	if comparison.Avg != nil && comparison.PreviousAvg != nil {
+		comparison.AvgDelta, comparison.AvgPercentDelta = timeseriesDeltas(*comparison.Avg, *comparison.PreviousAvg)
+	}
+
+	if mode == timeseriesModeSummary {
+		if current != nil {
+			summary := summarizeTimeseries(*current, points)
+			comparison.Summary = &summary
+		}
+		if previous != nil {
+			summary := summarizeTimeseries(*previous, points)
+			comparison.PreviousSummary = &summary
+		}
+		return comparison
+	}
+
+	comparison.Data = alignTimeseriesPoints(currentPoints, previousPoints, offset)
+	return comparison
+}
+
+// alignTimeseriesPoints matches every bucket of the comparison period shifted by the offset with the bucket of the
+// time period at the same time. When the offset is not a multiple of the bucket size the closest bucket within half a
+// bucket is used. The points of both are in milliseconds.
+func alignTimeseriesPoints(current []timeseriesSummaryPoint, previous []timeseriesSummaryPoint, offset time.Duration) []timeseriesComparisonPoint {
+	tolerance := timeseriesBucketSize(current, previous) / 2
+	shifted := make([]timeseriesSummaryPoint, len(previous))
+	for i, point := range previous {
+		shifted[i] = timeseriesSummaryPoint{Time: point.Time + offset.Milliseconds(), Value: point.Value}
+	}
+
+	aligned := make([]timeseriesComparisonPoint, 0, max(len(current), len(shifted)))
+	used := make([]bool, len(shifted))
+	next := 0
+	for _, point := range current {
+		value := roundTimeseriesValue(point.Value)
+		entry := timeseriesComparisonPoint{Time: point.Time, Value: &value}
+		for next < len(shifted) && shifted[next].Time < point.Time-tolerance {
+			next++
+		}
+		if next < len(shifted) && shifted[next].Time <= point.Time+tolerance && !used[next] {
+			used[next] = true
+			previousValue := roundTimeseriesValue(shifted[next].Value)
+			entry.PreviousValue = &previousValue
+			entry.Delta, entry.PercentDelta = timeseriesDeltas(point.Value, shifted[next].Value)
+		}
+		aligned = append(aligned, entry)
+	}
+	for i, point := range shifted {
+		if !used[i] {
+			previousValue := roundTimeseriesValue(point.Value)
+			aligned = append(aligned, timeseriesComparisonPoint{Time: point.Time, PreviousValue: &previousValue})
+		}
+	}
+	sort.SliceStable(aligned, func(i, j int) bool {
+		return aligned[i].Time < aligned[j].Time
+	})
+	return aligned
+}
+
+// timeseriesBucketSize returns the smallest distance between two points of either series.
+func timeseriesBucketSize(series ...[]timeseriesSummaryPoint) int64 {
+	var bucketSize int64
+	for _, points := range series {
+		for i := 1; i < len(points); i++ {
+			if distance := points[i].Time - points[i-1].Time; distance > 0 && (bucketSize == 0 || distance < bucketSize) {
+				bucketSize = distance
+			}
+		}
+	}
+	return bucketSize
+}
+
+// timeseriesDeltas returns the absolute and the percent change from previous to current. The percent change is left
+// out when the previous value is 0.
+func timeseriesDeltas(current float64, previous float64) (*float64, *float64) {
+	delta := roundTimeseriesValue(current - previous)
+	if previous == 0 {
+		return &delta, nil
+	}
+	percent := math.Round((current-previous)/math.Abs(previous)*10000) / 100
+	return &delta, &percent
+}
+
+func averageTimeseriesPoints(points []timeseriesSummaryPoint) *float64 {
+	if len(points) == 0 {
+		return nil
+	}
+	average := roundTimeseriesValue(meanPointValue(points))
+	return &average
+}
+
+// metricSeriesKey identifies a split by its attributes.
+func metricSeriesKey(attributes map[string]string) string {
+	var builder strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(attributes)) {
+		builder.WriteString(key)
+		builder.WriteString("=")
+		builder.WriteString(attributes[key])
+		builder.WriteString("\x00")
+	}
+	return builder.String()
+}
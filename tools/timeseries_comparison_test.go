@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+func TestParseCompareToOffset(t *testing.T) {
+	tests := map[string]time.Duration{
+		"previous_period": 2 * time.Hour,
+		"1d":              24 * time.Hour,
+		"7d":              7 * 24 * time.Hour,
+		"2w":              14 * 24 * time.Hour,
+		"90m":             90 * time.Minute,
+	}
+	for compareTo, expected := range tests {
+		offset, err := parseCompareToOffset(compareTo, 1771495200, 1771502400)
+		if err != nil || offset != expected {
+			t.Fatalf("expected %s to be %v, got %v %v", compareTo, expected, offset, err)
+		}
+	}
+	for _, compareTo := range []string{"yesterday", "-1d", "0h", "d"} {
+		if _, err := parseCompareToOffset(compareTo, 1771495200, 1771502400); err == nil {
+			t.Fatalf("expected error for %s", compareTo)
+		}
+	}
+}
+
+func TestAlignTimeseriesPoints(t *testing.T) {
+	current := []timeseriesSummaryPoint{{Time: 86400000, Value: 15}, {Time: 86460000, Value: 10}, {Time: 86520000, Value: 4}}
+	// The comparison period has no bucket for the second minute and an extra bucket before the time period.
+	previous := []timeseriesSummaryPoint{{Time: -60000, Value: 7}, {Time: 0, Value: 10}, {Time: 120000, Value: 0}}
+
+	aligned := alignTimeseriesPoints(current, previous, 24*time.Hour)
+	if len(aligned) != 4 {
+		t.Fatalf("expected 4 points, got %+v", aligned)
+	}
+	if aligned[0].Value != nil || *aligned[0].PreviousValue != 7 {
+		t.Fatalf("expected the extra bucket to only have a previous value, got %+v", aligned[0])
+	}
+	if *aligned[1].Value != 15 || *aligned[1].PreviousValue != 10 || *aligned[1].Delta != 5 || *aligned[1].PercentDelta != 50 {
+		t.Fatalf("unexpected aligned point %+v", aligned[1])
+	}
+	if aligned[2].PreviousValue != nil || aligned[2].Delta != nil {
+		t.Fatalf("expected no previous value, got %+v", aligned[2])
+	}
+	if *aligned[3].Delta != 4 || aligned[3].PercentDelta != nil {
+		t.Fatalf("expected no percent delta from 0, got %+v", aligned[3])
+	}
+}
+
+func TestGetMultiMetricHandlerCompareTo(t *testing.T) {
+	splitSeries := func(service string, start int64, values ...float64) map[string]any {
+		var data []map[string]any
+		for i, value := range values {
+			data = append(data, map[string]any{"time": (start + int64(i)*60) * 1000, "value": value})
+		}
+		return map[string]any{"attributes": map[string]string{"service.name": service}, "data": data}
+	}
+
+	var requests []model.GetMultiMetricRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":["service.name"]}`))
+		case "/api/v1/metrics":
+			body, _ := io.ReadAll(r.Body)
+			var request model.GetMultiMetricRequest
+			if err := json.Unmarshal(body, &request); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			requests = append(requests, request)
+
+			series := []map[string]any{splitSeries("checkout", request.StartTime, 10, 12)}
+			if len(requests) == 1 {
+				series = []map[string]any{splitSeries("checkout", request.StartTime, 20, 30), splitSeries("payments", request.StartTime, 1, 1)}
+			}
+			response, _ := json.Marshal(map[string]any{"metrics": []map[string]any{{"metric": series}}})
+			_, _ = w.Write(response)
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	arguments := GetMultiMetricHandlerArgs{
+		TimeConfig: absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:02:00Z"),
+		Timeseries: []model.SingleTimeseriesRequest{{Type: model.Trace, Aggregation: model.AggregationCount, BucketSize: 60, Splits: []string{"service.name"}, FormulaIdentifier: "a"}},
+		CompareTo:  "1D",
+	}
+	response, err := GetMultiMetricHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(requests) != 2 || requests[1].StartTime != requests[0].StartTime-86400 || requests[1].Metrics[0].Trace.EndTime != requests[0].EndTime-86400 {
+		t.Fatalf("expected the comparison period to be a day earlier, got %+v", requests)
+	}
+
+	var comparison timeseriesComparisonResponse
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &comparison); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if comparison.ComparisonStart != "2026-02-18T10:00:00Z" || comparison.Metrics[0].Name != "a" || len(comparison.Metrics[0].Series) != 2 {
+		t.Fatalf("unexpected comparison %+v", comparison)
+	}
+	checkout := comparison.Metrics[0].Series[0]
+	if *checkout.Avg != 25 || *checkout.PreviousAvg != 11 || *checkout.AvgDelta != 14 || *checkout.AvgPercentDelta != 127.27 {
+		t.Fatalf("unexpected checkout comparison %+v", checkout)
+	}
+	if len(checkout.Data) != 2 || *checkout.Data[1].Value != 30 || *checkout.Data[1].PreviousValue != 12 {
+		t.Fatalf("expected aligned buckets, got %+v", checkout.Data)
+	}
+	payments := comparison.Metrics[0].Series[1]
+	if payments.Attributes["service.name"] != "payments" || payments.PreviousAvg != nil || payments.AvgDelta != nil {
+		t.Fatalf("expected payments to only be in the time period, got %+v", payments)
+	}
+
+	arguments.Mode = "summary"
+	requests = nil
+	response, err = GetMultiMetricHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	comparison = timeseriesComparisonResponse{}
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &comparison); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if checkout := comparison.Metrics[0].Series[0]; checkout.Data != nil || checkout.Summary.Max != 30 || checkout.PreviousSummary.Max != 12 {
+		t.Fatalf("expected summaries of both periods, got %+v", checkout)
+	}
+
+	arguments.CompareTo = "last week"
+	if _, err := GetMultiMetricHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error for invalid compare_to")
+	}
+}
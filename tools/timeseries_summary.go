@@ -0,0 +1,321 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	timeseriesModeRaw     = "raw"
+	timeseriesModeSummary = "summary"
+
+	maxTimeseriesSummaryPoints = 500
+	timeseriesSparklineWidth   = 24
+)
+
+var timeseriesSparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// metricsResponse is the response of the metrics endpoint. Every item of Metrics is a requested timeseries or formula
+// whose series are kept in Metric. The other fields of the items are passed through unchanged.
+type metricsResponse struct {
+	Metrics []map[string]json.RawMessage `json:"metrics"`
+}
+
+type metricSeries struct {
+	Attributes map[string]string `json:"attributes"`
+	Data       []metricDataPoint `json:"data"`
+}
+
+type metricDataPoint struct {
+	// The time of the bucket in milliseconds since the epoch
+	Time  int64    `json:"time"`
+	Value *float64 `json:"value"`
+}
+
+type timeseriesSummary struct {
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Points     int               `json:"points"`
+	Start      string            `json:"start,omitempty"`
+	End        string            `json:"end,omitempty"`
+	Min        float64           `json:"min"`
+	Max        float64           `json:"max"`
+	Avg        float64           `json:"avg"`
+	P50        float64           `json:"p50"`
+	P95        float64           `json:"p95"`
+	Last       float64           `json:"last"`
+	// TrendPerMinute is the slope of the least squares line through the points.
+	TrendPerMinute    float64                  `json:"trendPerMinute"`
+	LargestStepChange *timeseriesStepChange    `json:"largestStepChange,omitempty"`
+	Sparkline         string                   `json:"sparkline"`
+	Data              []timeseriesSummaryPoint `json:"data,omitempty"`
+}
+
+type timeseriesStepChange struct {
+	Time   string  `json:"time"`
+	From   float64 `json:"from"`
+	To     float64 `json:"to"`
+	Change float64 `json:"change"`
+}
+
+type timeseriesSummaryPoint struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// parseTimeseriesMode validates the mode and points arguments of the timeseries tools. The mode defaults to raw.
+func parseTimeseriesMode(mode string, points int) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = timeseriesModeRaw
+	}
+	if mode != timeseriesModeRaw && mode != timeseriesModeSummary {
+		return "", fmt.Errorf("mode must be %s or %s", timeseriesModeRaw, timeseriesModeSummary)
+	}
+	if points < 0 || points > maxTimeseriesSummaryPoints {
+		return "", fmt.Errorf("points must be between 0 and %d", maxTimeseriesSummaryPoints)
+	}
+	if points > 0 && mode != timeseriesModeSummary {
+		return "", fmt.Errorf("points can only be set with mode %s", timeseriesModeSummary)
+	}
+	return mode, nil
+}
+
+// summarizeMetricsResponse replaces the series of every timeseries and formula of the metrics response with their
+// summary. When points is positive the series are downsampled to that many points with LTTB, otherwise the raw points
+// are dropped.
+func summarizeMetricsResponse(body []byte, points int) ([]byte, error) {
+	var response metricsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling metrics response: %v", err)
+	}
+
+	for _, item := range response.Metrics {
+		rawSeries, ok := item["metric"]
+		if !ok {
+			continue
+		}
+		var series []metricSeries
+		if err := json.Unmarshal(rawSeries, &series); err != nil {
+			return nil, fmt.Errorf("error unmarshaling metric series: %v", err)
+		}
+		summaries := make([]timeseriesSummary, 0, len(series))
+		for _, single := range series {
+			summaries = append(summaries, summarizeTimeseries(single, points))
+		}
+		summarized, err := json.Marshal(summaries)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling timeseries summaries: %v", err)
+		}
+		item["metric"] = summarized
+	}
+
+	summarized, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling metrics response: %v", err)
+	}
+	return summarized, nil
+}
+
+// metricSeriesPoints returns the points of the series that have a value ordered by time in milliseconds.
+func metricSeriesPoints(series metricSeries) []timeseriesSummaryPoint {
+	data := make([]timeseriesSummaryPoint, 0, len(series.Data))
+	for _, point := range series.Data {
+		if point.Value == nil || math.IsNaN(*point.Value) || math.IsInf(*point.Value, 0) {
+			continue
+		}
+		data = append(data, timeseriesSummaryPoint{Time: timeseriesMillis(point.Time), Value: *point.Value})
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].Time < data[j].Time
+	})
+	return data
+}
+
+func summarizeTimeseries(series metricSeries, points int) timeseriesSummary {
+	data := metricSeriesPoints(series)
+	summary := timeseriesSummary{Attributes: series.Attributes, Points: len(data)}
+	if len(data) == 0 {
+		return summary
+	}
+
+	values := make([]float64, len(data))
+	sum := 0.0
+	for i, point := range data {
+		values[i] = point.Value
+		sum += point.Value
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	summary.Start = formatTimeseriesTime(data[0].Time)
+	summary.End = formatTimeseriesTime(data[len(data)-1].Time)
+	summary.Min = roundTimeseriesValue(sorted[0])
+	summary.Max = roundTimeseriesValue(sorted[len(sorted)-1])
+	summary.Avg = roundTimeseriesValue(sum / float64(len(values)))
+	summary.P50 = roundTimeseriesValue(timeseriesPercentile(sorted, 0.5))
+	summary.P95 = roundTimeseriesValue(timeseriesPercentile(sorted, 0.95))
+	summary.Last = roundTimeseriesValue(values[len(values)-1])
+	summary.TrendPerMinute = roundTimeseriesValue(timeseriesSlope(data) * float64(time.Minute/time.Millisecond))
+	summary.LargestStepChange = largestTimeseriesStepChange(data)
+	summary.Sparkline = timeseriesSparkline(values, sorted[0], sorted[len(sorted)-1])
+
+	if points > 0 {
+		for _, point := range downsampleLTTB(data, points) {
+			summary.Data = append(summary.Data, timeseriesSummaryPoint{Time: point.Time, Value: roundTimeseriesValue(point.Value)})
+		}
+	}
+	return summary
+}
+
+// timeseriesPercentile interpolates linearly between the closest ranks of the sorted values.
+func timeseriesPercentile(sorted []float64, percentile float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := percentile * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// timeseriesSlope returns the slope of the least squares line through the points per millisecond.
+func timeseriesSlope(data []timeseriesSummaryPoint) float64 {
+	if len(data) < 2 {
+		return 0
+	}
+	// Times are taken relative to the first point so the squares stay small.
+	var meanTime, meanValue float64
+	for _, point := range data {
+		meanTime += float64(point.Time - data[0].Time)
+		meanValue += point.Value
+	}
+	meanTime /= float64(len(data))
+	meanValue /= float64(len(data))
+
+	var covariance, variance float64
+	for _, point := range data {
+		deltaTime := float64(point.Time-data[0].Time) - meanTime
+		covariance += deltaTime * (point.Value - meanValue)
+		variance += deltaTime * deltaTime
+	}
+	if variance == 0 {
+		return 0
+	}
+	return covariance / variance
+}
+
+func largestTimeseriesStepChange(data []timeseriesSummaryPoint) *timeseriesStepChange {
+	var largest *timeseriesStepChange
+	for i := 1; i < len(data); i++ {
+		change := data[i].Value - data[i-1].Value
+		if change == 0 || (largest != nil && math.Abs(change) <= math.Abs(largest.Change)) {
+			continue
+		}
+		largest = &timeseriesStepChange{
+			Time:   formatTimeseriesTime(data[i].Time),
+			From:   roundTimeseriesValue(data[i-1].Value),
+			To:     roundTimeseriesValue(data[i].Value),
+			Change: roundTimeseriesValue(change),
+		}
+	}
+	return largest
+}
+
+// timeseriesSparkline averages the values into at most timeseriesSparklineWidth columns and draws each column with a
+// block whose height is relative to the min and max of the series.
+func timeseriesSparkline(values []float64, minValue float64, maxValue float64) string {
+	columns := min(len(values), timeseriesSparklineWidth)
+	var builder strings.Builder
+	for column := 0; column < columns; column++ {
+		from := column * len(values) / columns
+		to := (column + 1) * len(values) / columns
+		sum := 0.0
+		for _, value := range values[from:to] {
+			sum += value
+		}
+		level := 0
+		if maxValue > minValue {
+			level = int(math.Round((sum/float64(to-from) - minValue) / (maxValue - minValue) * float64(len(timeseriesSparklineLevels)-1)))
+		}
+		builder.WriteRune(timeseriesSparklineLevels[level])
+	}
+	return builder.String()
+}
+
+// downsampleLTTB keeps threshold points of the series with the Largest-Triangle-Three-Buckets algorithm, which keeps
+// the first and last point and picks the point of every bucket in between that best preserves the shape of the series.
+func downsampleLTTB(data []timeseriesSummaryPoint, threshold int) []timeseriesSummaryPoint {
+	if threshold >= len(data) || threshold <= 0 {
+		return data
+	}
+	if threshold < 3 {
+		return []timeseriesSummaryPoint{data[0], data[len(data)-1]}[:threshold]
+	}
+
+	sampled := make([]timeseriesSummaryPoint, 0, threshold)
+	sampled = append(sampled, data[0])
+	bucketSize := float64(len(data)-2) / float64(threshold-2)
+	selected := 0
+	for bucket := 0; bucket < threshold-2; bucket++ {
+		// The next bucket is represented by its average point, the last bucket by the last point.
+		nextFrom := int(math.Floor(float64(bucket+1)*bucketSize)) + 1
+		nextTo := min(int(math.Floor(float64(bucket+2)*bucketSize))+1, len(data))
+		var averageTime, averageValue float64
+		for _, point := range data[nextFrom:nextTo] {
+			averageTime += float64(point.Time)
+			averageValue += point.Value
+		}
+		averageTime /= float64(nextTo - nextFrom)
+		averageValue /= float64(nextTo - nextFrom)
+
+		from := int(math.Floor(float64(bucket)*bucketSize)) + 1
+		to := int(math.Floor(float64(bucket+1)*bucketSize)) + 1
+		selectedTime, selectedValue := float64(data[selected].Time), data[selected].Value
+		largestArea, next := -1.0, from
+		for i := from; i < to; i++ {
+			area := math.Abs((selectedTime-averageTime)*(data[i].Value-selectedValue) -
+				(selectedTime-float64(data[i].Time))*(averageValue-selectedValue))
+			if area > largestArea {
+				largestArea, next = area, i
+			}
+		}
+		sampled = append(sampled, data[next])
+		selected = next
+	}
+	return append(sampled, data[len(data)-1])
+}
+
+func timeseriesMillis(value int64) int64 {
+	return epochTime(value).UnixMilli()
+}
+
+func formatTimeseriesTime(value int64) string {
+	return epochTime(value).UTC().Format(time.RFC3339)
+}
+
+// epochTime converts a timestamp of an endpoint that doesn't document its unit to a time. The unit is detected from
+// the magnitude, seconds, milliseconds and nanoseconds since the epoch are far enough apart for any recent time.
+func epochTime(value int64) time.Time {
+	switch {
+	case value > 1e15:
+		return time.Unix(0, value)
+	case value > 1e12:
+		return time.UnixMilli(value)
+	default:
+		return time.Unix(value, 0)
+	}
+}
+
+// roundTimeseriesValue keeps 6 significant digits so summaries don't spend tokens on float noise.
+func roundTimeseriesValue(value float64) float64 {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(value, 'g', 6, 64), 64)
+	if err != nil {
+		return value
+	}
+	return rounded
+}
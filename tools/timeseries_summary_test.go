@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+// newTestMetricSeries returns a series of one minute buckets starting at 2026-02-19T10:00:00Z.
+func newTestMetricSeries(values ...float64) metricSeries {
+	series := metricSeries{Attributes: map[string]string{"service.name": "checkout"}}
+	for i := range values {
+		series.Data = append(series.Data, metricDataPoint{Time: 1771495200000 + int64(i)*60000, Value: &values[i]})
+	}
+	return series
+}
+
+func TestSummarizeTimeseries(t *testing.T) {
+	summary := summarizeTimeseries(newTestMetricSeries(1, 2, 3, 4, 5, 6, 7, 8, 9, 50), 0)
+
+	if summary.Points != 10 || summary.Min != 1 || summary.Max != 50 || summary.Avg != 9.5 || summary.Last != 50 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if summary.P50 != 5.5 || summary.P95 != 31.55 {
+		t.Fatalf("unexpected percentiles %v %v", summary.P50, summary.P95)
+	}
+	if summary.Start != "2026-02-19T10:00:00Z" || summary.End != "2026-02-19T10:09:00Z" {
+		t.Fatalf("unexpected range %s %s", summary.Start, summary.End)
+	}
+	if summary.TrendPerMinute <= 1 {
+		t.Fatalf("expected an upward trend, got %v", summary.TrendPerMinute)
+	}
+	step := summary.LargestStepChange
+	if step == nil || step.Time != "2026-02-19T10:09:00Z" || step.From != 9 || step.To != 50 || step.Change != 41 {
+		t.Fatalf("unexpected step change %+v", step)
+	}
+	if summary.Sparkline != "▁▁▁▁▂▂▂▂▂█" || summary.Data != nil {
+		t.Fatalf("unexpected sparkline %q or data %v", summary.Sparkline, summary.Data)
+	}
+}
+
+func TestSummarizeTimeseriesSkipsMissingValues(t *testing.T) {
+	series := newTestMetricSeries(4, 4)
+	series.Data = append(series.Data, metricDataPoint{Time: 1771495320000})
+
+	summary := summarizeTimeseries(series, 0)
+	if summary.Points != 2 || summary.Last != 4 || summary.TrendPerMinute != 0 || summary.LargestStepChange != nil {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if summary.Sparkline != "▁▁" {
+		t.Fatalf("unexpected sparkline %q", summary.Sparkline)
+	}
+
+	if empty := summarizeTimeseries(metricSeries{}, 10); empty.Points != 0 || empty.Sparkline != "" {
+		t.Fatalf("unexpected empty summary %+v", empty)
+	}
+}
+
+func TestDownsampleLTTBKeepsSpikes(t *testing.T) {
+	values := make([]float64, 100)
+	values[37] = 100
+	values[80] = -20
+	var data []timeseriesSummaryPoint
+	for i, value := range values {
+		data = append(data, timeseriesSummaryPoint{Time: int64(i) * 1000, Value: value})
+	}
+
+	sampled := downsampleLTTB(data, 10)
+	if len(sampled) != 10 || sampled[0].Time != 0 || sampled[9].Time != 99000 {
+		t.Fatalf("expected 10 points with the first and last point, got %v", sampled)
+	}
+	var times []int64
+	for _, point := range sampled {
+		times = append(times, point.Time)
+	}
+	if !slices.Contains(times, 37000) || !slices.Contains(times, 80000) {
+		t.Fatalf("expected the spike and the dip to be kept, got %v", times)
+	}
+
+	if unchanged := downsampleLTTB(data[:5], 10); len(unchanged) != 5 {
+		t.Fatalf("expected short series to be unchanged, got %d points", len(unchanged))
+	}
+}
+
+func TestGetMultiMetricHandlerSummaryMode(t *testing.T) {
+	series := newTestMetricSeries(1, 2, 3, 4, 5, 6, 7, 8, 9, 50)
+	body, _ := json.Marshal(map[string]any{
+		"metrics": []map[string]any{{"metric": []metricSeries{series}, "isAggregated": false}},
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metrics/attributes":
+			_, _ = w.Write([]byte(`{"attributes":[]}`))
+		case "/api/v1/metrics":
+			_, _ = w.Write(body)
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	arguments := GetMultiMetricHandlerArgs{
+		TimeConfig: absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:10:00Z"),
+		Timeseries: []model.SingleTimeseriesRequest{{Type: model.Trace, Aggregation: model.AggregationCount, BucketSize: 60}},
+		Mode:       "Summary",
+		Points:     4,
+	}
+	response, err := GetMultiMetricHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	text := response.Content[0].TextContent.Text
+	if !strings.Contains(text, `"isAggregated":false`) {
+		t.Fatalf("expected other fields to be kept, got %s", text)
+	}
+	var summarized struct {
+		Metrics []struct {
+			Metric []timeseriesSummary `json:"metric"`
+		} `json:"metrics"`
+	}
+	if err := json.Unmarshal([]byte(text), &summarized); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	summary := summarized.Metrics[0].Metric[0]
+	if summary.Max != 50 || summary.Attributes["service.name"] != "checkout" || len(summary.Data) != 4 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	arguments.Mode = "raw"
+	if _, err := GetMultiMetricHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error for points in raw mode")
+	}
+	arguments.Mode = "stats"
+	if _, err := GetMultiMetricHandler(context.Background(), arguments); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
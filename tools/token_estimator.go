@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tiktoken-go/tokenizer/codec"
+)
+
+const (
+	toolResponseTokenizerEnvVar = "METORO_TOOL_RESPONSE_TOKENIZER"
+	tokenizerCl100k             = "cl100k"
+	tokenizerRunes              = "runes"
+)
+
+// TokenEstimator estimates how many tokens a piece of text takes up in the context window of the agent.
+type TokenEstimator interface {
+	EstimateTokens(value string) int
+}
+
+// RuneTokenEstimator counts every 4 runes as one token. It is cheap but under-counts dense JSON such as UUIDs and
+// numbers and over-counts prose.
+type RuneTokenEstimator struct{}
+
+func (RuneTokenEstimator) EstimateTokens(value string) int {
+	if value == "" {
+		return 0
+	}
+
+	runeCount := utf8.RuneCountInString(value)
+	return int(math.Ceil(float64(runeCount) / 4.0))
+}
+
+// BPETokenEstimator counts tokens with the cl100k_base BPE vocabulary which is embedded in the binary, so no
+// network access is needed.
+type BPETokenEstimator struct{}
+
+var (
+	cl100kCodec     *codec.Codec
+	cl100kCodecOnce sync.Once
+)
+
+func (BPETokenEstimator) EstimateTokens(value string) int {
+	if value == "" {
+		return 0
+	}
+
+	cl100kCodecOnce.Do(func() {
+		cl100kCodec = codec.NewCl100kBase()
+	})
+
+	count, err := cl100kCodec.Count(value)
+	if err != nil {
+		return RuneTokenEstimator{}.EstimateTokens(value)
+	}
+	return count
+}
+
+// getGlobalTokenEstimator returns the estimator selected with METORO_TOOL_RESPONSE_TOKENIZER, cl100k by default.
+func getGlobalTokenEstimator() TokenEstimator {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(toolResponseTokenizerEnvVar))) {
+	case tokenizerRunes:
+		return RuneTokenEstimator{}
+	default:
+		return BPETokenEstimator{}
+	}
+}
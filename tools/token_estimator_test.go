@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+)
+
+func TestBPETokenEstimatorCountsDenseJSONHigherThanRunes(t *testing.T) {
+	var builder strings.Builder
+	for i := 0; i < 50; i++ {
+		builder.WriteString(`{"traceId":"3f2b9c1e-4f1a-9b2c-8d7e-6a5b4c3d2e1f","duration":918273645},`)
+	}
+	value := builder.String()
+
+	bpe := BPETokenEstimator{}.EstimateTokens(value)
+	runes := RuneTokenEstimator{}.EstimateTokens(value)
+	if bpe <= runes {
+		t.Fatalf("expected bpe estimate %d to be higher than rune estimate %d for dense json", bpe, runes)
+	}
+}
+
+func TestBPETokenEstimatorCountsKnownText(t *testing.T) {
+	if count := (BPETokenEstimator{}).EstimateTokens("hello world"); count != 2 {
+		t.Fatalf("expected 2 tokens, got %d", count)
+	}
+	if count := (BPETokenEstimator{}).EstimateTokens(""); count != 0 {
+		t.Fatalf("expected 0 tokens for empty text, got %d", count)
+	}
+}
+
+func TestGetGlobalTokenEstimatorUsesEnvVar(t *testing.T) {
+	t.Setenv(toolResponseTokenizerEnvVar, "runes")
+	if _, ok := getGlobalTokenEstimator().(RuneTokenEstimator); !ok {
+		t.Fatalf("expected rune estimator when %s=runes", toolResponseTokenizerEnvVar)
+	}
+
+	t.Setenv(toolResponseTokenizerEnvVar, "")
+	if _, ok := getGlobalTokenEstimator().(BPETokenEstimator); !ok {
+		t.Fatalf("expected bpe estimator by default")
+	}
+}
+
+func TestWrappedHandlerUsesPerToolBudget(t *testing.T) {
+	type testArgs struct{}
+
+	handler := func(ctx context.Context, arguments testArgs) (*mcpgolang.ToolResponse, error) {
+		return mcpgolang.NewToolResponse(
+			mcpgolang.NewTextContent(strings.Repeat("word ", 200)),
+		), nil
+	}
+
+	t.Setenv(toolResponseMaxTokensEnvVar, "50")
+
+	small := MetoroTools{Name: "small_budget_tool", Handler: handler}
+	wrappedSmall := small.WrappedHandler().(func(context.Context, testArgs) (*mcpgolang.ToolResponse, error))
+	if _, err := wrappedSmall(context.Background(), testArgs{}); err == nil {
+		t.Fatalf("expected global budget to reject the response")
+	}
+
+	large := MetoroTools{Name: "large_budget_tool", Handler: handler, MaxResponseTokens: 1000}
+	wrappedLarge := large.WrappedHandler().(func(context.Context, testArgs) (*mcpgolang.ToolResponse, error))
+	if _, err := wrappedLarge(context.Background(), testArgs{}); err != nil {
+		t.Fatalf("expected per tool budget to accept the response, got %v", err)
+	}
+}
+
+func TestWrappedHandlerBudgetDoesNotLeakBetweenTools(t *testing.T) {
+	type testArgs struct{}
+
+	handler := func(ctx context.Context, arguments testArgs) (*mcpgolang.ToolResponse, error) {
+		return mcpgolang.NewToolResponse(
+			mcpgolang.NewTextContent(strings.Repeat("word ", 200)),
+		), nil
+	}
+
+	t.Setenv(toolResponseMaxTokensEnvVar, "50")
+
+	large := MetoroTools{Name: "same_name_tool", Handler: handler, MaxResponseTokens: 1000}
+	wrappedLarge := large.WrappedHandler().(func(context.Context, testArgs) (*mcpgolang.ToolResponse, error))
+	small := MetoroTools{Name: "same_name_tool", Handler: handler}
+	wrappedSmall := small.WrappedHandler().(func(context.Context, testArgs) (*mcpgolang.ToolResponse, error))
+
+	if _, err := wrappedLarge(context.Background(), testArgs{}); err != nil {
+		t.Fatalf("expected per tool budget to accept the response, got %v", err)
+	}
+	if _, err := wrappedSmall(context.Background(), testArgs{}); err == nil {
+		t.Fatalf("expected the budget of a tool with the same name not to be used")
+	}
+}
+
+func TestMetoroToolsListBudgetsAreUsed(t *testing.T) {
+	for _, tool := range MetoroToolsList {
+		if tool.ResponseGuard != nil && (tool.MaxResponseTokens > 0 || tool.TokenEstimator != nil || tool.ResponseModifier != nil) {
+			t.Fatalf("%s sets its own response guard so its budget and modifier have to be set in the guard's options", tool.Name)
+		}
+	}
+}
@@ -1,10 +1,23 @@
 package tools
 
 type MetoroTools struct {
-	Name          string
-	Description   string
-	Handler       any
+	Name        string
+	Description string
+	Handler     any
+	// ResponseGuard replaces the default guard of the tool. Its budget is set in its options, MaxResponseTokens,
+	// TokenEstimator and ResponseModifier are only used by the default guard.
 	ResponseGuard ToolResponseGuard
+	// ResponseModifier rewrites the responses of the tool, e.g. to trim large fields, before they are checked against
+	// the token budget.
+	ResponseModifier ToolResponseModifier
+	// TruncateOversizedResponse drops items from the largest JSON array of a response that is too large instead of
+	// failing. The handler args should embed ResponseContinuationArgs so the dropped items can be requested.
+	TruncateOversizedResponse bool
+	// MaxResponseTokens overrides METORO_TOOL_RESPONSE_MAX_TOKENS for this tool, e.g. to give tools which return
+	// whole traces a larger budget.
+	MaxResponseTokens int
+	// TokenEstimator overrides the estimator selected with METORO_TOOL_RESPONSE_TOKENIZER for this tool.
+	TokenEstimator TokenEstimator
 }
 
 var MetoroToolsList = []MetoroTools{
@@ -24,30 +37,60 @@ var MetoroToolsList = []MetoroTools{
 		Handler:     GetNamespacesHandler,
 	},
 	{
-		Name:          "get_logs",
-		Description:   `Get logs from all or specific services/hosts/pods. Results are limited to 20 logs lines.  Before using this you MUST first call get_attribute_keys and get_attribute_values to get the possible log attribute keys and values which can be used as Filter/ExcludeFilter keys.`,
-		Handler:       GetLogsHandler,
-		ResponseGuard: LogsToolResponseGuard,
+		Name:                      "get_logs",
+		Description:               `Get logs from all or specific services/hosts/pods. Returns 20 log lines by default, set limit for up to 100 and order to choose newest or oldest first. If the response has a next_cursor pass it as cursor to get the next page.  Before using this you MUST first call get_attribute_keys and get_attribute_values to get the possible log attribute keys and values which can be used as Filter/ExcludeFilter keys.`,
+		Handler:                   GetLogsHandler,
+		ResponseModifier:          trimLargeLogFieldsInToolResponse,
+		TruncateOversizedResponse: true,
 	},
 	{
-		Name:          "get_log_context",
-		Description:   "Get log lines before and after a specific log line from a container. This is useful to understand the full context around a log entry of interest - what happened before and after. Requires the exact timestamp, container ID, and service name of the log line you want context for.",
-		Handler:       GetLogContextHandler,
-		ResponseGuard: LogsToolResponseGuard,
+		Name:             "get_log_context",
+		Description:      "Get log lines before and after a specific log line from a container. This is useful to understand the full context around a log entry of interest - what happened before and after. Requires the exact timestamp, container ID, and service name of the log line you want context for.",
+		Handler:          GetLogContextHandler,
+		ResponseModifier: trimLargeLogFieldsInToolResponse,
+	},
+	{
+		Name:                      "get_log_patterns",
+		Description:               "Group the logs of a time period into patterns/templates, e.g. 'connection to <*> timed out after <*>', and return the most frequent ones with their count, first and last seen time, severities, services and an example line. Use this to answer what kinds of logs or errors happened instead of reading raw lines with get_logs. It accepts the same filters as get_logs so call get_attribute_keys and get_attribute_values first.",
+		Handler:                   GetLogPatternsHandler,
+		ResponseModifier:          trimLargeLogFieldsInToolResponse,
+		TruncateOversizedResponse: true,
+	},
+	{
+		Name:                      "compare_log_patterns",
+		Description:               "Compare the log patterns of two time periods and return the patterns that are new, vanished or changed in rate in the comparison period, ranked and with an example line each. Use this after a deployment with the period before the deployment as the baseline and the period after it as the comparison to find new errors before calling report_deployment_verdict. It accepts the same filters as get_logs so call get_attribute_keys and get_attribute_values first.",
+		Handler:                   CompareLogPatternsHandler,
+		ResponseModifier:          trimLargeLogFieldsInToolResponse,
+		TruncateOversizedResponse: true,
 	},
 	{
 		Name: "get_traces",
-		Description: `Get list of traces from your cluster. Results are limited to 20 traces so try to use filters to narrow down what you are looking for.
+		Description: `Get list of traces from your cluster. Returns 20 traces by default (set limit for up to 100) so try to use filters to narrow down what you are looking for. If the response has a next_cursor pass it as cursor to get the next page.
                       Prior to using this tool, YOU MUST first call get_attribute_keys and subsequently get_attribute_values to get the possible trace attribute keys and values which can be used as Filter/ExcludeFilter keys.
 					  Use this tool when you are interested in the trace attributes to get more information to answer why/what. If you want more details about a specific trace use get_trace_spans to see individual span details.
                       If you would like to check existence of traces use get_timeseries_data tool with type=trace to get count/p50/p90/p95/p99 of traces instead of using get_traces tool.
-                      After calling get traces you should normally call get_trace_spans to get the spans associated with the traceId you are interested in. When reading duration of a trace use the durationReadable field.`,
-		Handler: GetTracesHandler,
+                      After calling get traces you should normally call get_trace_spans to get the spans associated with the traceId you are interested in. When reading duration of a trace use the durationReadable field.
+                     `,
+		Handler:                   GetTracesHandler,
+		TruncateOversizedResponse: true,
 	},
 	{
-		Name:        "get_trace_spans",
-		Description: `Get the spans associated with a specific traceId. This allows you to view the entire trace with all its spans in a tree like structure. You should basically always use this after calling get_traces tool to get the traceId you are interested in. This tool gives you all spans in a trace.`,
-		Handler:     GetTraceSpansHandler,
+		Name: "get_trace_spans",
+		Description: `Get the spans associated with a specific traceId. This allows you to view the entire trace with all its spans in a tree like structure. You should basically always use this after calling get_traces tool to get the traceId you are interested in. This tool gives you all spans in a trace.
+                      Set mode=analysis to get a compact span tree with self times, the critical path and the error spans instead of the raw spans, which is usually the best way to find out why a trace is slow or failing.
+                      Set mode=waterfall for a text waterfall or mode=mermaid for a Mermaid Gantt chart of the trace, which can be put in the markdown of create_investigation to show the trace to a human.`,
+		Handler:           GetTraceSpansHandler,
+		MaxResponseTokens: 30000,
+	},
+	{
+		Name: "compare_traces",
+		Description: `Compares a slow trace with a typical fast trace of the same request to find out why it was slow.
+                      Spans of both traces are matched by the service and span names on their path from the root and the spans that were added, removed or took longer in the slow trace are returned, with the largest growth first.
+                      Set slow_trace_id and fast_trace_id to compare two specific traces. If either is empty it is picked from the latest 500 traces matching the filters: the slowest trace as the slow one and the trace with the median duration of the same root service and span name as the fast one.
+                      Set root_span_name to pick the traces of a specific endpoint, otherwise the most common root span is used, and min_duration to only pick a slow trace that took at least that long.
+                     `,
+		Handler:                   CompareTracesHandler,
+		TruncateOversizedResponse: true,
 	},
 	{
 		Name: "get_traces_distribution",
@@ -68,8 +111,24 @@ var MetoroToolsList = []MetoroTools{
 					  Then YOU HAVE TO call get_attribute_keys tool to retrieve the available attribute keys and get_attribute_values to retrieve values you are interested in to use in Filter/ExcludeFilter keys for this tool.
 					  You can also use Splits argument to group/split the metric data by the given metric attribute keys. Only use the attribute keys and values that are available for the MetricName that are returned from get_attribute_keys and get_attribute_values tools. If you are not getting proper results back then you might have forgotten to set the correct attribute keys and values. Try again with the correct attribute keys and values you get from get_attribute_values.
                       Metrics of type counter (or with _total suffix) are cumulative metrics but Metoro querying engine already accounts for rate differences when returning the value so you don't need to calculate the rate/monotonic difference yourself. You can just query those metrics as they are without extra functions. If you are in doubt use the get_metric_metadata tool to get more information (description type unit) about the metric and how to use it.
+                      Set mode=summary to get statistics a trend and a sparkline of each series instead of every bucket, optionally with points set to keep a downsampled copy of the series. Use it when you query long time ranges or many series.
+                      Set compare_to to previous_period or 1d or 7d or an offset like 6h to find out whether the data is higher or lower than the same time before. It returns both periods aligned bucket by bucket for every split with the absolute and percent deltas.
 `,
-		Handler: GetMultiMetricHandler,
+		Handler:                   GetMultiMetricHandler,
+		TruncateOversizedResponse: true,
+	},
+	{
+		Name: "detect_anomalies",
+		Description: `Find anomalies in one or more timeseries of metrics or traces or logs or kubernetes resources. Takes the same timeseries and formulas as get_timeseries_data, fetches the time period together with a baseline of the days before it and checks the time period with robust z-scores against the baseline, a daily seasonal model and CUSUM change point detection.
+                      Returns the anomalous intervals ordered by severity with the detector that found them, the value compared to the expected value and the series and split attributes they were found in. Use this instead of reading raw timeseries to find spikes, drops and subtle regressions. Use splits e.g. service.name to find out which split is anomalous.
+                      Use bucket sizes that keep the number of buckets reasonable for the baseline e.g. 300 seconds or more for a 7 day baseline.`,
+		Handler: DetectAnomaliesHandler,
+	},
+	{
+		Name: "explain_timeseries_query",
+		Description: `Convert timeseries and formulas in the shape of get_timeseries_data into MetoroQL text. Returns the MetoroQL query of every returned timeseries and formula which can be copied into the Metoro UI or alert definitions.
+                      The attribute keys and values are not checked so use get_attribute_keys and get_attribute_values first.`,
+		Handler: ExplainTimeseriesQueryHandler,
 	},
 	{
 		Name: "get_attribute_keys",
@@ -86,9 +145,18 @@ var MetoroToolsList = []MetoroTools{
 		Handler: GetAttributeValuesHandler,
 	},
 	{
-		Name:        "get_profiles",
-		Description: "Get cpu profiles of your services running in your Kubernetes cluster. This tool is useful for answering performance related questions for a specific service. It provides information about which functions taking time in the service.",
-		Handler:     GetProfilesHandler,
+		Name: "get_profiles",
+		Description: `Get cpu profiles of your services running in your Kubernetes cluster. This tool is useful for answering performance related questions for a specific service. It provides information about which functions taking time in the service.
+                      Set mode=top to get the functions taking the most time, which is usually the best place to start, or mode=folded to get folded stacks instead of the default flame graph tree.
+                      If the user wants a profile file set export_format=pprof for go tool pprof or export_format=speedscope for speedscope. The file is attached as an embedded resource.`,
+		Handler: GetProfilesHandler,
+	},
+	{
+		Name: "compare_profiles",
+		Description: `Compares the cpu profiles of a service between two time periods or two sets of containers to find out whether a change made the service slower and where.
+                      Returns the functions whose share of cpu time grew or shrank the most, or a differential folded stacks diff with mode=folded.
+                      Use get_version_for_service to find out when a new version of the service was deployed and compare the period before with the period after.`,
+		Handler: CompareProfilesHandler,
 	},
 	{
 		Name: "get_k8s_events",
@@ -110,9 +178,10 @@ And then you can call this tool (get_k8s_events) to get the specific events you
 		Handler:     GetK8sEventAttributeValuesForIndividualAttributeHandler,
 	},
 	{
-		Name:        "get_k8s_list",
-		Description: "List kubernetes resources for a resource kind and api version at a point in time or over a time range. Use this tool to find resource identity details and lifecycle timestamps.",
-		Handler:     GetK8sListHandler,
+		Name:                      "get_k8s_list",
+		Description:               "List kubernetes resources for a resource kind and api version at a point in time or over a time range. Use this tool to find resource identity details and lifecycle timestamps.",
+		Handler:                   GetK8sListHandler,
+		TruncateOversizedResponse: true,
 	},
 	{
 		Name:        "get_k8s_get",
@@ -174,11 +243,34 @@ And then you can call this tool (get_k8s_events) to get the specific events you
 		Description: "Get list of alerts from your Kubernetes cluster. These alerts are configured by the user in Metoro therefore it may not have full coverage for all the issues that might occur in the cluster.",
 		Handler:     GetAlertsHandler,
 	},
+	{
+		Name:        "get_alert",
+		Description: "Get the definition of a single alert by its ID including its name description MetoroQL query bucket size and evaluation rules with their thresholds and persistence settings. Use get_alerts to find the ID of an alert.",
+		Handler:     GetAlertHandler,
+	},
+	{
+		Name: "update_alert",
+		Description: `Update the name description MetoroQL query bucket size destinations or the condition threshold and persistence settings of an evaluation rule of an existing alert. Only the arguments that are set are changed.
+                      Set evaluation_rules to replace all evaluation rules of the alert e.g. to add a warning and a critical rule with their own thresholds persistence settings and destinations.
+                      Set dry_run=true first to get the changes that would be made as JSON pointer paths with the current and proposed values and check them with the user before updating the alert.`,
+		Handler: UpdateAlertHandler,
+	},
 	{
 		Name:        "get_alert_fires",
 		Description: "Get list of alert fires from your Kubernetes cluster. Alert fires are the instances when an alert is triggered. This tool provides information about the alert name the time it was triggered the time it recovered the environment and the service name (if available) and the alert trigger message.",
 		Handler:     GetAlertFiresHandler,
 	},
+	{
+		Name: "search_alert_fires",
+		Description: `Get the alert fires of all alerts in a time period e.g. to find out what fired overnight in prod. Filter by alert name pattern and by the environment and service the query of the alert filters on.
+                      Returns a timeline of the fires ordered by when they fired and per alert the number of fires the total time firing and the mean time to resolve ranked from the noisiest alert. Use get_alert_fires to get the fires of a single alert.`,
+		Handler: SearchAlertFiresHandler,
+	},
+	{
+		Name:        "list_alert_destinations",
+		Description: "Get the slack channels PagerDuty services email addresses and webhooks that the existing alerts notify and how many alerts notify each of them. Use this before setting the destinations of create_alert create_slo_alerts or update_alert so you use destinations that exist. Destinations that no alert notifies yet are not listed so ask the user for those.",
+		Handler:     ListAlertDestinationsHandler,
+	},
 	{
 		Name: "create_dashboard",
 		Description: `Create a dashboard with the described metrics. This tool is useful for creating a dashboard with the metrics you are interested in.
@@ -190,9 +282,30 @@ And then you can call this tool (get_k8s_events) to get the specific events you
 	{
 		Name: "create_alert",
 		Description: `Create an alert with the described metrics. This tool is useful for creating an alert with the timeseries data that you are interested in. How to use this tool:
-					 NEVER GUESS the attribute keys and values that will be used for filtering or splits. Always use trace_querier or log_querier or metric_querier to understand the available attribute keys and values for the type of data/timeseries you are interested in. Ask these tools for the available attribute keys and values and metric names etc before using this tool.`,
+					 NEVER GUESS the attribute keys and values that will be used for filtering or splits. Always use trace_querier or log_querier or metric_querier to understand the available attribute keys and values for the type of data/timeseries you are interested in. Ask these tools for the available attribute keys and values and metric names etc before using this tool.
+                      Set destinations to the slack channels PagerDuty services email addresses or webhooks to notify when the alert fires. Use list_alert_destinations to get the destinations the existing alerts notify.
+                      To alert on several severities set evaluation_rules instead of condition e.g. a warning rule above 80 for 5 of 10 datapoints and a critical rule above 95 for 1 datapoint each with their own destinations. Use the OutsideRange condition with lower_threshold and upper_threshold to alert when the value leaves a band.`,
 		Handler: CreateAlertHandler,
 	},
+	{
+		Name: "backtest_alert",
+		Description: `Find out how often an alert would have fired before creating it. Takes the same arguments as create_alert, fetches the last days of its timeseries with the bucket size of the alert and replays the condition with datapoints_to_alarm over the evaluation_window locally for every series.
+                      Returns the simulated fire intervals of every series that would have fired, the total fire time and how often the alert flapped i.e. fired again within an evaluation window of resolving. Use it to tune the threshold and persistence of noisy alerts before creating them with create_alert.`,
+		Handler: BacktestAlertHandler,
+	},
+	{
+		Name: "get_slo_status",
+		Description: `Get the status of an SLO defined as the share of good events of all events over a rolling window e.g. the traces of a service without errors of all its traces. Takes the good and total timeseries in the same shape as get_timeseries_data, the target percentage e.g. 99.9 and the window in days.
+                      Returns the attainment, the good bad and allowed bad events, the remaining error budget, the burn rates over the last 5m to 3d and which multi-window multi-burn-rate alerts would be firing now.
+                      NEVER GUESS the attribute keys and values used in the filters. Use get_attribute_keys and get_attribute_values first.`,
+		Handler: GetSLOStatusHandler,
+	},
+	{
+		Name: "create_slo_alerts",
+		Description: `Create the multi-window multi-burn-rate alerts of an SLO. Takes the same SLO definition as get_slo_status and creates 4 alerts on the error ratio of the SLO, 2 page alerts for burn rates of 14.4x over 1h and 6x over 6h and 2 ticket alerts for 3x over 1d and 1x over 3d (for a 30 day window).
+                      Check the SLO with get_slo_status before creating its alerts.`,
+		Handler: CreateSLOAlertsHandler,
+	},
 	{
 		Name:        "get_source_repository",
 		Description: "Get the source repository URL/path for a specific service. This tool is useful for finding where the code for a service is stored. You need to provide the service name time range and optionally specific environments to search in.",
@@ -0,0 +1,356 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+const (
+	traceAnalysisTopContributors = 5
+	// traceAnalysisMaxTreeLines keeps the rendered tree of very large traces within the response budget.
+	traceAnalysisMaxTreeLines = 400
+	// traceAnalysisMinCollapsedSiblings is the number of identical sibling leaf spans from which they are shown
+	// as a single line.
+	traceAnalysisMinCollapsedSiblings = 3
+)
+
+type traceSpanNode struct {
+	span     model.TraceEl
+	start    int64
+	end      int64
+	parent   *traceSpanNode
+	children []*traceSpanNode
+
+	// selfTime is the part of the span's duration not covered by any of its children.
+	selfTime int64
+	// criticalTime is the part of the span's duration that is on the critical path and not spent in a child.
+	criticalTime   int64
+	onCriticalPath bool
+
+	isError bool
+	// erroringAncestor is the closest ancestor that is an error span too.
+	erroringAncestor *traceSpanNode
+}
+
+type traceAnalysis struct {
+	spans []*traceSpanNode
+	roots []*traceSpanNode
+	// root is the longest root span which the critical path is calculated for.
+	root *traceSpanNode
+}
+
+// parseTraceSpans reads the spans from a spans response, which is either a list of spans or an object with a
+// spans list.
+func parseTraceSpans(body []byte) ([]model.TraceEl, error) {
+	var wrapped struct {
+		Spans []model.TraceEl `json:"spans"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err == nil {
+		return wrapped.Spans, nil
+	}
+
+	var spans []model.TraceEl
+	if err := json.Unmarshal(body, &spans); err != nil {
+		return nil, fmt.Errorf("error unmarshaling trace spans response: %v", err)
+	}
+	return spans, nil
+}
+
+func buildTraceAnalysis(spans []model.TraceEl) *traceAnalysis {
+	analysis := &traceAnalysis{}
+	nodes := map[string]*traceSpanNode{}
+	for _, span := range spans {
+		if _, ok := nodes[span.SpanId]; ok && span.SpanId != "" {
+			continue
+		}
+		start := epochTime(span.Time).UnixNano()
+		node := &traceSpanNode{span: span, start: start, end: start + max(span.Duration, 0), isError: isErrorSpan(span)}
+		if span.SpanId != "" {
+			nodes[span.SpanId] = node
+		}
+		analysis.spans = append(analysis.spans, node)
+	}
+
+	for _, node := range analysis.spans {
+		parent, ok := nodes[node.span.ParentSpanId]
+		if !ok || parent == node || isTraceSpanAncestor(node, parent) {
+			analysis.roots = append(analysis.roots, node)
+			continue
+		}
+		node.parent = parent
+		parent.children = append(parent.children, node)
+	}
+
+	for _, node := range analysis.spans {
+		sortTraceSpanNodes(node.children)
+		node.selfTime = node.end - node.start - coveredByChildren(node)
+		for ancestor := node.parent; ancestor != nil && node.isError; ancestor = ancestor.parent {
+			if ancestor.isError {
+				node.erroringAncestor = ancestor
+				break
+			}
+		}
+	}
+	sortTraceSpanNodes(analysis.roots)
+
+	for _, root := range analysis.roots {
+		if analysis.root == nil || root.end-root.start > analysis.root.end-analysis.root.start {
+			analysis.root = root
+		}
+	}
+	if analysis.root != nil {
+		markCriticalPath(analysis.root, analysis.root.end)
+	}
+	return analysis
+}
+
+// markCriticalPath walks backwards from the end of the span, each time following the child that finished last
+// before the current point in time. Time in between is spent in the span itself.
+func markCriticalPath(node *traceSpanNode, limit int64) {
+	node.onCriticalPath = true
+	cursor := min(node.end, limit)
+	for cursor > node.start {
+		var next *traceSpanNode
+		nextEnd := int64(0)
+		for _, child := range node.children {
+			if child.start >= cursor {
+				continue
+			}
+			childEnd := min(child.end, cursor)
+			if next == nil || childEnd > nextEnd {
+				next, nextEnd = child, childEnd
+			}
+		}
+
+		if next == nil {
+			node.criticalTime += cursor - node.start
+			return
+		}
+		node.criticalTime += cursor - nextEnd
+		markCriticalPath(next, nextEnd)
+		cursor = next.start
+	}
+}
+
+func coveredByChildren(node *traceSpanNode) int64 {
+	covered := int64(0)
+	cursor := node.start
+	for _, child := range node.children {
+		start, end := max(child.start, cursor), min(child.end, node.end)
+		if end > start {
+			covered += end - start
+			cursor = end
+		}
+	}
+	return covered
+}
+
+func isTraceSpanAncestor(node *traceSpanNode, candidate *traceSpanNode) bool {
+	for ancestor := candidate; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == node {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTraceSpanNodes(nodes []*traceSpanNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].start < nodes[j].start
+	})
+}
+
+func isErrorSpan(span model.TraceEl) bool {
+	if strings.Contains(strings.ToUpper(span.StatusCode), "ERROR") {
+		return true
+	}
+	for _, key := range []string{"http.status_code", "http.response.status_code"} {
+		if code, err := strconv.Atoi(span.SpanAttributes[key]); err == nil && code >= 500 {
+			return true
+		}
+	}
+	return false
+}
+
+func traceSpanLabel(node *traceSpanNode) string {
+	service := node.span.DisplayServiceName
+	if service == "" {
+		service = node.span.ServiceName
+	}
+	return fmt.Sprintf("%s: %s", service, node.span.SpanName)
+}
+
+// render returns the analysis as a compact indented tree preceded by the critical path contributors and errors.
+func (a *traceAnalysis) render(traceId string) string {
+	if a.root == nil {
+		return fmt.Sprintf("Trace %s has no spans.", traceId)
+	}
+
+	var builder strings.Builder
+	total := a.root.end - a.root.start
+	fmt.Fprintf(&builder, "Trace %s: %d spans, duration %s, root %s\n", traceId, len(a.spans), formatHumanReadableDuration(total), traceSpanLabel(a.root))
+
+	builder.WriteString("\nLargest contributors to end-to-end latency (time on the critical path spent in the span itself):\n")
+	for i, node := range a.topCriticalContributors() {
+		fmt.Fprintf(&builder, "%d. %s %s (%s of the trace)\n", i+1, traceSpanLabel(node), formatHumanReadableDuration(node.criticalTime), formatTracePercentage(node.criticalTime, total))
+	}
+
+	var errorOrigins []string
+	for _, node := range a.spans {
+		if node.isError && !hasErroringChild(node) {
+			errorOrigins = append(errorOrigins, a.describeError(node))
+		}
+	}
+	if len(errorOrigins) > 0 {
+		builder.WriteString("\nError spans without an erroring child (likely where errors originate):\n")
+		for _, description := range errorOrigins {
+			builder.WriteString("- " + description + "\n")
+		}
+	}
+
+	builder.WriteString("\nSpan tree. Each line is: flags, service: span name, start offset, duration, self time. Flags: * on the critical path, ! error.\n")
+	lines := 0
+	a.walk(func(group []*traceSpanNode, depth int) {
+		lines++
+		if lines <= traceAnalysisMaxTreeLines {
+			a.renderNode(&builder, group, depth)
+		}
+	})
+	if lines > traceAnalysisMaxTreeLines {
+		fmt.Fprintf(&builder, "... %d more lines not shown\n", lines-traceAnalysisMaxTreeLines)
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// walk visits the span tree depth first with identical sibling spans grouped, see groupTraceSpanSiblings.
+func (a *traceAnalysis) walk(visit func(group []*traceSpanNode, depth int)) {
+	var walkGroup func(group []*traceSpanNode, depth int)
+	walkGroup = func(group []*traceSpanNode, depth int) {
+		visit(group, depth)
+		if len(group) > 1 {
+			return
+		}
+		for _, siblings := range groupTraceSpanSiblings(group[0].children) {
+			walkGroup(siblings, depth+1)
+		}
+	}
+	for _, root := range a.roots {
+		walkGroup([]*traceSpanNode{root}, 0)
+	}
+}
+
+// renderNode renders a group of identical sibling spans, which is usually a single span.
+func (a *traceAnalysis) renderNode(builder *strings.Builder, group []*traceSpanNode, depth int) {
+	node := group[0]
+	indent := strings.Repeat("  ", depth)
+	offset := formatHumanReadableDuration(max(node.start-a.root.start, 0))
+	if len(group) > 1 {
+		totalDuration, maxDuration := int64(0), int64(0)
+		for _, member := range group {
+			totalDuration += member.end - member.start
+			maxDuration = max(maxDuration, member.end-member.start)
+		}
+		fmt.Fprintf(builder, "%s   %s x%d, first at +%s, total %s, max %s\n", indent, traceSpanLabel(node), len(group), offset, formatHumanReadableDuration(totalDuration), formatHumanReadableDuration(maxDuration))
+		return
+	}
+
+	line := fmt.Sprintf("%s%-2s %s +%s %s (self %s)", indent, traceSpanFlags(node), traceSpanLabel(node), offset, formatHumanReadableDuration(node.end-node.start), formatHumanReadableDuration(node.selfTime))
+	if node.isError {
+		line += " " + a.describeErrorStatus(node)
+	}
+	builder.WriteString(line + "\n")
+}
+
+func traceSpanFlags(node *traceSpanNode) string {
+	flags := ""
+	if node.onCriticalPath {
+		flags += "*"
+	}
+	if node.isError {
+		flags += "!"
+	}
+	return flags
+}
+
+// groupTraceSpanSiblings groups leaf children with the same service and name that are neither on the critical path
+// nor errors, like the queries of an N+1 loop, so they take up one line.
+func groupTraceSpanSiblings(children []*traceSpanNode) [][]*traceSpanNode {
+	collapsible := map[string][]*traceSpanNode{}
+	for _, child := range children {
+		if isCollapsibleTraceSpan(child) {
+			collapsible[traceSpanLabel(child)] = append(collapsible[traceSpanLabel(child)], child)
+		}
+	}
+
+	var groups [][]*traceSpanNode
+	emitted := map[string]bool{}
+	for _, child := range children {
+		label := traceSpanLabel(child)
+		if isCollapsibleTraceSpan(child) && len(collapsible[label]) >= traceAnalysisMinCollapsedSiblings {
+			if !emitted[label] {
+				groups = append(groups, collapsible[label])
+				emitted[label] = true
+			}
+			continue
+		}
+		groups = append(groups, []*traceSpanNode{child})
+	}
+	return groups
+}
+
+func isCollapsibleTraceSpan(node *traceSpanNode) bool {
+	return len(node.children) == 0 && !node.onCriticalPath && !node.isError
+}
+
+func hasErroringChild(node *traceSpanNode) bool {
+	for _, child := range node.children {
+		if child.isError {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *traceAnalysis) describeError(node *traceSpanNode) string {
+	return fmt.Sprintf("%s %s", traceSpanLabel(node), a.describeErrorStatus(node))
+}
+
+func (a *traceAnalysis) describeErrorStatus(node *traceSpanNode) string {
+	status := node.span.StatusCode
+	if code := node.span.SpanAttributes["http.status_code"]; code != "" {
+		status = strings.TrimSpace(status + " http " + code)
+	}
+	description := fmt.Sprintf("[error %s", strings.TrimSpace(status))
+	if node.erroringAncestor != nil {
+		description += ", first erroring ancestor: " + traceSpanLabel(node.erroringAncestor)
+	}
+	return description + "]"
+}
+
+func (a *traceAnalysis) topCriticalContributors() []*traceSpanNode {
+	var contributors []*traceSpanNode
+	for _, node := range a.spans {
+		if node.criticalTime > 0 {
+			contributors = append(contributors, node)
+		}
+	}
+	sort.SliceStable(contributors, func(i, j int) bool {
+		return contributors[i].criticalTime > contributors[j].criticalTime
+	})
+	if len(contributors) > traceAnalysisTopContributors {
+		contributors = contributors[:traceAnalysisTopContributors]
+	}
+	return contributors
+}
+
+func formatTracePercentage(part int64, total int64) string {
+	if total <= 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)/float64(total)*100)
+}
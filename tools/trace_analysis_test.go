@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+const testTraceStartMs = int64(1771495200000)
+
+func newTestSpan(spanId string, parentSpanId string, service string, name string, startMs int64, durationMs int64) model.TraceEl {
+	return model.TraceEl{
+		SpanId:       spanId,
+		ParentSpanId: parentSpanId,
+		ServiceName:  service,
+		SpanName:     name,
+		Time:         testTraceStartMs + startMs,
+		Duration:     durationMs * 1000000,
+	}
+}
+
+// newTestTraceSpans returns a checkout request which authenticates while looking up the cart in parallel and then
+// charges a payment whose database insert fails.
+func newTestTraceSpans() []model.TraceEl {
+	charge := newTestSpan("charge", "root", "payments", "charge", 20, 70)
+	charge.StatusCode = "STATUS_CODE_ERROR"
+	insert := newTestSpan("insert", "charge", "payments-db", "INSERT payments", 30, 50)
+	insert.StatusCode = "STATUS_CODE_ERROR"
+
+	return []model.TraceEl{
+		newTestSpan("root", "", "frontend", "GET /checkout", 0, 100),
+		newTestSpan("auth", "root", "auth", "verify", 0, 10),
+		newTestSpan("redis-1", "root", "cart", "redis GET", 2, 1),
+		newTestSpan("redis-2", "root", "cart", "redis GET", 3, 1),
+		newTestSpan("redis-3", "root", "cart", "redis GET", 4, 1),
+		newTestSpan("redis-4", "root", "cart", "redis GET", 5, 1),
+		insert,
+		charge,
+	}
+}
+
+func TestBuildTraceAnalysis(t *testing.T) {
+	analysis := buildTraceAnalysis(newTestTraceSpans())
+
+	nodes := map[string]*traceSpanNode{}
+	for _, node := range analysis.spans {
+		nodes[node.span.SpanId] = node
+	}
+
+	if len(analysis.roots) != 1 || analysis.root != nodes["root"] {
+		t.Fatalf("expected a single root span")
+	}
+	if len(nodes["root"].children) != 6 || nodes["charge"].children[0] != nodes["insert"] {
+		t.Fatalf("unexpected tree")
+	}
+
+	// The root is covered by its children from 0 to 10 and 20 to 90 milliseconds.
+	if nodes["root"].selfTime != 20*1000000 || nodes["charge"].selfTime != 20*1000000 {
+		t.Fatalf("unexpected self times %d %d", nodes["root"].selfTime, nodes["charge"].selfTime)
+	}
+
+	for _, id := range []string{"root", "auth", "charge", "insert"} {
+		if !nodes[id].onCriticalPath {
+			t.Fatalf("expected %s to be on the critical path", id)
+		}
+	}
+	if nodes["redis-4"].onCriticalPath {
+		t.Fatalf("expected the cart lookups to be off the critical path")
+	}
+
+	criticalTotal := int64(0)
+	for _, node := range analysis.spans {
+		criticalTotal += node.criticalTime
+	}
+	if criticalTotal != 100*1000000 {
+		t.Fatalf("expected the critical path to cover the whole trace, got %d", criticalTotal)
+	}
+	if top := analysis.topCriticalContributors(); top[0] != nodes["insert"] || top[0].criticalTime != 50*1000000 {
+		t.Fatalf("expected the insert to contribute most to the latency")
+	}
+
+	if nodes["insert"].erroringAncestor != nodes["charge"] || nodes["charge"].erroringAncestor != nil {
+		t.Fatalf("unexpected erroring ancestors")
+	}
+}
+
+func TestBuildTraceAnalysisTreatsOrphansAsRoots(t *testing.T) {
+	analysis := buildTraceAnalysis([]model.TraceEl{
+		newTestSpan("a", "missing", "frontend", "GET /", 0, 10),
+		newTestSpan("b", "c", "worker", "job", 0, 50),
+		newTestSpan("c", "b", "worker", "step", 5, 5),
+	})
+
+	if len(analysis.roots) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(analysis.roots))
+	}
+	// The cycle between b and c is broken at c.
+	if analysis.roots[1].span.SpanId != "c" || analysis.roots[1].children[0].span.SpanId != "b" {
+		t.Fatalf("expected the cycle to be broken")
+	}
+	if analysis.root.span.SpanId != "a" {
+		t.Fatalf("expected the longest root to be analysed, got %s", analysis.root.span.SpanId)
+	}
+}
+
+func TestRenderTraceAnalysis(t *testing.T) {
+	rendered := buildTraceAnalysis(newTestTraceSpans()).render("trace-1")
+
+	expectedLines := []string{
+		"Trace trace-1: 8 spans, duration 100.00 milliseconds, root frontend: GET /checkout",
+		"1. payments-db: INSERT payments 50.00 milliseconds (50.0% of the trace)",
+		"- payments-db: INSERT payments [error STATUS_CODE_ERROR, first erroring ancestor: payments: charge]",
+		"*  frontend: GET /checkout +0 nanoseconds 100.00 milliseconds (self 20.00 milliseconds)",
+		"  *  auth: verify +0 nanoseconds 10.00 milliseconds (self 10.00 milliseconds)",
+		"     cart: redis GET x4, first at +2.00 milliseconds, total 4.00 milliseconds, max 1.00 milliseconds",
+		"  *! payments: charge +20.00 milliseconds 70.00 milliseconds (self 20.00 milliseconds) [error STATUS_CODE_ERROR]",
+		"    *! payments-db: INSERT payments +30.00 milliseconds 50.00 milliseconds (self 50.00 milliseconds) [error STATUS_CODE_ERROR, first erroring ancestor: payments: charge]",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(rendered, line) {
+			t.Fatalf("expected line %q in\n%s", line, rendered)
+		}
+	}
+}
+
+func TestGetTraceSpansHandlerAnalysisMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/spans" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(map[string]any{"spans": newTestTraceSpans()})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	response, err := GetTraceSpansHandler(context.Background(), GetTraceSpansHandlerArgs{
+		TimeConfig: absoluteTimeConfig("2026-02-19T10:00:00Z", "2026-02-19T10:05:00Z"),
+		TraceId:    "trace-1",
+		Mode:       "analysis",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if text := response.Content[0].TextContent.Text; !strings.HasPrefix(text, "Trace trace-1: 8 spans") {
+		t.Fatalf("unexpected analysis %s", text)
+	}
+
+	if _, err := GetTraceSpansHandler(context.Background(), GetTraceSpansHandlerArgs{TraceId: "trace-1", Mode: "flamegraph"}); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	traceWaterfallBarWidth = 60
+	// traceWaterfallMaxNameWidth keeps deeply nested spans with long names from pushing the bars off screen.
+	traceWaterfallMaxNameWidth = 70
+	// traceGanttMaxTasks keeps charts of very large traces small enough for Mermaid to render.
+	traceGanttMaxTasks = 200
+)
+
+// traceSpanGroupInterval returns when the first span of a group started and the last one ended.
+func traceSpanGroupInterval(group []*traceSpanNode) (int64, int64) {
+	start, end := group[0].start, group[0].end
+	for _, node := range group[1:] {
+		start, end = min(start, node.start), max(end, node.end)
+	}
+	return start, end
+}
+
+func traceSpanGroupLabel(group []*traceSpanNode) string {
+	if len(group) > 1 {
+		return fmt.Sprintf("%s x%d", traceSpanLabel(group[0]), len(group))
+	}
+	return traceSpanLabel(group[0])
+}
+
+// renderWaterfall returns the span tree as a text waterfall in a fenced code block. Each line shows the span with a
+// bar proportional to when it ran within the trace, its start offset and its duration.
+func (a *traceAnalysis) renderWaterfall(traceId string) string {
+	if a.root == nil {
+		return fmt.Sprintf("Trace %s has no spans.", traceId)
+	}
+
+	traceStart, traceEnd := a.root.start, a.root.end
+	for _, root := range a.roots {
+		traceStart, traceEnd = min(traceStart, root.start), max(traceEnd, root.end)
+	}
+	total := max(traceEnd-traceStart, 1)
+
+	type waterfallLine struct {
+		name       string
+		start, end int64
+		flags      string
+	}
+	var lines []waterfallLine
+	nameWidth, count := 0, 0
+	a.walk(func(group []*traceSpanNode, depth int) {
+		count++
+		if count > traceAnalysisMaxTreeLines {
+			return
+		}
+		name, _ := truncateWithSuffix(strings.Repeat("  ", depth)+traceSpanGroupLabel(group), traceWaterfallMaxNameWidth)
+		start, end := traceSpanGroupInterval(group)
+		flags := ""
+		if len(group) == 1 {
+			flags = traceSpanFlags(group[0])
+		}
+		lines = append(lines, waterfallLine{name: name, start: start, end: end, flags: flags})
+		nameWidth = max(nameWidth, len([]rune(name)))
+	})
+
+	var builder strings.Builder
+	builder.WriteString("```text\n")
+	fmt.Fprintf(&builder, "Trace %s, %d spans, %s. Flags: * on the critical path, ! error.\n", traceId, len(a.spans), formatHumanReadableDuration(total))
+	for _, line := range lines {
+		from := int((line.start - traceStart) * traceWaterfallBarWidth / total)
+		to := int(((line.end-traceStart)*traceWaterfallBarWidth + total - 1) / total)
+		from = min(max(from, 0), traceWaterfallBarWidth-1)
+		to = min(max(to, from+1), traceWaterfallBarWidth)
+		bar := strings.Repeat(" ", from) + strings.Repeat("#", to-from) + strings.Repeat(" ", traceWaterfallBarWidth-to)
+
+		padding := strings.Repeat(" ", nameWidth-len([]rune(line.name)))
+		fmt.Fprintf(&builder, "%-2s %s%s |%s| +%s %s\n", line.flags, line.name, padding, bar, formatHumanReadableDuration(line.start-traceStart), formatHumanReadableDuration(line.end-line.start))
+	}
+	if count > traceAnalysisMaxTreeLines {
+		fmt.Fprintf(&builder, "... %d more lines not shown\n", count-traceAnalysisMaxTreeLines)
+	}
+	builder.WriteString("```")
+	return builder.String()
+}
+
+// renderMermaidGantt returns the trace as a Mermaid Gantt chart in a fenced code block with a section per service.
+// Times are milliseconds since the start of the trace. Spans on the critical path are marked crit.
+func (a *traceAnalysis) renderMermaidGantt(traceId string) string {
+	if a.root == nil {
+		return fmt.Sprintf("Trace %s has no spans.", traceId)
+	}
+
+	traceStart := a.root.start
+	for _, root := range a.roots {
+		traceStart = min(traceStart, root.start)
+	}
+
+	var services []string
+	tasks := map[string][]string{}
+	count := 0
+	a.walk(func(group []*traceSpanNode, depth int) {
+		count++
+		if count > traceGanttMaxTasks {
+			return
+		}
+		node := group[0]
+		service := node.span.DisplayServiceName
+		if service == "" {
+			service = node.span.ServiceName
+		}
+		if _, ok := tasks[service]; !ok {
+			services = append(services, service)
+		}
+
+		name := node.span.SpanName
+		if len(group) > 1 {
+			name = fmt.Sprintf("%s x%d", name, len(group))
+		}
+		if len(group) == 1 && node.isError {
+			name = "ERROR " + name
+		}
+		tags := ""
+		if len(group) == 1 && node.onCriticalPath {
+			tags = "crit, "
+		}
+
+		start, end := traceSpanGroupInterval(group)
+		startMs := (start - traceStart) / 1000000
+		endMs := max((end-traceStart+999999)/1000000, startMs+1)
+		tasks[service] = append(tasks[service], fmt.Sprintf("    %s :%sspan%d, %d, %d", escapeMermaidText(name), tags, count, startMs, endMs))
+	})
+
+	var builder strings.Builder
+	builder.WriteString("```mermaid\n")
+	builder.WriteString("gantt\n")
+	fmt.Fprintf(&builder, "    title Trace %s (%s)\n", escapeMermaidText(traceId), formatHumanReadableDuration(a.root.end-a.root.start))
+	builder.WriteString("    dateFormat x\n")
+	builder.WriteString("    axisFormat %S.%L s\n")
+	for _, service := range services {
+		fmt.Fprintf(&builder, "    section %s\n", escapeMermaidText(service))
+		for _, task := range tasks[service] {
+			builder.WriteString(task + "\n")
+		}
+	}
+	builder.WriteString("```")
+	if count > traceGanttMaxTasks {
+		fmt.Fprintf(&builder, "\n%d more spans not shown.", count-traceGanttMaxTasks)
+	}
+	return builder.String()
+}
+
+// escapeMermaidText removes the characters Mermaid uses to separate the parts of a Gantt line.
+func escapeMermaidText(text string) string {
+	text = strings.NewReplacer(":", " ", ";", " ", "#", "", "\n", " ", "`", "'").Replace(text)
+	if strings.TrimSpace(text) == "" {
+		return "unknown"
+	}
+	return text
+}
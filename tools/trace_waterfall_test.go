@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderWaterfall(t *testing.T) {
+	rendered := buildTraceAnalysis(newTestTraceSpans()).renderWaterfall("trace-1")
+
+	lines := strings.Split(rendered, "\n")
+	if lines[0] != "```text" || lines[len(lines)-1] != "```" {
+		t.Fatalf("expected a fenced code block, got\n%s", rendered)
+	}
+
+	expectedLines := []string{
+		"*  frontend: GET /checkout          |############################################################| +0 nanoseconds 100.00 milliseconds",
+		"     cart: redis GET x4             | ###                                                        | +2.00 milliseconds 4.00 milliseconds",
+		"*!     payments-db: INSERT payments |                  ##############################            | +30.00 milliseconds 50.00 milliseconds",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(rendered, line+"\n") {
+			t.Fatalf("expected line %q in\n%s", line, rendered)
+		}
+	}
+}
+
+func TestRenderMermaidGantt(t *testing.T) {
+	spans := newTestTraceSpans()
+	spans[0].SpanName = "GET /checkout: v2; #1"
+	rendered := buildTraceAnalysis(spans).renderMermaidGantt("trace-1")
+
+	expected := strings.Join([]string{
+		"```mermaid",
+		"gantt",
+		"    title Trace trace-1 (100.00 milliseconds)",
+		"    dateFormat x",
+		"    axisFormat %S.%L s",
+		"    section frontend",
+		"    GET /checkout  v2  1 :crit, span1, 0, 100",
+		"    section auth",
+		"    verify :crit, span2, 0, 10",
+		"    section cart",
+		"    redis GET x4 :span3, 2, 6",
+		"    section payments",
+		"    ERROR charge :crit, span4, 20, 90",
+		"    section payments-db",
+		"    ERROR INSERT payments :crit, span5, 30, 80",
+		"```",
+	}, "\n")
+	if rendered != expected {
+		t.Fatalf("unexpected chart\n%s", rendered)
+	}
+}
@@ -37,7 +37,7 @@ func UpdateAIIssueHandler(ctx context.Context, arguments UpdateAIIssueHandlerArg
 	}
 
 	endpoint := fmt.Sprintf("aiIssue?uuid=%s", arguments.IssueUUID)
-	responseBody, err := utils.MakeMetoroAPIRequest("PUT", endpoint, bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIRequest(ctx, "PUT", endpoint, bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to update AI issue: %w", err)
 	}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+type UpdateAlertHandlerArgs struct {
+	AlertId           string                 `json:"alert_id" jsonschema:"required,description=The ID of the alert to update. Use get_alerts to find the ID of an alert."`
+	AlertName         *string                `json:"alert_name,omitempty" jsonschema:"description=Optional new name of the alert"`
+	AlertDescription  *string                `json:"alert_description,omitempty" jsonschema:"description=Optional new description of the alert"`
+	Query             *string                `json:"query,omitempty" jsonschema:"description=Optional new MetoroQL query of the alert. Use explain_timeseries_query to get the query of timeseries. Only balanced brackets and terminated strings are checked before the alert is updated."`
+	BucketSize        *int64                 `json:"bucket_size,omitempty" jsonschema:"description=Optional new size of each datapoint bucket of the query in seconds"`
+	RuleName          string                 `json:"rule_name,omitempty" jsonschema:"description=The name of the evaluation rule to change. Only required when the alert has more than one evaluation rule."`
+	Condition         *string                `json:"condition,omitempty" jsonschema:"enum=GreaterThan,enum=LessThan,enum=GreaterThanOrEqual,enum=LessThanOrEqual,description=Optional new arithmetic comparison of the evaluation rule"`
+	Threshold         *float64               `json:"threshold,omitempty" jsonschema:"description=Optional new threshold of the evaluation rule"`
+	DatapointsToAlarm *int64                 `json:"datapoints_to_alarm,omitempty" jsonschema:"description=Optional new number of datapoints that need to breach the threshold for the alert to fire"`
+	EvaluationWindow  *int64                 `json:"evaluation_window,omitempty" jsonschema:"description=Optional new evaluation window in number of datapoints"`
+	Destinations      *[]AlertDestination    `json:"destinations,omitempty" jsonschema:"description=Optional destinations that replace the destinations the alert notifies. Changes the evaluation rule set with rule_name or every evaluation rule if rule_name is not set. Set to an empty list to stop notifying. Use list_alert_destinations to get the destinations the existing alerts notify."`
+	EvaluationRules   *[]AlertEvaluationRule `json:"evaluation_rules,omitempty" jsonschema:"description=Optional evaluation rules that replace all evaluation rules of the alert e.g. to add a critical rule next to a warning rule. Can't be combined with rule_name condition threshold datapoints_to_alarm evaluation_window or destinations."`
+	DryRun            bool                   `json:"dry_run,omitempty" jsonschema:"description=If true the alert is not updated and only the changes that would be made are returned. Use this to check the changes with the user before updating the alert."`
+}
+
+func UpdateAlertHandler(ctx context.Context, arguments UpdateAlertHandlerArgs) (*mcpgolang.ToolResponse, error) {
+	alert, err := getAlert(ctx, arguments.AlertId)
+	if err != nil {
+		return nil, err
+	}
+	current, err := alert.model()
+	if err != nil {
+		return nil, err
+	}
+	proposed, err := applyAlertUpdate(current, arguments)
+	if err != nil {
+		return nil, err
+	}
+	if arguments.Destinations != nil || arguments.EvaluationRules != nil {
+		if err := resolveAlertActionTypes(ctx, &proposed); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := diffAlertDocuments(current, proposed)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("the update doesn't change the alert")
+	}
+
+	response := alertChangeResponse{AlertId: arguments.AlertId, DryRun: arguments.DryRun, Changes: changes}
+	if !arguments.DryRun {
+		resp, err := setAlertMetoroCall(ctx, model.CreateUpdateAlertRequest{Alert: proposed})
+		if err != nil {
+			return nil, fmt.Errorf("error updating alert: %v", err)
+		}
+		response.Response = rawJSONResponse(resp)
+	}
+	return alertChangeToolResponse(response)
+}
+
+// applyAlertUpdate returns a copy of the alert with the changes of the arguments.
+func applyAlertUpdate(alert model.Alert, arguments UpdateAlertHandlerArgs) (model.Alert, error) {
+	// Copy the alert through JSON so the changes don't modify the current alert through shared pointers and slices.
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return model.Alert{}, fmt.Errorf("error marshaling alert: %v", err)
+	}
+	var updated model.Alert
+	if err := json.Unmarshal(body, &updated); err != nil {
+		return model.Alert{}, fmt.Errorf("error unmarshaling alert: %v", err)
+	}
+
+	if arguments.AlertName != nil {
+		if strings.TrimSpace(*arguments.AlertName) == "" {
+			return model.Alert{}, fmt.Errorf("alert_name can't be empty")
+		}
+		updated.Metadata.Name = *arguments.AlertName
+	}
+	if arguments.AlertDescription != nil {
+		description := *arguments.AlertDescription
+		updated.Metadata.Description = &description
+	}
+
+	if arguments.Query != nil || arguments.BucketSize != nil {
+		if updated.Timeseries.Expression.MetoroQLTimeseries == nil {
+			return model.Alert{}, fmt.Errorf("the alert doesn't have a MetoroQL query to update")
+		}
+		if arguments.Query != nil {
+			query := strings.TrimSpace(*arguments.Query)
+			if err := validateMetoroQL(query); err != nil {
+				return model.Alert{}, err
+			}
+			updated.Timeseries.Expression.MetoroQLTimeseries.Query = query
+		}
+		if arguments.BucketSize != nil {
+			if *arguments.BucketSize <= 0 {
+				return model.Alert{}, fmt.Errorf("bucket_size must be positive")
+			}
+			updated.Timeseries.Expression.MetoroQLTimeseries.BucketSize = *arguments.BucketSize
+		}
+	}
+
+	if arguments.EvaluationRules != nil {
+		if arguments.RuleName != "" || arguments.Condition != nil || arguments.Threshold != nil || arguments.DatapointsToAlarm != nil || arguments.EvaluationWindow != nil || arguments.Destinations != nil {
+			return model.Alert{}, fmt.Errorf("evaluation_rules replaces all rules and can't be combined with rule_name condition threshold datapoints_to_alarm evaluation_window or destinations")
+		}
+		if len(*arguments.EvaluationRules) == 0 {
+			return model.Alert{}, fmt.Errorf("evaluation_rules needs at least one rule")
+		}
+		updated.Timeseries.EvaluationRules, err = alertEvaluationRulesToConditions(*arguments.EvaluationRules, nil)
+		if err != nil {
+			return model.Alert{}, err
+		}
+		return updated, nil
+	}
+
+	if arguments.Destinations != nil {
+		actions, err := alertDestinationsToActions(*arguments.Destinations)
+		if err != nil {
+			return model.Alert{}, err
+		}
+		if arguments.RuleName == "" {
+			setAlertActions(&updated, actions)
+		} else {
+			rule, err := findAlertRule(updated, arguments.RuleName)
+			if err != nil {
+				return model.Alert{}, err
+			}
+			rule.Actions = actions
+		}
+	}
+
+	if arguments.Condition == nil && arguments.Threshold == nil && arguments.DatapointsToAlarm == nil && arguments.EvaluationWindow == nil {
+		return updated, nil
+	}
+	rule, err := findAlertRule(updated, arguments.RuleName)
+	if err != nil {
+		return model.Alert{}, err
+	}
+	if rule.Static == nil {
+		return model.Alert{}, fmt.Errorf("evaluation rule %q is not a static threshold rule", rule.Name)
+	}
+	if arguments.Condition != nil || arguments.Threshold != nil {
+		if len(rule.Static.Operators) != 1 {
+			return model.Alert{}, fmt.Errorf("evaluation rule %q has %d operators, only rules with a single operator can be updated", rule.Name, len(rule.Static.Operators))
+		}
+		if arguments.Condition != nil {
+			operator, err := alertOperatorType(*arguments.Condition)
+			if err != nil {
+				return model.Alert{}, err
+			}
+			rule.Static.Operators[0].Operator = operator
+		}
+		if arguments.Threshold != nil {
+			rule.Static.Operators[0].Threshold = *arguments.Threshold
+		}
+	}
+	persistence := &rule.Static.PersistenceSettings
+	if arguments.DatapointsToAlarm != nil {
+		persistence.DatapointsToAlarm = *arguments.DatapointsToAlarm
+	}
+	if arguments.EvaluationWindow != nil {
+		persistence.DatapointsInEvaluationWindow = *arguments.EvaluationWindow
+	}
+	if err := validateAlertPersistence(persistence.DatapointsToAlarm, persistence.DatapointsInEvaluationWindow); err != nil {
+		return model.Alert{}, err
+	}
+	return updated, nil
+}
+
+// findAlertRule returns the evaluation rule with the name, which can be left out when the alert has a single rule.
+func findAlertRule(alert model.Alert, ruleName string) (*model.Condition, error) {
+	rules := alert.Timeseries.EvaluationRules
+	if ruleName == "" {
+		if len(rules) != 1 {
+			return nil, fmt.Errorf("the alert has %d evaluation rules, set rule_name to one of: %s", len(rules), alertRuleNames(rules))
+		}
+		return &rules[0], nil
+	}
+	for i := range rules {
+		if rules[i].Name == ruleName {
+			return &rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("the alert has no evaluation rule %q, set rule_name to one of: %s", ruleName, alertRuleNames(rules))
+}
+
+func alertRuleNames(rules []model.Condition) string {
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// rawJSONResponse keeps a JSON response as is and quotes anything else so it can be embedded in a JSON response.
+func rawJSONResponse(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+func alertChangeToolResponse(response alertChangeResponse) (*mcpgolang.ToolResponse, error) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling alert changes: %v", err)
+	}
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(body))), nil
+}
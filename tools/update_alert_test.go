@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/metoro-io/metoro-mcp-server/model"
+)
+
+func newTestAlert() model.Alert {
+	description := "Checkout error rate is high"
+	conditionType := model.STATIC
+	alertType := model.TIMESERIES
+	return model.Alert{
+		Metadata: model.MetadataObject{Name: "Checkout errors", Description: &description, Id: "alert-1"},
+		Type:     &alertType,
+		Timeseries: model.TimeseriesConfig{
+			Expression: model.ExpressionConfig{MetoroQLTimeseries: &model.MetoroQlTimeseries{Query: "sum(errors)", BucketSize: 60}},
+			EvaluationRules: []model.Condition{{
+				Name: "Alert Condition",
+				Type: &conditionType,
+				Static: &model.StaticCondition{
+					Operators:           []model.OperatorConfig{{Operator: model.GREATER_THAN, Threshold: 10}},
+					PersistenceSettings: model.PersistenceSettings{DatapointsToAlarm: 1, DatapointsInEvaluationWindow: 3},
+				},
+			}},
+		},
+	}
+}
+
+// alertTestServer serves the alert from GET /alert and records the other requests by path.
+type alertTestServer struct {
+	mu       sync.Mutex
+	requests map[string][]byte
+}
+
+func newAlertTestServer(t *testing.T, alert model.Alert) *alertTestServer {
+	t.Helper()
+	recorded := &alertTestServer{requests: map[string][]byte{}}
+	alertBody, err := json.Marshal(map[string][]model.Alert{"alerts": {alert}})
+	if err != nil {
+		t.Fatalf("failed to encode alert: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/searchAlerts" {
+			_, _ = w.Write(alertBody)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		recorded.mu.Lock()
+		recorded.requests[r.Method+" "+r.URL.Path] = body
+		recorded.mu.Unlock()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+	setMetoroAPIEnv(t, server.URL)
+	return recorded
+}
+
+func (s *alertTestServer) request(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, ok := s.requests[key]
+	return body, ok
+}
+
+func decodeAlertChangeResponse(t *testing.T, text string) alertChangeResponse {
+	t.Helper()
+	var response alertChangeResponse
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return response
+}
+
+func TestDiffJSON(t *testing.T) {
+	var current, proposed any
+	_ = json.Unmarshal([]byte(`{"a":1,"b":{"c":[1,2,3]},"d/e":"x"}`), &current)
+	_ = json.Unmarshal([]byte(`{"a":1,"b":{"c":[1,5]},"f":true}`), &proposed)
+
+	changes := diffJSON(nil, current, proposed)
+	expected := []jsonChange{
+		{Op: jsonChangeReplace, Path: "/b/c/1", From: float64(2), To: float64(5)},
+		{Op: jsonChangeRemove, Path: "/b/c/2", From: float64(3)},
+		{Op: jsonChangeRemove, Path: "/d~1e", From: "x"},
+		{Op: jsonChangeAdd, Path: "/f", To: true},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("expected %+v, got %+v", expected[i], changes[i])
+		}
+	}
+}
+
+func TestGetAlertHandler(t *testing.T) {
+	newAlertTestServer(t, newTestAlert())
+
+	response, err := GetAlertHandler(context.Background(), GetAlertHandlerArgs{AlertId: "alert-1"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(response.Content[0].TextContent.Text, `"query":"sum(errors)"`) {
+		t.Fatalf("unexpected response %s", response.Content[0].TextContent.Text)
+	}
+	if _, err := getAlert(context.Background(), "missing"); err == nil {
+		t.Fatalf("expected error for unknown alert")
+	}
+}
+
+func TestSearchedAlertIgnoresUnknownFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"alerts":[{"metadata":{"id":"alert-1","name":"Errors","owner":"team"},"timeseries":{"expression":{"metoroQLTimeseries":{"query":"sum(errors)","bucketSize":60}},"evaluationRules":[]},"createdAt":1}],"total":1}`))
+	}))
+	defer server.Close()
+
+	setMetoroAPIEnv(t, server.URL)
+
+	response, err := GetAlertHandler(context.Background(), GetAlertHandlerArgs{AlertId: "alert-1"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(response.Content[0].TextContent.Text, `"createdAt":1`) {
+		t.Fatalf("expected the whole alert, got %s", response.Content[0].TextContent.Text)
+	}
+	threshold := 25.0
+	if _, err := UpdateAlertHandler(context.Background(), UpdateAlertHandlerArgs{AlertId: "alert-1", Threshold: &threshold}); err == nil {
+		t.Fatalf("expected the update to refuse an alert it can't send back whole")
+	}
+}
+
+func TestUpdateAlertHandler(t *testing.T) {
+	server := newAlertTestServer(t, newTestAlert())
+	threshold := 25.0
+	window := int64(5)
+	query := " sum(errors) / sum(requests) "
+	arguments := UpdateAlertHandlerArgs{AlertId: "alert-1", Threshold: &threshold, EvaluationWindow: &window, Query: &query, DryRun: true}
+
+	response, err := UpdateAlertHandler(context.Background(), arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	result := decodeAlertChangeResponse(t, response.Content[0].TextContent.Text)
+	if !result.DryRun || len(result.Changes) != 3 || result.Response != nil {
+		t.Fatalf("unexpected dry run %+v", result)
+	}
+	if result.Changes[0].Path != "/timeseries/evaluationRules/0/static/operators/0/threshold" || result.Changes[0].From != float64(10) || result.Changes[0].To != float64(25) {
+		t.Fatalf("unexpected threshold change %+v", result.Changes[0])
+	}
+	if result.Changes[2].Path != "/timeseries/expression/metoroQLTimeseries/query" || result.Changes[2].To != "sum(errors) / sum(requests)" {
+		t.Fatalf("unexpected query change %+v", result.Changes[2])
+	}
+	if _, ok := server.request("POST /api/v1/alerts/update"); ok {
+		t.Fatalf("expected the dry run not to update the alert")
+	}
+
+	arguments.DryRun = false
+	if _, err := UpdateAlertHandler(context.Background(), arguments); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	body, ok := server.request("POST /api/v1/alerts/update")
+	if !ok {
+		t.Fatalf("expected the alert to be updated")
+	}
+	var request model.CreateUpdateAlertRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatalf("failed to decode update request: %v", err)
+	}
+	static := request.Alert.Timeseries.EvaluationRules[0].Static
+	if request.Alert.Metadata.Id != "alert-1" || static.Operators[0].Threshold != 25 || static.PersistenceSettings.DatapointsInEvaluationWindow != 5 {
+		t.Fatalf("unexpected update request %+v", request.Alert)
+	}
+}
+
+func TestApplyAlertUpdateDestinations(t *testing.T) {
+	alert := newTestAlert()
+	conditionType := model.STATIC
+	alert.Timeseries.EvaluationRules = append(alert.Timeseries.EvaluationRules, model.Condition{Name: "Fast", Type: &conditionType})
+
+	destinations := []AlertDestination{{Type: "slack", Channel: "#alerts"}}
+	updated, err := applyAlertUpdate(alert, UpdateAlertHandlerArgs{Destinations: &destinations})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, rule := range updated.Timeseries.EvaluationRules {
+		if len(rule.Actions) != 1 || *rule.Actions[0].SlackDestination.Channel != "#alerts" {
+			t.Fatalf("expected every rule to notify the channel, got %+v", rule)
+		}
+	}
+
+	cleared := []AlertDestination{}
+	updated, err = applyAlertUpdate(updated, UpdateAlertHandlerArgs{Destinations: &cleared, RuleName: "Fast"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(updated.Timeseries.EvaluationRules[0].Actions) != 1 || len(updated.Timeseries.EvaluationRules[1].Actions) != 0 {
+		t.Fatalf("expected only the fast rule to stop notifying, got %+v", updated.Timeseries.EvaluationRules)
+	}
+}
+
+func TestApplyAlertUpdateEvaluationRules(t *testing.T) {
+	warning, critical := 80.0, 95.0
+	rules := []AlertEvaluationRule{
+		{Name: "warning", Condition: "GreaterThan", Threshold: &warning, DatapointsToAlarm: 3, EvaluationWindow: 5},
+		{Name: "critical", Condition: "GreaterThan", Threshold: &critical, DatapointsToAlarm: 1, EvaluationWindow: 1},
+	}
+	updated, err := applyAlertUpdate(newTestAlert(), UpdateAlertHandlerArgs{EvaluationRules: &rules})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(updated.Timeseries.EvaluationRules) != 2 || updated.Timeseries.EvaluationRules[1].Name != "critical" {
+		t.Fatalf("expected the rules to be replaced, got %+v", updated.Timeseries.EvaluationRules)
+	}
+
+	if _, err := applyAlertUpdate(newTestAlert(), UpdateAlertHandlerArgs{EvaluationRules: &rules, Threshold: &warning}); err == nil {
+		t.Fatalf("expected error when combining evaluation_rules with threshold")
+	}
+	empty := []AlertEvaluationRule{}
+	if _, err := applyAlertUpdate(newTestAlert(), UpdateAlertHandlerArgs{EvaluationRules: &empty}); err == nil {
+		t.Fatalf("expected error for no rules")
+	}
+}
+
+func TestApplyAlertUpdateValidation(t *testing.T) {
+	alert := newTestAlert()
+	datapoints := int64(4)
+	if _, err := applyAlertUpdate(alert, UpdateAlertHandlerArgs{DatapointsToAlarm: &datapoints}); err == nil {
+		t.Fatalf("expected error for more datapoints to alarm than the evaluation window")
+	}
+	condition := "Above"
+	if _, err := applyAlertUpdate(alert, UpdateAlertHandlerArgs{Condition: &condition}); err == nil {
+		t.Fatalf("expected error for invalid condition")
+	}
+	threshold := 1.0
+	if _, err := applyAlertUpdate(alert, UpdateAlertHandlerArgs{Threshold: &threshold, RuleName: "Fast"}); err == nil || !strings.Contains(err.Error(), "Alert Condition") {
+		t.Fatalf("expected error listing the rules, got %v", err)
+	}
+
+	destinations := []AlertDestination{{Type: "slack"}}
+	if _, err := applyAlertUpdate(alert, UpdateAlertHandlerArgs{Destinations: &destinations}); err == nil {
+		t.Fatalf("expected error for a slack destination without channel")
+	}
+
+	updated, err := applyAlertUpdate(alert, UpdateAlertHandlerArgs{Threshold: &threshold})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if updated.Timeseries.EvaluationRules[0].Static.Operators[0].Threshold != 1 || alert.Timeseries.EvaluationRules[0].Static.Operators[0].Threshold != 10 {
+		t.Fatalf("expected only the copy to change")
+	}
+}
@@ -42,7 +42,7 @@ type getInvestigationResponse struct {
 
 func fetchInvestigationCategory(ctx context.Context, investigationUUID string) (string, error) {
 	endpoint := fmt.Sprintf("investigation?uuid=%s", investigationUUID)
-	responseBody, err := utils.MakeMetoroAPIRequest("GET", endpoint, nil, utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIRequest(ctx, "GET", endpoint, nil, utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch investigation for category validation: %w", err)
 	}
@@ -142,7 +142,7 @@ func UpdateInvestigationHandler(ctx context.Context, arguments UpdateInvestigati
 
 	// Make the API request - using PUT method for update
 	endpoint := fmt.Sprintf("investigation?uuid=%s", arguments.InvestigationUUID)
-	responseBody, err := utils.MakeMetoroAPIRequest("PUT", endpoint, bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
+	responseBody, err := utils.MakeMetoroAPIRequest(ctx, "PUT", endpoint, bytes.NewBuffer(requestBody), utils.GetAPIRequirementsFromRequest(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to update investigation: %w", err)
 	}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Errors an APIError unwraps to, so callers can check the kind of failure with errors.Is.
+var (
+	ErrMetoroUnauthorized = errors.New("metoro api rejected the credentials")
+	ErrMetoroNotFound     = errors.New("metoro api resource not found")
+	ErrMetoroRateLimited  = errors.New("metoro api rate limit exceeded")
+	ErrMetoroServer       = errors.New("metoro api server error")
+)
+
+// APIError is returned when the Metoro API answers with a non 2xx status code.
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay the API asked for in its Retry-After header, zero if it sent none.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+	if hint := e.hint(); hint != "" {
+		message = fmt.Sprintf("%s. %s", message, hint)
+	}
+	return message
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrMetoroUnauthorized
+	case e.StatusCode == http.StatusNotFound:
+		return ErrMetoroNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrMetoroRateLimited
+	case e.StatusCode >= 500:
+		return ErrMetoroServer
+	default:
+		return nil
+	}
+}
+
+// hint tells the agent what it can do about the error, it ends up in the tool error message.
+func (e *APIError) hint() string {
+	switch e.Unwrap() {
+	case ErrMetoroUnauthorized:
+		return "The Metoro auth token is missing, expired or has no access to this data. Ask the user to check their Metoro token"
+	case ErrMetoroNotFound:
+		return "The requested resource does not exist. Check the ids and names passed to the tool"
+	case ErrMetoroRateLimited:
+		if e.RetryAfter > 0 {
+			return fmt.Sprintf("The Metoro API is rate limiting requests. Wait at least %s before calling the tool again", e.RetryAfter.Round(time.Second))
+		}
+		return "The Metoro API is rate limiting requests. Wait before calling the tool again and make fewer calls"
+	case ErrMetoroServer:
+		return "The Metoro API failed to handle the request. Try again later or with a smaller time range or more filters"
+	default:
+		return ""
+	}
+}
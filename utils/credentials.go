@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+const METORO_AUTH_TOKEN_FILE_ENV_VAR = "METORO_AUTH_TOKEN_FILE"
+const METORO_ORGANIZATIONS_FILE_ENV_VAR = "METORO_ORGANIZATIONS_FILE"
+
+// METORO_ORGANIZATION_HEADER lets an HTTP caller pick one of the organisations in the organisations file.
+const METORO_ORGANIZATION_HEADER = "X-Metoro-Organization"
+
+// OrganizationConfig is one named Metoro organisation the server can talk to.
+// ApiUrl falls back to METORO_API_URL when empty. AuthToken takes precedence over AuthTokenFile.
+type OrganizationConfig struct {
+	ApiUrl        string `json:"apiUrl"`
+	AuthToken     string `json:"authToken"`
+	AuthTokenFile string `json:"authTokenFile"`
+}
+
+// OrganizationsConfig is the content of the file METORO_ORGANIZATIONS_FILE points to, e.g.
+//
+//	{
+//	  "defaultOrganization": "prod",
+//	  "organizations": {
+//	    "prod": {"apiUrl": "https://us-east.metoro.io", "authTokenFile": "/secrets/metoro-prod"},
+//	    "staging": {"apiUrl": "https://us-east.metoro.io", "authTokenFile": "/secrets/metoro-staging"}
+//	  }
+//	}
+type OrganizationsConfig struct {
+	DefaultOrganization string                        `json:"defaultOrganization"`
+	Organizations       map[string]OrganizationConfig `json:"organizations"`
+}
+
+// ResolveCredentials works out which Metoro API and token a call should use. headers are the headers of the
+// HTTP request that started the call and may be nil (e.g. in stdio mode).
+//
+// The API url comes from the selected organisation or METORO_API_URL. The token comes from, in order:
+// the Authorization header, the selected organisation, and finally METORO_AUTH_TOKEN or METORO_AUTH_TOKEN_FILE.
+// Organisations picked explicitly through the X-Metoro-Organization header never fall back to the
+// server-wide token so a call can't silently land in the wrong organisation.
+func ResolveCredentials(headers http.Header) (*APIRequirements, error) {
+	organizations, err := LoadOrganizationsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	requestedOrganization := ""
+	authHeader := ""
+	if headers != nil {
+		requestedOrganization = strings.TrimSpace(headers.Get(METORO_ORGANIZATION_HEADER))
+		authHeader = strings.TrimSpace(headers.Get("Authorization"))
+	}
+
+	var organization *OrganizationConfig
+	organizationName := requestedOrganization
+	if organizationName == "" && organizations != nil {
+		organizationName = organizations.DefaultOrganization
+	}
+	if organizationName != "" {
+		if organizations == nil {
+			return nil, fmt.Errorf("unknown Metoro organization %q: %s is not set", organizationName, METORO_ORGANIZATIONS_FILE_ENV_VAR)
+		}
+		org, ok := organizations.Organizations[organizationName]
+		if !ok {
+			return nil, fmt.Errorf("unknown Metoro organization %q, must be one of: %s", organizationName, strings.Join(organizations.organizationNames(), ", "))
+		}
+		organization = &org
+	}
+
+	metoroUrl := os.Getenv(METORO_API_URL_ENV_VAR)
+	if organization != nil && organization.ApiUrl != "" {
+		metoroUrl = organization.ApiUrl
+	}
+	if metoroUrl == "" {
+		return nil, fmt.Errorf("no Metoro API url configured: set %s or apiUrl for the organization", METORO_API_URL_ENV_VAR)
+	}
+
+	if authHeader == "" && organization != nil {
+		token, err := readToken(organization.AuthToken, organization.AuthTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading auth token for Metoro organization %q: %v", organizationName, err)
+		}
+		if token != "" {
+			authHeader = "Bearer " + token
+		}
+	}
+	if authHeader == "" && requestedOrganization == "" {
+		token, err := readToken(os.Getenv(METORO_AUTH_TOKEN_ENV_VAR), os.Getenv(METORO_AUTH_TOKEN_FILE_ENV_VAR))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", METORO_AUTH_TOKEN_FILE_ENV_VAR, err)
+		}
+		if token != "" {
+			authHeader = "Bearer " + token
+		}
+	}
+	if authHeader == "" {
+		return nil, fmt.Errorf("no Metoro auth token: send an Authorization header or set %s or %s", METORO_AUTH_TOKEN_ENV_VAR, METORO_AUTH_TOKEN_FILE_ENV_VAR)
+	}
+
+	return &APIRequirements{
+		authHeader: authHeader,
+		metoroUrl:  strings.TrimRight(metoroUrl, "/"),
+	}, nil
+}
+
+// LoadOrganizationsConfig reads the organisations file. It returns nil when METORO_ORGANIZATIONS_FILE is not set.
+// The file is re-read on every call so rotated tokens and new organisations are picked up without a restart.
+func LoadOrganizationsConfig() (*OrganizationsConfig, error) {
+	path := strings.TrimSpace(os.Getenv(METORO_ORGANIZATIONS_FILE_ENV_VAR))
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", METORO_ORGANIZATIONS_FILE_ENV_VAR, err)
+	}
+
+	var config OrganizationsConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", METORO_ORGANIZATIONS_FILE_ENV_VAR, err)
+	}
+	if config.DefaultOrganization != "" {
+		if _, ok := config.Organizations[config.DefaultOrganization]; !ok {
+			return nil, fmt.Errorf("defaultOrganization %q is not defined in %s", config.DefaultOrganization, path)
+		}
+	}
+
+	return &config, nil
+}
+
+func (c *OrganizationsConfig) organizationNames() []string {
+	names := make([]string, 0, len(c.Organizations))
+	for name := range c.Organizations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readToken(token string, tokenFile string) (string, error) {
+	if token = strings.TrimSpace(token); token != "" {
+		return token, nil
+	}
+	if tokenFile = strings.TrimSpace(tokenFile); tokenFile == "" {
+		return "", nil
+	}
+
+	content, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
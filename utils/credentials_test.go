@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setCredentialEnv(t *testing.T, apiURL, token, tokenFile, organizationsFile string) {
+	t.Helper()
+	t.Setenv(METORO_API_URL_ENV_VAR, apiURL)
+	t.Setenv(METORO_AUTH_TOKEN_ENV_VAR, token)
+	t.Setenv(METORO_AUTH_TOKEN_FILE_ENV_VAR, tokenFile)
+	t.Setenv(METORO_ORGANIZATIONS_FILE_ENV_VAR, organizationsFile)
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+const testOrganizationsFile = `{
+  "defaultOrganization": "prod",
+  "organizations": {
+    "prod": {"apiUrl": "https://prod.metoro.test/", "authToken": "prod-token"},
+    "staging": {"apiUrl": "https://staging.metoro.test"}
+  }
+}`
+
+func TestResolveCredentialsUsesEnvToken(t *testing.T) {
+	setCredentialEnv(t, "https://metoro.test", "env-token", "", "")
+
+	requirements, err := ResolveCredentials(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requirements.metoroUrl != "https://metoro.test" || requirements.authHeader != "Bearer env-token" {
+		t.Fatalf("unexpected requirements: %+v", requirements)
+	}
+}
+
+func TestResolveCredentialsReadsTokenFile(t *testing.T) {
+	tokenFile := writeTestFile(t, "token", "file-token\n")
+	setCredentialEnv(t, "https://metoro.test", "", tokenFile, "")
+
+	requirements, err := ResolveCredentials(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requirements.authHeader != "Bearer file-token" {
+		t.Fatalf("expected token from file, got %q", requirements.authHeader)
+	}
+}
+
+func TestResolveCredentialsPrefersAuthorizationHeader(t *testing.T) {
+	setCredentialEnv(t, "https://metoro.test", "env-token", "", "")
+
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer caller-token")
+	requirements, err := ResolveCredentials(headers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requirements.metoroUrl != "https://metoro.test" || requirements.authHeader != "Bearer caller-token" {
+		t.Fatalf("unexpected requirements: %+v", requirements)
+	}
+}
+
+func TestResolveCredentialsRequiresToken(t *testing.T) {
+	setCredentialEnv(t, "https://metoro.test", "", "", "")
+
+	_, err := ResolveCredentials(http.Header{})
+	if err == nil || !strings.Contains(err.Error(), "no Metoro auth token") {
+		t.Fatalf("expected missing token error, got %v", err)
+	}
+}
+
+func TestResolveCredentialsUsesDefaultOrganization(t *testing.T) {
+	organizationsFile := writeTestFile(t, "organizations.json", testOrganizationsFile)
+	setCredentialEnv(t, "", "", "", organizationsFile)
+
+	requirements, err := ResolveCredentials(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requirements.metoroUrl != "https://prod.metoro.test" || requirements.authHeader != "Bearer prod-token" {
+		t.Fatalf("unexpected requirements: %+v", requirements)
+	}
+}
+
+func TestResolveCredentialsSelectsOrganizationFromHeader(t *testing.T) {
+	organizationsFile := writeTestFile(t, "organizations.json", testOrganizationsFile)
+	setCredentialEnv(t, "", "env-token", "", organizationsFile)
+
+	headers := http.Header{}
+	headers.Set(METORO_ORGANIZATION_HEADER, "staging")
+	headers.Set("Authorization", "Bearer staging-caller")
+	requirements, err := ResolveCredentials(headers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requirements.metoroUrl != "https://staging.metoro.test" || requirements.authHeader != "Bearer staging-caller" {
+		t.Fatalf("unexpected requirements: %+v", requirements)
+	}
+
+	// Without a caller token the staging organisation must not borrow the server-wide token.
+	headers.Del("Authorization")
+	if _, err := ResolveCredentials(headers); err == nil {
+		t.Fatalf("expected error when selected organization has no token")
+	}
+}
+
+func TestResolveCredentialsRejectsUnknownOrganization(t *testing.T) {
+	organizationsFile := writeTestFile(t, "organizations.json", testOrganizationsFile)
+	setCredentialEnv(t, "", "", "", organizationsFile)
+
+	headers := http.Header{}
+	headers.Set(METORO_ORGANIZATION_HEADER, "dev")
+	_, err := ResolveCredentials(headers)
+	if err == nil || !strings.Contains(err.Error(), "prod, staging") {
+		t.Fatalf("expected unknown organization error listing known ones, got %v", err)
+	}
+}
@@ -1,22 +1,47 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 const METORO_API_URL_ENV_VAR = "METORO_API_URL"
 const METORO_AUTH_TOKEN_ENV_VAR = "METORO_AUTH_TOKEN"
+const METORO_API_REQUEST_TIMEOUT_ENV_VAR = "METORO_API_REQUEST_TIMEOUT"
+const METORO_API_MAX_RETRIES_ENV_VAR = "METORO_API_MAX_RETRIES"
+
+const (
+	defaultAPIRequestTimeout = 60 * time.Second
+	defaultAPIMaxRetries     = 3
+	apiRetryMaxDelay         = 10 * time.Second
+	// Retry-After values above this are not waited for, the rate limited error is returned straight away.
+	apiRetryAfterMaxDelay = 30 * time.Second
+)
+
+// apiRetryBaseDelay is the backoff before the first retry, it doubles on every further retry.
+var apiRetryBaseDelay = 500 * time.Millisecond
+
+// metoroHTTPClient is shared by all requests so connections to the Metoro API are reused.
+var metoroHTTPClient = &http.Client{}
 
 type APIRequirements struct {
 	authHeader string
 	metoroUrl  string
+	// err is set when the credentials for the request could not be resolved, it is returned by MakeMetoroAPIRequest.
+	err error
 }
 
 func GetAPIRequirementsFromRequest(ctx context.Context) *APIRequirements {
@@ -29,53 +54,213 @@ func GetAPIRequirementsFromRequest(ctx context.Context) *APIRequirements {
 		return nil
 	}
 
-	if ginContext.Request.Header.Get("Authorization") != "" {
-		return &APIRequirements{
-			authHeader: ginContext.Request.Header.Get("Authorization"),
-			metoroUrl:  "http://localhost:8080",
-		}
+	apiRequirements, err := ResolveCredentials(ginContext.Request.Header)
+	if err != nil {
+		return &APIRequirements{err: err}
 	}
-	return nil
+	return apiRequirements
+}
+
+// MakeMetoroAPIRequest makes an HTTP request to the Metoro API with the given method, endpoint, and body.
+// It handles authentication and common error cases. Every attempt is bounded by METORO_API_REQUEST_TIMEOUT and
+// cancelled together with ctx. Rate limited (429), server error (5xx) and connection failures are retried with
+// exponential backoff, so it must only be used for idempotent endpoints. Use MakeMetoroAPIWriteRequest for
+// endpoints that create or change data.
+func MakeMetoroAPIRequest(ctx context.Context, method, endpoint string, body io.Reader, apiRequirements *APIRequirements) ([]byte, error) {
+	return doMetoroAPIRequest(ctx, method, endpoint, body, apiRequirements, true)
 }
 
-// makeMetoroAPIRequest makes an HTTP request to the Metoro API with the given method, endpoint, and body.
-// It handles authentication and common error cases.
-func MakeMetoroAPIRequest(method, endpoint string, body io.Reader, apiRequirements *APIRequirements) ([]byte, error) {
-	// Create a new HTTP client
-	client := &http.Client{}
+// MakeMetoroAPIWriteRequest is MakeMetoroAPIRequest for endpoints that are not safe to repeat. Only requests the
+// API rejected up front with a 429 are retried, anything else may already have been applied.
+func MakeMetoroAPIWriteRequest(ctx context.Context, method, endpoint string, body io.Reader, apiRequirements *APIRequirements) ([]byte, error) {
+	return doMetoroAPIRequest(ctx, method, endpoint, body, apiRequirements, false)
+}
+
+func doMetoroAPIRequest(ctx context.Context, method, endpoint string, body io.Reader, apiRequirements *APIRequirements, idempotent bool) ([]byte, error) {
 	if apiRequirements == nil {
-		apiRequirements = &APIRequirements{
-			authHeader: "Bearer " + os.Getenv(METORO_AUTH_TOKEN_ENV_VAR),
-			metoroUrl:  os.Getenv(METORO_API_URL_ENV_VAR),
+		var err error
+		apiRequirements, err = ResolveCredentials(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if apiRequirements.err != nil {
+		return nil, apiRequirements.err
+	}
+
+	// The body is buffered so it can be sent again on retries.
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body: %v", err)
 		}
 	}
 
+	url := fmt.Sprintf("%s/api/v1/%s", apiRequirements.metoroUrl, endpoint)
+	timeout := getAPIRequestTimeout()
+	maxRetries := getAPIMaxRetries()
+	for attempt := 0; ; attempt++ {
+		responseBody, err := sendMetoroAPIRequest(ctx, method, url, endpoint, payload, apiRequirements.authHeader, timeout)
+		if err == nil {
+			return responseBody, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("request to %s was cancelled: %w", endpoint, ctx.Err())
+		}
+
+		delay, retryable := retryDelay(err, attempt, idempotent)
+		if !retryable || attempt >= maxRetries {
+			return nil, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("request to %s was cancelled: %w", endpoint, ctx.Err())
+		}
+	}
+}
+
+func sendMetoroAPIRequest(ctx context.Context, method, url, endpoint string, payload []byte, authHeader string, timeout time.Duration) ([]byte, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
 	// Create a new request
-	req, err := http.NewRequest(method, fmt.Sprintf("%s/api/v1/%s", apiRequirements.metoroUrl, endpoint), body)
+	req, err := http.NewRequestWithContext(attemptCtx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %v", err)
 	}
 
 	// Add the Authorization header
-	req.Header.Add("Authorization", apiRequirements.authHeader)
+	req.Header.Add("Authorization", authHeader)
 
 	// Send the request
-	resp, err := client.Do(req)
+	resp, err := metoroHTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %v", err)
+		return nil, fmt.Errorf("error sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read the response body
-	responseBody, err := ioutil.ReadAll(resp.Body)
+	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
+		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
 
 	// Check the response status code
 	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(responseBody))
+		return nil, &APIError{
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+			Body:       string(responseBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
 	return responseBody, nil
 }
+
+// retryDelay decides whether a failed attempt should be retried and how long to wait before doing so.
+func retryDelay(err error, attempt int, idempotent bool) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+		case apiErr.StatusCode >= 500 && idempotent:
+		default:
+			return 0, false
+		}
+		if apiErr.RetryAfter > apiRetryAfterMaxDelay {
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			return apiErr.RetryAfter, true
+		}
+		return backoffDelay(attempt), true
+	}
+
+	if idempotent && isRetryableConnectionError(err) {
+		return backoffDelay(attempt), true
+	}
+	return 0, false
+}
+
+func backoffDelay(attempt int) time.Duration {
+	delay := apiRetryMaxDelay
+	if attempt < 16 {
+		delay = min(apiRetryBaseDelay<<attempt, apiRetryMaxDelay)
+	}
+	// Jitter so concurrent tool calls don't retry in lockstep.
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func isRetryableConnectionError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	// The per attempt timeout expired, the caller's own context is checked before this is called.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if retryAt, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(retryAt); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+func getAPIRequestTimeout() time.Duration {
+	value := strings.TrimSpace(os.Getenv(METORO_API_REQUEST_TIMEOUT_ENV_VAR))
+	if value == "" {
+		return defaultAPIRequestTimeout
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return defaultAPIRequestTimeout
+	}
+
+	return parsed
+}
+
+func getAPIMaxRetries() int {
+	value := strings.TrimSpace(os.Getenv(METORO_API_MAX_RETRIES_ENV_VAR))
+	if value == "" {
+		return defaultAPIMaxRetries
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return defaultAPIMaxRetries
+	}
+
+	return parsed
+}
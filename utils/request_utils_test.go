@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setRetryTestEnv(t *testing.T, apiURL string) {
+	t.Helper()
+	setCredentialEnv(t, apiURL, "test-token", "", "")
+	t.Setenv(METORO_API_REQUEST_TIMEOUT_ENV_VAR, "")
+	t.Setenv(METORO_API_MAX_RETRIES_ENV_VAR, "")
+
+	oldDelay := apiRetryBaseDelay
+	apiRetryBaseDelay = time.Millisecond
+	t.Cleanup(func() {
+		apiRetryBaseDelay = oldDelay
+	})
+}
+
+func TestMakeMetoroAPIRequestRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"query":true}` {
+			t.Errorf("expected request body to be resent on retry, got %q", string(body))
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`ok`))
+	}))
+	defer server.Close()
+	setRetryTestEnv(t, server.URL)
+
+	resp, err := MakeMetoroAPIRequest(context.Background(), "POST", "logs", strings.NewReader(`{"query":true}`), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp) != "ok" || calls.Load() != 3 {
+		t.Fatalf("expected success on third attempt, got %q after %d calls", string(resp), calls.Load())
+	}
+}
+
+func TestMakeMetoroAPIRequestStopsAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	setRetryTestEnv(t, server.URL)
+	t.Setenv(METORO_API_MAX_RETRIES_ENV_VAR, "2")
+
+	_, err := MakeMetoroAPIRequest(context.Background(), "GET", "services", nil, nil)
+	if !errors.Is(err, ErrMetoroServer) {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestMakeMetoroAPIWriteRequestDoesNotRetryServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	setRetryTestEnv(t, server.URL)
+
+	_, err := MakeMetoroAPIWriteRequest(context.Background(), "POST", "investigation", strings.NewReader(`{}`), nil)
+	if !errors.Is(err, ErrMetoroServer) {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single attempt for a write request, got %d", calls.Load())
+	}
+}
+
+func TestMakeMetoroAPIRequestHonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`ok`))
+	}))
+	defer server.Close()
+	setRetryTestEnv(t, server.URL)
+
+	start := time.Now()
+	_, err := MakeMetoroAPIWriteRequest(context.Background(), "POST", "investigation", strings.NewReader(`{}`), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected to wait for Retry-After, only waited %s", elapsed)
+	}
+}
+
+func TestMakeMetoroAPIRequestReturnsTypedErrors(t *testing.T) {
+	cases := []struct {
+		status   int
+		expected error
+	}{
+		{status: http.StatusUnauthorized, expected: ErrMetoroUnauthorized},
+		{status: http.StatusForbidden, expected: ErrMetoroUnauthorized},
+		{status: http.StatusNotFound, expected: ErrMetoroNotFound},
+	}
+
+	for _, tc := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+		}))
+		setRetryTestEnv(t, server.URL)
+
+		_, err := MakeMetoroAPIRequest(context.Background(), "GET", "services", nil, nil)
+		server.Close()
+		if !errors.Is(err, tc.expected) {
+			t.Fatalf("status %d: expected %v, got %v", tc.status, tc.expected, err)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status || apiErr.Endpoint != "services" {
+			t.Fatalf("status %d: expected APIError with status and endpoint, got %#v", tc.status, err)
+		}
+	}
+}
+
+func TestMakeMetoroAPIRequestStopsWhenContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	setRetryTestEnv(t, server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := MakeMetoroAPIRequest(ctx, "GET", "services", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected request to stop with the context, took %s", elapsed)
+	}
+}
+
+func TestMakeMetoroAPIRequestTimesOutHungAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(`ok`))
+	}))
+	defer server.Close()
+	setRetryTestEnv(t, server.URL)
+	t.Setenv(METORO_API_REQUEST_TIMEOUT_ENV_VAR, "50ms")
+
+	resp, err := MakeMetoroAPIRequest(context.Background(), "GET", "services", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp) != "ok" {
+		t.Fatalf("expected retry after the hung attempt, got %q", string(resp))
+	}
+}
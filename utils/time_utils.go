@@ -38,10 +38,30 @@ type TimeConfig struct {
 	EndTime   *string `json:"end_time,omitempty" jsonschema:"description=For absolute time range: end time in RFC3339 format (e.g., '2024-12-12T14:27:22Z')"`
 }
 
+// prodMaxLookback is how far back the start of a time range can be in prod
+const prodMaxLookback = 30 * 24 * time.Hour
+
+// EarliestStartTime returns the earliest start of a time range at now. It is only limited in prod
+func EarliestStartTime(now time.Time) (time.Time, bool) {
+	if os.Getenv("IS_PROD") != "true" {
+		return time.Time{}, false
+	}
+	return now.Add(-prodMaxLookback), true
+}
+
 // CalculateTimeRange returns start and end timestamps based on the time configuration
 func CalculateTimeRange(config TimeConfig) (startTime, endTime int64, err error) {
+	return CalculateTimeRangeWithOffset(config, 0)
+}
+
+// CalculateTimeRangeWithOffset returns start and end timestamps based on the time configuration shifted back by the
+// offset, e.g. the same time range one day earlier
+func CalculateTimeRangeWithOffset(config TimeConfig, offset time.Duration) (startTime, endTime int64, err error) {
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("time offset cannot be negative")
+	}
 	now := time.Now()
-	thirtyDaysAgo := now.Add(-30 * 24 * time.Hour)
+	earliestStartTime, limited := EarliestStartTime(now)
 
 	switch config.Type {
 	case RelativeTimeRange:
@@ -63,14 +83,15 @@ func CalculateTimeRange(config TimeConfig) (startTime, endTime int64, err error)
 			return 0, 0, fmt.Errorf("invalid time window: %s", *config.TimeWindow)
 		}
 
-		startTimeObj := now.Add(-duration)
+		endTimeObj := now.Add(-offset)
+		startTimeObj := endTimeObj.Add(-duration)
 
 		// Check if the start time is more than 30 days ago in Prod.
-		if os.Getenv("IS_PROD") == "true" && startTimeObj.Before(thirtyDaysAgo) {
+		if limited && startTimeObj.Before(earliestStartTime) {
 			return 0, 0, fmt.Errorf("time range cannot exceed 30 days ago, please adjust the time_period or time_window")
 		}
 
-		return startTimeObj.Unix(), now.Unix(), nil
+		return startTimeObj.Unix(), endTimeObj.Unix(), nil
 
 	case AbsoluteTimeRange:
 		if config.StartTime == nil || config.EndTime == nil {
@@ -81,18 +102,20 @@ func CalculateTimeRange(config TimeConfig) (startTime, endTime int64, err error)
 		if err != nil {
 			return 0, 0, fmt.Errorf("invalid start_time format: %v", err)
 		}
+		startTimeObj = startTimeObj.Add(-offset)
 
 		endTimeObj, err := time.Parse(time.RFC3339, *config.EndTime)
 		if err != nil {
 			return 0, 0, fmt.Errorf("invalid end_time format: %v", err)
 		}
+		endTimeObj = endTimeObj.Add(-offset)
 
 		if endTimeObj.Before(startTimeObj) {
 			return 0, 0, fmt.Errorf("end_time cannot be before start_time")
 		}
 
 		// Check if the start time is more than 30 days ago
-		if os.Getenv("IS_PROD") == "true" && startTimeObj.Before(thirtyDaysAgo) {
+		if limited && startTimeObj.Before(earliestStartTime) {
 			return 0, 0, fmt.Errorf("time range cannot exceed 30 days")
 		}
 
@@ -241,6 +241,42 @@ func TestCalculateTimeRange(t *testing.T) {
 	}
 }
 
+func TestCalculateTimeRangeWithOffset(t *testing.T) {
+	start := "2026-02-19T10:00:00Z"
+	end := "2026-02-19T11:00:00Z"
+	absolute := TimeConfig{Type: AbsoluteTimeRange, StartTime: &start, EndTime: &end}
+	startTime, endTime, err := CalculateTimeRangeWithOffset(absolute, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("CalculateTimeRangeWithOffset() unexpected error = %v", err)
+	}
+	if startTime != 1771408800 || endTime != 1771412400 {
+		t.Errorf("CalculateTimeRangeWithOffset() = %v, %v, want the range one day earlier", startTime, endTime)
+	}
+
+	t.Setenv("IS_PROD", "true")
+	period := 2
+	window := Hours
+	relative := TimeConfig{Type: RelativeTimeRange, TimePeriod: &period, TimeWindow: &window}
+	startTime, endTime, err = CalculateTimeRangeWithOffset(relative, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("CalculateTimeRangeWithOffset() unexpected error = %v", err)
+	}
+	if endTime-startTime != 7200 {
+		t.Errorf("CalculateTimeRangeWithOffset() time period = %v seconds, want 7200", endTime-startTime)
+	}
+	if diff := abs(endTime - time.Now().Add(-7*24*time.Hour).Unix()); diff > 1 {
+		t.Errorf("CalculateTimeRangeWithOffset() endTime is not a week ago. diff = %v seconds", diff)
+	}
+
+	// The shifted range is also limited to the last 30 days.
+	if _, _, err := CalculateTimeRangeWithOffset(relative, 30*24*time.Hour); err == nil || !strings.Contains(err.Error(), "time range cannot exceed 30 days") {
+		t.Errorf("CalculateTimeRangeWithOffset() error = %v, want 30 day limit error", err)
+	}
+	if _, _, err := CalculateTimeRangeWithOffset(relative, -time.Hour); err == nil {
+		t.Errorf("CalculateTimeRangeWithOffset() error = nil, want error for negative offset")
+	}
+}
+
 func abs(x int64) int64 {
 	if x < 0 {
 		return -x